@@ -0,0 +1,78 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"path/filepath"
+
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newApplyCmd() *cobra.Command {
+	opt := manager.ApplyOptions{}
+	deployOpt := manager.DeployOptions{
+		IdentityFile: filepath.Join(utils.UserHome(), ".ssh", "id_rsa"),
+	}
+	cmd := &cobra.Command{
+		Use:   "apply <cluster-name> <topology.yaml>",
+		Short: "Reconcile a TiDB cluster with a topology file",
+		Long: `Reconcile a TiDB cluster with a topology file. The topology file is compared
+with the deployed cluster, instances only defined in the file are scaled out,
+instances missing from the file are scaled in, changed configs are reloaded and
+version changes are upgraded.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			topoFile := args[1]
+
+			return cm.Apply(
+				clusterName,
+				topoFile,
+				opt,
+				deployOpt,
+				postScaleOutHook,
+				final,
+				skipConfirm,
+				gOpt,
+			)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			case 1:
+				return nil, cobra.ShellCompDirectiveDefault
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opt.Version, "version", "", "Upgrade the cluster to the specified version, keep the current version if not set")
+	cmd.Flags().StringVarP(&deployOpt.User, "user", "u", utils.CurrentUser(), "The user name to login via SSH. The user must has root (or sudo) privilege.")
+	cmd.Flags().BoolVarP(&deployOpt.SkipCreateUser, "skip-create-user", "", false, "(EXPERIMENTAL) Skip creating the user specified in topology.")
+	cmd.Flags().StringVarP(&deployOpt.IdentityFile, "identity_file", "i", deployOpt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used, along with the OpenSSH certificate <identity_file>-cert.pub if it exists.")
+	cmd.Flags().BoolVarP(&deployOpt.UsePassword, "password", "p", false, "Use password of target hosts. If specified, password authentication will be used.")
+	cmd.Flags().BoolVar(&deployOpt.UseSSHAgent, "ssh-agent", false, "Use the keys in the ssh-agent of SSH_AUTH_SOCK instead of the identity file, e.g. the keys stored in hardware tokens.")
+	cmd.Flags().BoolVarP(&deployOpt.NoLabels, "no-labels", "", false, "Don't check TiKV labels")
+	cmd.Flags().Uint64Var(&gOpt.APITimeout, "transfer-timeout", 600, "Timeout in seconds when transferring PD and TiKV store leaders, also for TiCDC drain one capture")
+	cmd.Flags().BoolVarP(&gOpt.IgnoreConfigCheck, "ignore-config-check", "", false, "Ignore the config check result")
+
+	return cmd
+}
@@ -24,6 +24,7 @@ import (
 var retainDays int
 
 func newAuditCmd() *cobra.Command {
+	showCommands := false
 	cmd := &cobra.Command{
 		Use:   "audit [audit-id]",
 		Short: "Show audit log of cluster operation",
@@ -32,12 +33,16 @@ func newAuditCmd() *cobra.Command {
 			case 0:
 				return audit.ShowAuditList(spec.AuditDir())
 			case 1:
+				if showCommands {
+					return audit.ShowAuditCommands(spec.AuditDir(), args[0], gOpt.DisplayMode)
+				}
 				return audit.ShowAuditLog(spec.AuditDir(), args[0])
 			default:
 				return cmd.Help()
 			}
 		},
 	}
+	cmd.Flags().BoolVar(&showCommands, "commands", false, "Show the commands executed on the hosts in the operation")
 	cmd.AddCommand(newAuditCleanupCmd())
 	return cmd
 }
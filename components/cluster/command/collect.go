@@ -0,0 +1,64 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/spf13/cobra"
+)
+
+func newCollectCmd() *cobra.Command {
+	opt := manager.CollectOptions{}
+	cmd := &cobra.Command{
+		Use:   "collect <cluster-name>",
+		Short: "Collect a diagnostic bundle of a TiDB cluster",
+		Long: `Collect a diagnostic bundle of a TiDB cluster for a support case. The logs
+in the --since duration, the configs and run scripts of the instances, the
+output of insight and dmesg on the hosts, the meta and the recent audit logs
+of the cluster are packed into a tar.gz file with an index.json. The passwords
+and other secrets in the files are redacted.
+
+The log files modified in the --since duration are collected. The ones in the
+unified log format of TiDB, TiKV, PD and TiFlash are trimmed on the hosts to
+the lines in the duration, the others, e.g. the slow query logs, are included
+as a whole and may contain older lines.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			return cm.Collect(clusterName, opt, gOpt)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only collect specified roles")
+	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only collect specified nodes")
+	cmd.Flags().DurationVar(&opt.Since, "since", 2*time.Hour, "Collect the logs in the duration, the log files in other formats than the unified one are collected as a whole, 0 for all the logs")
+	cmd.Flags().StringVarP(&opt.Output, "output", "o", "", "The path of the bundle, <cluster-name>-collect-<time>.tar.gz by default")
+	cmd.Flags().IntVarP(&opt.Limit, "limit", "l", 0, "Limits the used bandwidth, specified in Kbit/s")
+	cmd.Flags().BoolVar(&opt.Compress, "compress", false, "Compression enable. Passes the -C flag to ssh(1) to enable compression.")
+
+	return cmd
+}
@@ -66,10 +66,13 @@ func newDeploy() *cobra.Command {
 
 	cmd.Flags().StringVarP(&opt.User, "user", "u", utils.CurrentUser(), "The user name to login via SSH. The user must has root (or sudo) privilege.")
 	cmd.Flags().BoolVarP(&opt.SkipCreateUser, "skip-create-user", "", false, "(EXPERIMENTAL) Skip creating the user specified in topology.")
-	cmd.Flags().StringVarP(&opt.IdentityFile, "identity_file", "i", opt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used.")
+	cmd.Flags().BoolVar(&opt.NoSudo, "no-sudo", false, "Deploy by the deploy user without sudo, the hosts need to be prepared by root with the scripts generated, and the services are managed by the systemd user instance.")
+	cmd.Flags().StringVarP(&opt.IdentityFile, "identity_file", "i", opt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used, along with the OpenSSH certificate <identity_file>-cert.pub if it exists.")
 	cmd.Flags().BoolVarP(&opt.UsePassword, "password", "p", false, "Use password of target hosts. If specified, password authentication will be used.")
+	cmd.Flags().BoolVar(&opt.UseSSHAgent, "ssh-agent", false, "Use the keys in the ssh-agent of SSH_AUTH_SOCK instead of the identity file, e.g. the keys stored in hardware tokens.")
 	cmd.Flags().BoolVarP(&gOpt.IgnoreConfigCheck, "ignore-config-check", "", false, "Ignore the config check result of components")
 	cmd.Flags().BoolVarP(&opt.NoLabels, "no-labels", "", false, "Don't check TiKV labels")
+	cmd.Flags().IntVar(&gOpt.PeerFanout, "p2p-fanout", 0, "Upload the packages to this many hosts at a time from the control machine, and let the other hosts fetch them from the hosts holding them over SSH, 0 to upload to all hosts from the control machine")
 
 	return cmd
 }
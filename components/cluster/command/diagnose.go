@@ -0,0 +1,59 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/spf13/cobra"
+)
+
+func newDiagnoseCmd() *cobra.Command {
+	opt := manager.DiagnoseOptions{}
+	cmd := &cobra.Command{
+		Use:   "diagnose <cluster-name>",
+		Short: "Report the health of a TiDB cluster",
+		Long: `Report the health of a TiDB cluster. The status of the instances, the
+states of the stores and the unhealthy regions in PD, the expiry of the TLS
+certificates, the versions and patches of the instances and the checks of the
+hosts are aggregated into findings ranked by severity, each with a suggested
+TiUP command. Use --format json or --output to get a report which can be
+attached to tickets.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			return cm.Diagnose(clusterName, opt, gOpt)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only diagnose specified roles")
+	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only diagnose specified nodes")
+	cmd.Flags().BoolVar(&opt.SkipCheck, "skip-check", false, "Skip the checks of the system on the hosts")
+	cmd.Flags().IntVar(&opt.CertExpiryDays, "cert-expiry-days", 30, "Report the TLS certificates expiring in the days")
+	cmd.Flags().StringVarP(&opt.OutputFile, "output", "o", "", "Also write the report in JSON to the file")
+	cmd.Flags().StringVarP(&opt.TempDir, "tempdir", "t", "/tmp/tiup", "The temporary directory of the checks on the hosts.")
+	cmd.Flags().Uint64Var(&gOpt.APITimeout, "api-timeout", 10, "Timeout in seconds when querying PD APIs.")
+
+	return cmd
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/spf13/cobra"
+)
+
+func newDriftCmd() *cobra.Command {
+	opt := manager.DriftOptions{}
+	cmd := &cobra.Command{
+		Use:   "drift <cluster-name>",
+		Short: "Detect changes made on the hosts of a TiDB cluster",
+		Long: `Detect changes made on the hosts of a TiDB cluster. The config files, run
+scripts and systemd units on the hosts are compared with the ones generated
+from the topology, and the binaries are compared with the ones of the cluster
+version. Use --adopt to save the config items changed on the hosts into the
+topology, so that they are not overwritten by the next reload.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			return cm.Drift(clusterName, opt, gOpt, skipConfirm)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only check specified roles")
+	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only check specified nodes")
+	cmd.Flags().BoolVar(&opt.Adopt, "adopt", false, "Save the config items changed on the hosts into the topology")
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newLockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Show or break the lock of a cluster",
+		Long: `Every operation that changes a cluster holds the lock of the cluster, so
+that concurrent operations on the same cluster are refused.`,
+	}
+
+	validArgs := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return shellCompGetClusterName(cm, toComplete)
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status <cluster-name>",
+		Short: "Show the owner of the lock of a cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.LockStatus(args[0])
+		},
+		ValidArgsFunction: validArgs,
+	}
+
+	breakCmd := &cobra.Command{
+		Use:   "break <cluster-name>",
+		Short: "Break the lock of a cluster left by an exited operation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.BreakLock(args[0], skipConfirm)
+		},
+		ValidArgsFunction: validArgs,
+	}
+
+	cmd.AddCommand(statusCmd, breakCmd)
+	return cmd
+}
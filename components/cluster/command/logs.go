@@ -0,0 +1,65 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/spf13/cobra"
+)
+
+func newLogsCmd() *cobra.Command {
+	opt := manager.LogsOptions{}
+	cmd := &cobra.Command{
+		Use:   "logs <cluster-name>",
+		Short: "Show the logs of the instances of a TiDB cluster",
+		Long: `Show the logs of the instances of a TiDB cluster. The lines of the current
+log files of the instances are prefixed with the instance IDs and merged by
+the timestamps of the unified log format of TiDB, TiKV, PD and TiFlash. The
+lines without a timestamp, e.g. the stack traces, follow the previous line.
+Use --follow to keep showing the new lines until it's interrupted.
+
+The --grep pattern is a Go regular expression matched on the control machine,
+it's searched in the last 100000 lines of each log unless --tail is -1.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			// all the lines since the time are shown unless --tail is specified
+			if opt.Since > 0 && !cmd.Flags().Changed("tail") {
+				opt.Tail = -1
+			}
+
+			clusterName := args[0]
+			return cm.Logs(clusterName, opt, gOpt)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only show the logs of specified roles")
+	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only show the logs of specified nodes")
+	cmd.Flags().BoolVarP(&opt.Follow, "follow", "f", false, "Keep showing the new lines of the logs")
+	cmd.Flags().StringVar(&opt.Grep, "grep", "", "Only show the lines matching the regular expression")
+	cmd.Flags().DurationVar(&opt.Since, "since", 0, "Only show the lines logged in the duration, e.g. 30m or 2h")
+	cmd.Flags().IntVarP(&opt.Tail, "tail", "n", 100, "The number of the last lines of each instance to show, -1 for all the lines")
+
+	return cmd
+}
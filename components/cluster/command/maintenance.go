@@ -0,0 +1,76 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newMaintenanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Drain and stop the instances on a host for maintenance",
+		Long: `Put a host of the cluster under maintenance before hardware work on it, and
+take it back after the work is done. The hosts under maintenance are shown by
+the display command, and restart, reload and upgrade refuse to start their
+instances again unless --force is set.`,
+	}
+
+	var host string
+	validArgs := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return shellCompGetClusterName(cm, toComplete)
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	enterCmd := &cobra.Command{
+		Use:   "enter <cluster-name> --host <host>",
+		Short: "Drain and stop the instances on a host",
+		Long: `Drain and stop the instances on a host. The PD and TiKV leaders are evicted
+from the instances and the TiCDC captures are drained before they are stopped,
+the TiDB and TiProxy servers are stopped before others. The evict leader
+schedulers of the stores are kept until the host exits maintenance.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.EnterMaintenance(args[0], host, gOpt, skipConfirm)
+		},
+		ValidArgsFunction: validArgs,
+	}
+
+	exitCmd := &cobra.Command{
+		Use:   "exit <cluster-name> --host <host>",
+		Short: "Start the instances on a host under maintenance",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.ExitMaintenance(args[0], host, gOpt, skipConfirm)
+		},
+		ValidArgsFunction: validArgs,
+	}
+
+	for _, c := range []*cobra.Command{enterCmd, exitCmd} {
+		c.Flags().StringVar(&host, "host", "", "The host under maintenance")
+		c.Flags().Uint64Var(&gOpt.APITimeout, "transfer-timeout", 600, "Timeout in seconds when transferring PD and TiKV store leaders, also for TiCDC drain one capture")
+		_ = c.MarkFlagRequired("host")
+	}
+
+	cmd.AddCommand(enterCmd, exitCmd)
+	return cmd
+}
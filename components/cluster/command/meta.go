@@ -24,7 +24,7 @@ import (
 func newMetaCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "meta",
-		Short: "backup/restore meta information",
+		Short: "backup/restore meta information and manage its history",
 	}
 
 	var filePath string
@@ -59,8 +59,48 @@ func newMetaCmd() *cobra.Command {
 		},
 	}
 
+	var metaHistoryCmd = &cobra.Command{
+		Use:   "history <cluster-name>",
+		Short: "show the versions of meta information saved by operations on cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.MetaHistory(args[0])
+		},
+	}
+
+	var metaDiffCmd = &cobra.Command{
+		Use:   "diff <cluster-name> <version> [version]",
+		Short: "show the difference between two versions of meta information, the current one is used if the second version is omitted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch len(args) {
+			case 2:
+				return cm.MetaDiff(args[0], args[1], "")
+			case 3:
+				return cm.MetaDiff(args[0], args[1], args[2])
+			default:
+				return cmd.Help()
+			}
+		},
+	}
+
+	var metaRollbackCmd = &cobra.Command{
+		Use:   "rollback <cluster-name> <version>",
+		Short: "roll back meta information to a previous version",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return cmd.Help()
+			}
+			return cm.RollbackMeta(args[0], args[1], skipConfirm)
+		},
+	}
+
 	cmd.AddCommand(metaBackupCmd)
 	cmd.AddCommand(metaRestoreCmd)
+	cmd.AddCommand(metaHistoryCmd)
+	cmd.AddCommand(metaDiffCmd)
+	cmd.AddCommand(metaRollbackCmd)
 
 	return cmd
 }
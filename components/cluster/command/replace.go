@@ -0,0 +1,81 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"path/filepath"
+
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newReplaceCmd() *cobra.Command {
+	opt := manager.ReplaceOptions{}
+	var topoFile string
+	deployOpt := manager.DeployOptions{
+		IdentityFile: filepath.Join(utils.UserHome(), ".ssh", "id_rsa"),
+	}
+	cmd := &cobra.Command{
+		Use:   "replace <cluster-name> --node <node-id> --with <topology.yaml>",
+		Short: "Replace a node of a TiDB cluster with a new one",
+		Long: `Replace a node of a TiDB cluster with a new one. The new node defined in the
+topology file is scaled out, the old node is scaled in once the new one is
+ready, and it's destroyed after its regions are migrated to other stores. Other
+tombstone nodes of the cluster are left to the prune command.
+
+The steps that have been done are skipped, run the same command again to resume
+an interrupted replacement.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			return cm.Replace(
+				clusterName,
+				topoFile,
+				opt,
+				deployOpt,
+				postScaleOutHook,
+				final,
+				skipConfirm,
+				gOpt,
+			)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opt.Node, "node", "N", "", "The ID of the node to be replaced")
+	cmd.Flags().StringVar(&topoFile, "with", "", "The topology file defining the new node")
+	cmd.Flags().Uint64Var(&opt.WaitTimeout, "wait-timeout", 7200, "Timeout in seconds when waiting for the regions to be migrated from the old node")
+	cmd.Flags().StringVarP(&deployOpt.User, "user", "u", utils.CurrentUser(), "The user name to login via SSH. The user must has root (or sudo) privilege.")
+	cmd.Flags().BoolVarP(&deployOpt.SkipCreateUser, "skip-create-user", "", false, "(EXPERIMENTAL) Skip creating the user specified in topology.")
+	cmd.Flags().StringVarP(&deployOpt.IdentityFile, "identity_file", "i", deployOpt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used, along with the OpenSSH certificate <identity_file>-cert.pub if it exists.")
+	cmd.Flags().BoolVarP(&deployOpt.UsePassword, "password", "p", false, "Use password of target hosts. If specified, password authentication will be used.")
+	cmd.Flags().BoolVar(&deployOpt.UseSSHAgent, "ssh-agent", false, "Use the keys in the ssh-agent of SSH_AUTH_SOCK instead of the identity file, e.g. the keys stored in hardware tokens.")
+	cmd.Flags().BoolVarP(&deployOpt.NoLabels, "no-labels", "", false, "Don't check TiKV labels")
+	cmd.Flags().Uint64Var(&gOpt.APITimeout, "transfer-timeout", 600, "Timeout in seconds when transferring PD and TiKV store leaders, also for TiCDC drain one capture")
+	_ = cmd.MarkFlagRequired("node")
+	_ = cmd.MarkFlagRequired("with")
+
+	return cmd
+}
@@ -46,6 +46,9 @@ func newRestartCmd() *cobra.Command {
 
 	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only restart specified roles")
 	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only restart specified nodes")
+	cmd.Flags().BoolVar(&gOpt.RollingRestart, "rolling", false, "Restart the instances one by one with their leaders evicted, and wait for each of them to be ready before restarting the next one")
+	cmd.Flags().BoolVar(&gOpt.Force, "force", false, "Force restart the instances on the hosts under maintenance, and without evicting the leaders in rolling restart")
+	cmd.Flags().Uint64Var(&gOpt.APITimeout, "transfer-timeout", 600, "Timeout in seconds when transferring PD and TiKV store leaders and waiting for instances to be ready in rolling restart")
 
 	return cmd
 }
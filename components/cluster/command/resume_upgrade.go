@@ -0,0 +1,102 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+func newResumeUpgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume-upgrade <cluster-name>",
+		Short: "Resume a paused upgrade of a TiDB cluster",
+		Long: `Resume the upgrade paused by --pause-after-canary, the instances that are
+already upgraded are skipped.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			clusterName := args[0]
+			auditID, upgradeArgs, err := cm.PausedUpgrade(clusterName, subcommandName)
+			if err != nil {
+				return err
+			}
+
+			if !skipConfirm {
+				if err := tui.PromptForConfirmOrAbortError(
+					"%s", fmt.Sprintf("Will resume the upgrade `tiup cluster %s` (audit %s)\nDo you want to continue? [y/N]: ",
+						strings.Join(upgradeArgs[1:], " "), auditID),
+				); err != nil {
+					return err
+				}
+			}
+
+			// the flags of the paused upgrade are parsed by a new upgrade command
+			// instead of the flags already parsed for this command
+			upgradeCmd, rest := findSubcommand(upgradeArgs)
+			if upgradeCmd == nil || upgradeCmd.Name() != "upgrade" {
+				return perrs.Errorf("invalid arguments of the paused upgrade: %s", strings.Join(upgradeArgs, " "))
+			}
+			upgradeCmd = newUpgradeCmd()
+			upgradeCmd.Flags().AddFlagSet(rootCmd.PersistentFlags())
+			if err := upgradeCmd.ParseFlags(rest); err != nil {
+				return perrs.Annotatef(err, "invalid arguments of the paused upgrade")
+			}
+
+			if err := cm.ResumeUpgrade(auditID, upgradeArgs); err != nil {
+				return err
+			}
+			return upgradeCmd.RunE(upgradeCmd, upgradeCmd.Flags().Args())
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	return cmd
+}
+
+// findSubcommand finds the subcommand of the recorded arguments of a command
+// in the command tree, the global flags may be given before it. It returns the
+// subcommand and the arguments without its name, or nil if there is none.
+func findSubcommand(args []string) (*cobra.Command, []string) {
+	if len(args) < 2 {
+		return nil, nil
+	}
+	cmd, rest, err := rootCmd.Find(args[1:])
+	if err != nil || cmd == rootCmd {
+		return nil, nil
+	}
+	return cmd, rest
+}
+
+// subcommandName returns the name of the subcommand of the recorded arguments
+// of a command, or empty if there is none
+func subcommandName(args []string) string {
+	if cmd, _ := findSubcommand(args); cmd != nil {
+		return cmd.Name()
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindSubcommand(t *testing.T) {
+	cmd, rest := findSubcommand([]string{"tiup-cluster", "--ssh", "system", "-c", "3", "upgrade", "test", "v8.1.0", "--canary"})
+	if cmd == nil || cmd.Name() != "upgrade" {
+		t.Fatalf("unexpected subcommand %v", cmd)
+	}
+	if expected := []string{"--ssh", "system", "-c", "3", "test", "v8.1.0", "--canary"}; !slices.Equal(expected, rest) {
+		t.Fatalf("expected %v, got %v", expected, rest)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"tiup-cluster", "--yes", "resume-upgrade", "test"}, "resume-upgrade"},
+		// the value of a flag is not a subcommand
+		{[]string{"tiup-cluster", "--ssh", "upgrade"}, ""},
+		{[]string{"tiup-cluster"}, ""},
+	}
+	for _, test := range tests {
+		if name := subcommandName(test.args); name != test.expected {
+			t.Fatalf("expected %q for %v, got %q", test.expected, test.args, name)
+		}
+	}
+}
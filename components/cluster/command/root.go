@@ -14,7 +14,6 @@
 package command
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -23,6 +22,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/joomcode/errorx"
 	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
 	"github.com/pingcap/tiup/pkg/cluster/executor"
 	"github.com/pingcap/tiup/pkg/cluster/manager"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
@@ -33,6 +33,7 @@ import (
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/proxy"
 	"github.com/pingcap/tiup/pkg/repository"
+	"github.com/pingcap/tiup/pkg/set"
 
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
@@ -41,6 +42,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// dryRunCommands are the commands that support --dry-run
+var dryRunCommands = set.NewStringSet("deploy", "scale-out", "scale-in", "reload", "upgrade", "rollback", "destroy", "apply")
+
 var (
 	rootCmd     *cobra.Command
 	gOpt        operator.Options
@@ -72,8 +76,13 @@ func init() {
 		SilenceErrors: true,
 		Version:       version.NewTiUPVersion().String(),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// populate logger
+			// populate logger, the global display mode is used by the tables
 			log.SetDisplayModeFromString(gOpt.DisplayMode)
+			logprinter.SetDisplayModeFromString(gOpt.DisplayMode)
+
+			if gOpt.DryRun && !dryRunCommands.Exist(cmd.Name()) {
+				return perrs.Errorf("the --dry-run flag is not supported by the %s command", cmd.Name())
+			}
 
 			var err error
 			var env *tiupmeta.Environment
@@ -85,6 +94,7 @@ func init() {
 			cm = manager.NewManager("tidb", tidbSpec, log)
 			if cmd.Name() != "__complete" {
 				logger.EnableAuditLog(spec.AuditDir())
+				audit.EnableCommandAudit(spec.AuditDir())
 			}
 
 			// Running in other OS/ARCH Should be fine we only download manifest file.
@@ -134,22 +144,17 @@ func init() {
 	rootCmd.PersistentFlags().Uint64Var(&gOpt.OptTimeout, "wait-timeout", 120, "Timeout in seconds to wait for an operation to complete, ignored for operations that don't fit.")
 	rootCmd.PersistentFlags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip all confirmations and assumes 'yes'")
 	rootCmd.PersistentFlags().BoolVar(&gOpt.NativeSSH, "native-ssh", gOpt.NativeSSH, "(EXPERIMENTAL) Use the native SSH client installed on local system instead of the built-in one.")
-	rootCmd.PersistentFlags().StringVar((*string)(&gOpt.SSHType), "ssh", "", "(EXPERIMENTAL) The executor type: 'builtin', 'system', 'none' (default \"builtin\").")
+	rootCmd.PersistentFlags().StringVar((*string)(&gOpt.SSHType), "ssh", "", "(EXPERIMENTAL) The executor type: 'builtin', 'system', 'none', 'docker', 'podman', 'kubectl' (default \"builtin\").")
 	rootCmd.PersistentFlags().IntVarP(&gOpt.Concurrency, "concurrency", "c", 5, "max number of parallel tasks allowed")
-	rootCmd.PersistentFlags().StringVar(&gOpt.DisplayMode, "format", "default", "(EXPERIMENTAL) The format of output, available values are [default, json]")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyHost, "ssh-proxy-host", "", "The SSH proxy host used to connect to remote host.")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyUser, "ssh-proxy-user", utils.CurrentUser(), "The user name used to login the proxy host.")
-	rootCmd.PersistentFlags().IntVar(&gOpt.SSHProxyPort, "ssh-proxy-port", 22, "The port used to login the proxy host.")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyIdentity, "ssh-proxy-identity-file", path.Join(utils.UserHome(), ".ssh", "id_rsa"), "The identity file used to login the proxy host.")
-	rootCmd.PersistentFlags().BoolVar(&gOpt.SSHProxyUsePassword, "ssh-proxy-use-password", false, "Use password to login the proxy host.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.DisplayMode, "format", "default", "The format of output, available values are [default, json, yaml]")
+	rootCmd.PersistentFlags().BoolVar(&gOpt.DryRun, "dry-run", false, "Print the steps of deploy, scale-out, scale-in, reload, upgrade and destroy without connecting to any host.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyHost, "ssh-proxy-host", "", "The SSH proxy host used to connect to remote host, or a chain of jump hosts in the ProxyJump format `[user@]host[:port],...`, overrides the `ssh_proxy` settings of the topology.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyUser, "ssh-proxy-user", utils.CurrentUser(), "The user name used to login the proxy hosts without a user specified.")
+	rootCmd.PersistentFlags().IntVar(&gOpt.SSHProxyPort, "ssh-proxy-port", 22, "The port used to login the proxy hosts without a port specified.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyIdentity, "ssh-proxy-identity-file", path.Join(utils.UserHome(), ".ssh", "id_rsa"), "The identity file used to login the proxy hosts.")
+	rootCmd.PersistentFlags().BoolVar(&gOpt.SSHProxyUsePassword, "ssh-proxy-use-password", false, "Use password to login the proxy hosts.")
 	rootCmd.PersistentFlags().Uint64Var(&gOpt.SSHProxyTimeout, "ssh-proxy-timeout", 5, "Timeout in seconds to connect the proxy host via SSH, ignored for operations that don't need an SSH connection.")
 	_ = rootCmd.PersistentFlags().MarkHidden("native-ssh")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-host")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-user")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-port")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-identity-file")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-use-password")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-timeout")
 
 	rootCmd.AddCommand(
 		newCheckCmd(),
@@ -162,7 +167,10 @@ func init() {
 		newDestroyCmd(),
 		newCleanCmd(),
 		newUpgradeCmd(),
+		newResumeUpgradeCmd(),
+		newRollbackCmd(),
 		newDisplayCmd(),
+		newTopCmd(),
 		newPruneCmd(),
 		newListCmd(),
 		newAuditCmd(),
@@ -181,7 +189,16 @@ func init() {
 		newTemplateCmd(),
 		newTLSCmd(),
 		newMetaCmd(),
+		newLockCmd(),
+		newDriftCmd(),
+		newDiagnoseCmd(),
+		newCollectCmd(),
+		newLogsCmd(),
+		newMaintenanceCmd(),
+		newReplaceCmd(),
+		newSSHHostKeysCmd(),
 		newRotateSSHCmd(),
+		newApplyCmd(),
 	)
 }
 
@@ -225,21 +242,6 @@ func printErrorMessageForErrorX(err *errorx.Error) {
 	_, _ = tui.ColorErrorMsg.Fprintf(os.Stderr, "\nError: %s", msg)
 }
 
-func extractSuggestionFromErrorX(err *errorx.Error) string {
-	cause := err
-	for cause != nil {
-		v, ok := cause.Property(utils.ErrPropSuggestion)
-		if ok {
-			if s, ok := v.(string); ok {
-				return s
-			}
-		}
-		cause = errorx.Cast(cause.Cause())
-	}
-
-	return ""
-}
-
 // Execute executes the root command
 func Execute() {
 	zap.L().Info("Execute command", zap.String("command", tui.OsArgs()))
@@ -257,22 +259,13 @@ func Execute() {
 
 	zap.L().Info("Execute command finished", zap.Int("code", code), zap.Error(err))
 
-	switch log.GetDisplayMode() {
-	case logprinter.DisplayModeJSON:
-		obj := struct {
-			Code int    `json:"exit_code"`
-			Err  string `json:"error,omitempty"`
-		}{
-			Code: code,
-		}
-		if err != nil {
-			obj.Err = err.Error()
-		}
-		data, err := json.Marshal(obj)
+	switch mode := log.GetDisplayMode(); mode {
+	case logprinter.DisplayModeJSON, logprinter.DisplayModeYAML:
+		data, err := logprinter.Marshal(mode, tui.NewCommandResult(code, err))
 		if err != nil {
 			fmt.Printf("{\"exit_code\":%d, \"error\":\"%s\"}", code, err)
 		}
-		fmt.Fprintln(os.Stderr, string(data))
+		_, _ = os.Stderr.Write(data)
 	default:
 		if err != nil {
 			if errx := errorx.Cast(err); errx != nil {
@@ -285,10 +278,8 @@ func Execute() {
 				logger.OutputDebugLog("tiup-cluster")
 			}
 
-			if errx := errorx.Cast(err); errx != nil {
-				if suggestion := extractSuggestionFromErrorX(errx); len(suggestion) > 0 {
-					log.Errorf("\n%s\n", suggestion)
-				}
+			if suggestion := tui.ErrorSuggestion(err); len(suggestion) > 0 {
+				log.Errorf("\n%s\n", suggestion)
 			}
 		}
 	}
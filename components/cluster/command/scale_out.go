@@ -80,9 +80,12 @@ func newScaleOutCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&opt.User, "user", "u", utils.CurrentUser(), "The user name to login via SSH. The user must has root (or sudo) privilege.")
 	cmd.Flags().BoolVarP(&opt.SkipCreateUser, "skip-create-user", "", false, "(EXPERIMENTAL) Skip creating the user specified in topology.")
-	cmd.Flags().StringVarP(&opt.IdentityFile, "identity_file", "i", opt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used.")
+	cmd.Flags().BoolVar(&opt.NoSudo, "no-sudo", false, "Scale out by the deploy user without sudo, the hosts need to be prepared by root with the scripts generated, the systemd_mode of the cluster must be user.")
+	cmd.Flags().StringVarP(&opt.IdentityFile, "identity_file", "i", opt.IdentityFile, "The path of the SSH identity file. If specified, public key authentication will be used, along with the OpenSSH certificate <identity_file>-cert.pub if it exists.")
 	cmd.Flags().BoolVarP(&opt.UsePassword, "password", "p", false, "Use password of target hosts. If specified, password authentication will be used.")
+	cmd.Flags().BoolVar(&opt.UseSSHAgent, "ssh-agent", false, "Use the keys in the ssh-agent of SSH_AUTH_SOCK instead of the identity file, e.g. the keys stored in hardware tokens.")
 	cmd.Flags().BoolVarP(&opt.NoLabels, "no-labels", "", false, "Don't check TiKV labels")
+	cmd.Flags().IntVar(&gOpt.PeerFanout, "p2p-fanout", 0, "Upload the packages to this many hosts at a time from the control machine, and let the other hosts fetch them from the hosts holding them over SSH, 0 to upload to all hosts from the control machine")
 	cmd.Flags().BoolVarP(&opt.Stage1, "stage1", "", false, "Don't start the new instance after scale-out, need to manually execute cluster scale-out --stage2")
 	cmd.Flags().BoolVarP(&opt.Stage2, "stage2", "", false, "Start the new instance and init config after scale-out --stage1")
 
@@ -0,0 +1,82 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newSSHHostKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-hostkeys",
+		Short: "Manage the SSH host keys of a cluster",
+		Long: `The SSH host keys of the hosts are recorded when the cluster is deployed or
+scaled out, and the connections to a host presenting a different key are refused.`,
+	}
+
+	validArgs := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return shellCompGetClusterName(cm, toComplete)
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list <cluster-name>",
+		Short: "List the recorded SSH host keys of a cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.ListHostKeys(args[0])
+		},
+		ValidArgsFunction: validArgs,
+	}
+
+	var hosts []string
+	refreshCmd := &cobra.Command{
+		Use:   "refresh <cluster-name>",
+		Short: "Trust the SSH host keys the hosts present now",
+		Long: `Replace the recorded SSH host keys of the hosts with the ones they present now,
+for the hosts reinstalled or with their host keys regenerated on purpose. All
+hosts of the cluster are refreshed if no host is specified.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.RefreshHostKeys(args[0], hosts, gOpt, skipConfirm)
+		},
+		ValidArgsFunction: validArgs,
+	}
+	refreshCmd.Flags().StringSliceVar(&hosts, "host", nil, "Only refresh the host keys of the specified hosts")
+
+	removeCmd := &cobra.Command{
+		Use:   "remove <cluster-name>",
+		Short: "Remove the recorded SSH host keys of hosts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+			return cm.RemoveHostKeys(args[0], hosts, skipConfirm)
+		},
+		ValidArgsFunction: validArgs,
+	}
+	removeCmd.Flags().StringSliceVar(&hosts, "host", nil, "The hosts to remove the host keys of")
+	_ = removeCmd.MarkFlagRequired("host")
+
+	cmd.AddCommand(listCmd, refreshCmd, removeCmd)
+	return cmd
+}
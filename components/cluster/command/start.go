@@ -93,6 +93,7 @@ func newStartCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&restoreLeader, "restore-leaders", false, "Allow leaders to be scheduled to stores after start")
 	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only start specified roles")
 	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only start specified nodes")
+	cmd.Flags().BoolVar(&gOpt.Force, "force", false, "Force start the instances on the hosts under maintenance, and ignore the errors of restoring the leaders")
 
 	_ = cmd.Flags().MarkHidden("restore-leaders")
 
@@ -0,0 +1,65 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/manager"
+	"github.com/spf13/cobra"
+)
+
+func newTopCmd() *cobra.Command {
+	var statusTimeout uint64
+	opt := manager.TopOptions{}
+	cmd := &cobra.Command{
+		Use:   "top <cluster-name>",
+		Short: "Show the live status and resource usage of the instances of a TiDB cluster",
+		Long: `Show the status, uptime, CPU and memory usage of the instances of a TiDB
+cluster, the leader and region counts of the TiKV and TiFlash stores, and the
+PD leader and CDC owner, refreshed periodically in the terminal.
+
+Keys: '<' and '>' (or the arrow keys) change the column to sort by, 'r'
+reverses the order, 'f' filters the instances by role and 'q' quits. When the
+output is not a terminal, or --format is json or yaml, the snapshots are
+printed one after another instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Help()
+			}
+
+			gOpt.APITimeout = statusTimeout
+			clusterName := args[0]
+			return cm.Top(clusterName, opt, gOpt)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return shellCompGetClusterName(cm, toComplete)
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&gOpt.Roles, "role", "R", nil, "Only show specified roles")
+	cmd.Flags().StringSliceVarP(&gOpt.Nodes, "node", "N", nil, "Only show specified nodes")
+	cmd.Flags().DurationVarP(&opt.Interval, "interval", "i", 2*time.Second, "The interval to refresh")
+	cmd.Flags().StringVarP(&opt.SortBy, "sort", "s", "id", "The column to sort by, e.g. cpu, memory or leaders")
+	cmd.Flags().BoolVar(&opt.Reverse, "reverse", false, "Sort in descending order")
+	cmd.Flags().IntVarP(&opt.Count, "count", "n", 0, "Exit after the number of refreshes, 0 for no limit")
+	cmd.Flags().Uint64Var(&statusTimeout, "status-timeout", 10, "Timeout in seconds when getting node status")
+
+	return cmd
+}
@@ -40,6 +40,9 @@ func newUpgradeCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if gOpt.PauseAfterCanary {
+				gOpt.UpgradeCanary = true
+			}
 
 			componentVersions := map[string]string{
 				spec.ComponentDashboard:        dashboardVer,
@@ -75,6 +78,12 @@ func newUpgradeCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&ignoreVersionCheck, "ignore-version-check", "", false, "Ignore checking if target version is bigger than current version")
 	cmd.Flags().StringVar(&gOpt.SSHCustomScripts.BeforeRestartInstance.Raw, "pre-upgrade-script", "", "Custom script to be executed on each server before the server is upgraded")
 	cmd.Flags().StringVar(&gOpt.SSHCustomScripts.AfterRestartInstance.Raw, "post-upgrade-script", "", "Custom script to be executed on each server after the server is upgraded")
+	cmd.Flags().BoolVar(&gOpt.UpgradeCanary, "canary", false, "Upgrade one instance of each component first and check the cluster health before upgrading the others")
+	cmd.Flags().IntVar(&gOpt.UpgradeBatchSize, "batch-size", 1, "Max number of instances of a component upgraded at the same time, only the stateless components are batched freely, PD is upgraded one by one and TiKV or TiFlash stores are batched within a failure domain")
+	cmd.Flags().BoolVar(&gOpt.PauseAfterCanary, "pause-after-canary", false, "Pause the upgrade after the canary instance of each component is upgraded, implies --canary")
+	cmd.Flags().BoolVar(&gOpt.UpgradeCheck, "health-check", false, "Check the health of the whole cluster after each instance or batch is upgraded, it's always checked with --canary or --batch-size greater than 1 unless --force is set")
+	cmd.Flags().BoolVar(&gOpt.RollbackOnFailure, "rollback-on-failure", false, "Restore the previous version of the upgraded instances if the upgrade fails")
+	cmd.Flags().IntVar(&gOpt.PeerFanout, "p2p-fanout", 0, "Upload the packages to this many hosts at a time from the control machine, and let the other hosts fetch them from the hosts holding them over SSH, 0 to upload to all hosts from the control machine")
 
 	// cmd.Flags().StringVar(&tidbVer, "tidb-version", "", "Fix the version of tidb and no longer follows the cluster version.")
 	cmd.Flags().StringVar(&tikvVer, "tikv-version", "", "Fix the version of tikv and no longer follows the cluster version.")
@@ -23,6 +23,7 @@ import (
 var retainDays int
 
 func newAuditCmd() *cobra.Command {
+	showCommands := false
 	cmd := &cobra.Command{
 		Use:   "audit [audit-id]",
 		Short: "Show audit log of cluster operation",
@@ -31,12 +32,16 @@ func newAuditCmd() *cobra.Command {
 			case 0:
 				return audit.ShowAuditList(cspec.AuditDir())
 			case 1:
+				if showCommands {
+					return audit.ShowAuditCommands(cspec.AuditDir(), args[0], gOpt.DisplayMode)
+				}
 				return audit.ShowAuditLog(cspec.AuditDir(), args[0])
 			default:
 				return cmd.Help()
 			}
 		},
 	}
+	cmd.Flags().BoolVar(&showCommands, "commands", false, "Show the commands executed on the hosts in the operation")
 	cmd.AddCommand(newAuditCleanupCmd())
 	return cmd
 }
@@ -14,7 +14,6 @@
 package command
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -24,6 +23,7 @@ import (
 	"github.com/joomcode/errorx"
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/components/dm/spec"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
 	"github.com/pingcap/tiup/pkg/cluster/executor"
 	"github.com/pingcap/tiup/pkg/cluster/manager"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
@@ -34,6 +34,7 @@ import (
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/proxy"
 	"github.com/pingcap/tiup/pkg/repository"
+	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
 	"github.com/pingcap/tiup/pkg/version"
@@ -53,6 +54,9 @@ var (
 var dmspec *cspec.SpecManager
 var cm *manager.Manager
 
+// dryRunCommands are the commands that support --dry-run
+var dryRunCommands = set.NewStringSet("deploy", "scale-out", "scale-in", "reload", "upgrade", "destroy")
+
 func init() {
 	logger.InitGlobalLogger()
 
@@ -74,8 +78,13 @@ please backup your data before process.`,
 		SilenceErrors: true,
 		Version:       version.NewTiUPVersion().String(),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// populate logger
+			// populate logger, the global display mode is used by the tables
 			log.SetDisplayModeFromString(gOpt.DisplayMode)
+			logprinter.SetDisplayModeFromString(gOpt.DisplayMode)
+
+			if gOpt.DryRun && !dryRunCommands.Exist(cmd.Name()) {
+				return perrs.Errorf("the --dry-run flag is not supported by the %s command", cmd.Name())
+			}
 
 			var err error
 			var env *tiupmeta.Environment
@@ -85,6 +94,7 @@ please backup your data before process.`,
 
 			dmspec = spec.GetSpecManager()
 			logger.EnableAuditLog(cspec.AuditDir())
+			audit.EnableCommandAudit(cspec.AuditDir())
 			cm = manager.NewManager("dm", dmspec, log)
 
 			// Running in other OS/ARCH Should be fine we only download manifest file.
@@ -130,22 +140,17 @@ please backup your data before process.`,
 	rootCmd.PersistentFlags().Uint64Var(&gOpt.OptTimeout, "wait-timeout", 120, "Timeout in seconds to wait for an operation to complete, ignored for operations that don't fit.")
 	rootCmd.PersistentFlags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip all confirmations and assumes 'yes'")
 	rootCmd.PersistentFlags().BoolVar(&gOpt.NativeSSH, "native-ssh", gOpt.NativeSSH, "Use the SSH client installed on local system instead of the built-in one.")
-	rootCmd.PersistentFlags().StringVar((*string)(&gOpt.SSHType), "ssh", "", "The executor type: 'builtin', 'system', 'none'")
+	rootCmd.PersistentFlags().StringVar((*string)(&gOpt.SSHType), "ssh", "", "The executor type: 'builtin', 'system', 'none', 'docker', 'podman', 'kubectl'")
 	rootCmd.PersistentFlags().IntVarP(&gOpt.Concurrency, "concurrency", "c", 5, "max number of parallel tasks allowed")
-	rootCmd.PersistentFlags().StringVar(&gOpt.DisplayMode, "format", "default", "(EXPERIMENTAL) The format of output, available values are [default, json]")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyHost, "ssh-proxy-host", "", "The SSH proxy host used to connect to remote host.")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyUser, "ssh-proxy-user", utils.CurrentUser(), "The user name used to login the proxy host.")
-	rootCmd.PersistentFlags().IntVar(&gOpt.SSHProxyPort, "ssh-proxy-port", 22, "The port used to login the proxy host.")
-	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyIdentity, "ssh-proxy-identity-file", path.Join(utils.UserHome(), ".ssh", "id_rsa"), "The identity file used to login the proxy host.")
-	rootCmd.PersistentFlags().BoolVar(&gOpt.SSHProxyUsePassword, "ssh-proxy-use-password", false, "Use password to login the proxy host.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.DisplayMode, "format", "default", "The format of output, available values are [default, json, yaml]")
+	rootCmd.PersistentFlags().BoolVar(&gOpt.DryRun, "dry-run", false, "Print the steps of deploy, scale-out, scale-in, reload, upgrade and destroy without connecting to any host.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyHost, "ssh-proxy-host", "", "The SSH proxy host used to connect to remote host, or a chain of jump hosts in the ProxyJump format `[user@]host[:port],...`, overrides the `ssh_proxy` settings of the topology.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyUser, "ssh-proxy-user", utils.CurrentUser(), "The user name used to login the proxy hosts without a user specified.")
+	rootCmd.PersistentFlags().IntVar(&gOpt.SSHProxyPort, "ssh-proxy-port", 22, "The port used to login the proxy hosts without a port specified.")
+	rootCmd.PersistentFlags().StringVar(&gOpt.SSHProxyIdentity, "ssh-proxy-identity-file", path.Join(utils.UserHome(), ".ssh", "id_rsa"), "The identity file used to login the proxy hosts.")
+	rootCmd.PersistentFlags().BoolVar(&gOpt.SSHProxyUsePassword, "ssh-proxy-use-password", false, "Use password to login the proxy hosts.")
 	rootCmd.PersistentFlags().Uint64Var(&gOpt.SSHProxyTimeout, "ssh-proxy-timeout", 5, "Timeout in seconds to connect the proxy host via SSH, ignored for operations that don't need an SSH connection.")
 	_ = rootCmd.PersistentFlags().MarkHidden("native-ssh")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-host")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-user")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-port")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-identity-file")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-use-password")
-	_ = rootCmd.PersistentFlags().MarkHidden("ssh-proxy-timeout")
 
 	rootCmd.AddCommand(
 		newDeployCmd(),
@@ -169,6 +174,8 @@ please backup your data before process.`,
 		newReplayCmd(),
 		newTemplateCmd(),
 		newMetaCmd(),
+		newLockCmd(),
+		newSSHHostKeysCmd(),
 		newRotateSSHCmd(),
 	)
 }
@@ -213,21 +220,6 @@ func printErrorMessageForErrorX(err *errorx.Error) {
 	_, _ = tui.ColorErrorMsg.Fprintf(os.Stderr, "\nError: %s", msg)
 }
 
-func extractSuggestionFromErrorX(err *errorx.Error) string {
-	cause := err
-	for cause != nil {
-		v, ok := cause.Property(utils.ErrPropSuggestion)
-		if ok {
-			if s, ok := v.(string); ok {
-				return s
-			}
-		}
-		cause = errorx.Cast(cause.Cause())
-	}
-
-	return ""
-}
-
 // Execute executes the root command
 func Execute() {
 	zap.L().Info("Execute command", zap.String("command", tui.OsArgs()))
@@ -246,19 +238,14 @@ func Execute() {
 	zap.L().Info("Execute command finished", zap.Int("code", code), zap.Error(err))
 
 	if err != nil {
-		switch strings.ToLower(gOpt.DisplayMode) {
-		case "json":
-			obj := struct {
-				Err string `json:"error"`
-			}{
-				Err: err.Error(),
-			}
-			data, err := json.Marshal(obj)
+		switch mode := logprinter.ParseDisplayMode(gOpt.DisplayMode); mode {
+		case logprinter.DisplayModeJSON, logprinter.DisplayModeYAML:
+			data, err := logprinter.Marshal(mode, tui.NewCommandResult(code, err))
 			if err != nil {
 				fmt.Printf("{\"error\": \"%s\"}", err)
 				break
 			}
-			fmt.Fprintln(os.Stderr, string(data))
+			_, _ = os.Stderr.Write(data)
 		default:
 			if errx := errorx.Cast(err); errx != nil {
 				printErrorMessageForErrorX(errx)
@@ -270,10 +257,8 @@ func Execute() {
 				logger.OutputDebugLog("tiup-dm")
 			}
 
-			if errx := errorx.Cast(err); errx != nil {
-				if suggestion := extractSuggestionFromErrorX(errx); len(suggestion) > 0 {
-					_, _ = fmt.Fprintf(os.Stderr, "\n%s\n", suggestion)
-				}
+			if suggestion := tui.ErrorSuggestion(err); len(suggestion) > 0 {
+				_, _ = fmt.Fprintf(os.Stderr, "\n%s\n", suggestion)
 			}
 		}
 	}
@@ -672,6 +672,10 @@ func (s *Specification) Validate() error {
 		return err
 	}
 
+	if err := s.GlobalOptions.ValidateSSHProxy(); err != nil {
+		return err
+	}
+
 	return spec.RelativePathDetect(s, isSkipField)
 }
 
@@ -124,8 +124,7 @@ func (p *Playground) handleDisplay(r io.Writer) (err error) {
 		return err
 	}
 
-	td.Display()
-	return nil
+	return td.Display()
 }
 
 var timeoutOpt = &utils.RetryOption{
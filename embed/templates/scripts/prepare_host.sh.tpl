@@ -0,0 +1,50 @@
+#!/bin/bash
+set -e
+
+# WARNING: This file was auto-generated by TiUP for the sudo-less deployment
+#          of cluster {{.ClusterName}} on host {{.Host}}.
+#          Run it as root on the host once before deploying or scaling out,
+#          the other operations don't need root or sudo.
+if [ "$(id -u)" -ne 0 ]; then
+  echo "Please run this script as root" >&2
+  exit 1
+fi
+
+# create the deploy user
+{{- if .Group}}
+getent group {{.Group}} >/dev/null || groupadd {{.Group}}
+id -u {{.User}} >/dev/null 2>&1 || useradd -m -g {{.Group}} {{.User}}
+{{- else}}
+id -u {{.User}} >/dev/null 2>&1 || useradd -m {{.User}}
+{{- end}}
+
+# create the directories owned by the deploy user, the missing parents are
+# owned by the deploy user as well
+GROUP=$(id -gn {{.User}})
+prepare_dir() {
+  local dir=""
+  local part
+  IFS=/ read -ra parts <<< "$1"
+  for part in "${parts[@]}"; do
+    [ -n "$part" ] || continue
+    dir="$dir/$part"
+    [ -d "$dir" ] || { mkdir "$dir" && chown {{.User}}:$GROUP "$dir"; }
+  done
+  chown {{.User}}:$GROUP "$1"
+}
+{{- range .Dirs}}
+prepare_dir {{.}}
+{{- end}}
+
+# enable lingering for the systemd user instance of the deploy user, so that
+# the services keep running after the user logs out and start on boot
+loginctl enable-linger {{.User}}
+
+# set the system limits of the deploy user
+for limit in "soft nofile 1000000" "hard nofile 1000000" "soft stack 10240"; do
+  set -- $limit
+  grep -Eq "^{{.User}}[[:space:]]+$1[[:space:]]+$2[[:space:]]" /etc/security/limits.conf ||
+    echo "{{.User}}    $1    $2    $3" >> /etc/security/limits.conf
+done
+
+echo "Host {{.Host}} is prepared for the sudo-less deployment"
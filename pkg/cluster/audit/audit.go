@@ -15,19 +15,20 @@ package audit
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/base52"
 	"github.com/pingcap/tiup/pkg/crypto/rand"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/tui"
 	tiuputils "github.com/pingcap/tiup/pkg/utils"
 )
@@ -37,6 +38,11 @@ const (
 	EnvNameAuditID = "TIUP_AUDIT_ID"
 )
 
+var (
+	reservedIDMu sync.Mutex
+	reservedID   string
+)
+
 // CommandArgs returns the original commands from the first line of a file
 func CommandArgs(fp string) ([]string, error) {
 	file, err := os.Open(fp)
@@ -144,14 +150,39 @@ func GetAuditList(dir string) ([]Item, error) {
 	return auditList, nil
 }
 
-// OutputAuditLog outputs audit log.
-func OutputAuditLog(dir, fileSuffix string, data []byte) error {
+func newAuditID() string {
 	auditID := base52.Encode(time.Now().UnixNano() + rand.Int63n(1000))
 	if customID := os.Getenv(EnvNameAuditID); customID != "" {
 		auditID = fmt.Sprintf("%s_%s", auditID, customID)
 	}
+	return auditID
+}
+
+// ReserveAuditID returns the ID of the next audit log output without a file
+// suffix, so that the audit log can be referred to before it's written.
+func ReserveAuditID() string {
+	reservedIDMu.Lock()
+	defer reservedIDMu.Unlock()
+
+	if reservedID == "" {
+		reservedID = newAuditID()
+	}
+	return reservedID
+}
+
+// OutputAuditLog outputs audit log.
+func OutputAuditLog(dir, fileSuffix string, data []byte) error {
+	var auditID string
 	if fileSuffix != "" {
-		auditID = fmt.Sprintf("%s_%s", auditID, fileSuffix)
+		auditID = fmt.Sprintf("%s_%s", newAuditID(), fileSuffix)
+	} else {
+		reservedIDMu.Lock()
+		auditID, reservedID = reservedID, ""
+		reservedIDMu.Unlock()
+		closeCommandAudit()
+		if auditID == "" {
+			auditID = newAuditID()
+		}
 	}
 
 	fname := filepath.Join(dir, auditID)
@@ -188,6 +219,14 @@ func ShowAuditLog(dir string, auditID string) error {
 		return errors.Trace(err)
 	}
 
+	if logprinter.GetDisplayMode().Structured() {
+		return logprinter.Output(struct {
+			ID      string `json:"id"`
+			Time    string `json:"time"`
+			Content string `json:"content"`
+		}{auditID, t.Format(time.RFC3339), string(content)})
+	}
+
 	hint := fmt.Sprintf("- OPERATION TIME: %s -", t.Format("2006-01-02T15:04:05"))
 	line := strings.Repeat("-", len(hint))
 	_, _ = os.Stdout.WriteString(color.MagentaString("%s\n%s\n%s\n", line, hint, line))
@@ -260,16 +299,17 @@ func DeleteAuditLog(dir string, retainDays int, skipConfirm bool, displayMode st
 		}
 	}
 
-	// output format json
-	if displayMode == "json" {
-		data, err := json.Marshal(struct {
+	// output format json or yaml
+	mode := logprinter.ParseDisplayMode(displayMode)
+	if mode.Structured() {
+		data, err := logprinter.Marshal(mode, struct {
 			*deleteAuditLog `json:"deleted_logs"`
 		}{deleteLog})
 
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		fmt.Print(string(data))
 	} else {
 		// print table
 		fmt.Printf("Audit logs before %s will be deleted!\nFiles to be %s are:\n %s\nTotal count: %d \nTotal size: %s\n",
@@ -291,9 +331,13 @@ func DeleteAuditLog(dir string, retainDays int, skipConfirm bool, displayMode st
 		if err := os.Remove(f); err != nil {
 			return err
 		}
+		// the commands recorded for the audit log
+		if err := os.Remove(CommandsFile(dir, filepath.Base(f))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
 
-	if displayMode != "json" {
+	if !mode.Structured() {
 		fmt.Println("clean audit log successfully")
 	}
 
@@ -71,6 +71,19 @@ func TestOutputAuditLog(t *testing.T) {
 	require.Equal(t, 20, len(paths))
 }
 
+func TestReserveAuditID(t *testing.T) {
+	dir := auditDir()
+	resetDir()
+
+	id := ReserveAuditID()
+	require.Equal(t, id, ReserveAuditID())
+	require.NoError(t, OutputAuditLog(dir, "suffix", []byte("audit log")))
+	require.NoFileExists(t, filepath.Join(dir, id))
+	require.NoError(t, OutputAuditLog(dir, "", []byte("audit log")))
+	require.FileExists(t, filepath.Join(dir, id))
+	require.NotEqual(t, id, ReserveAuditID())
+}
+
 func TestShowAuditLog(t *testing.T) {
 	dir := auditDir()
 	resetDir()
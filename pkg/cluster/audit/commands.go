@@ -0,0 +1,201 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/tui"
+	tiuputils "github.com/pingcap/tiup/pkg/utils"
+)
+
+const (
+	// commandsDirName is the directory under the audit directory where the
+	// commands executed on the hosts are recorded, one file per audit log
+	commandsDirName = "commands"
+
+	// commandOutputLimit is the max number of bytes of the stdout and stderr
+	// recorded for each command
+	commandOutputLimit = 4096
+)
+
+// the kinds of the commands recorded
+const (
+	CommandKindExecute  = "execute"
+	CommandKindUpload   = "upload"
+	CommandKindDownload = "download"
+)
+
+// CommandRecord is a command executed on a host, or a file transferred from or
+// to it, during an operation
+type CommandRecord struct {
+	Time     time.Time     `json:"time"`
+	Host     string        `json:"host"`
+	Port     int           `json:"port,omitempty"`
+	User     string        `json:"user"`
+	Kind     string        `json:"kind"`
+	Command  string        `json:"command"`
+	Sudo     bool          `json:"sudo"`
+	ExitCode int           `json:"exit_code"` // -1 if the command did not exit, e.g. it timed out
+	Duration time.Duration `json:"duration"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+var commandAudit struct {
+	sync.Mutex
+	dir  string
+	file *os.File
+}
+
+// EnableCommandAudit enables recording the commands executed on the hosts,
+// they are recorded under the audit directory with the ID of the audit log
+// of the current operation.
+func EnableCommandAudit(dir string) {
+	commandAudit.Lock()
+	defer commandAudit.Unlock()
+	commandAudit.dir = dir
+}
+
+// closeCommandAudit closes the commands recorded for the audit log output, the
+// commands recorded later belong to the next audit log
+func closeCommandAudit() {
+	commandAudit.Lock()
+	defer commandAudit.Unlock()
+	if commandAudit.file != nil {
+		_ = commandAudit.file.Close()
+		commandAudit.file = nil
+	}
+}
+
+// CommandsFile returns the path of the commands recorded for the audit log
+func CommandsFile(dir, auditID string) string {
+	return filepath.Join(dir, commandsDirName, auditID)
+}
+
+// truncateOutput truncates the output to the limit and redacts the secrets in it
+func truncateOutput(output []byte) string {
+	if len(output) <= commandOutputLimit {
+		return tiuputils.RedactSecrets(string(output))
+	}
+	return tiuputils.RedactSecrets(string(output[:commandOutputLimit])) +
+		fmt.Sprintf("...(%d bytes truncated)", len(output)-commandOutputLimit)
+}
+
+// RecordCommand appends the record of the command to the commands of the
+// current operation if it's enabled, the stdout and stderr are truncated and
+// the secrets in them and in the command are redacted.
+func RecordCommand(rec CommandRecord, stdout, stderr []byte) error {
+	commandAudit.Lock()
+	defer commandAudit.Unlock()
+	if commandAudit.dir == "" {
+		return nil
+	}
+
+	if commandAudit.file == nil {
+		path := CommandsFile(commandAudit.dir, ReserveAuditID())
+		if err := tiuputils.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return errors.Annotate(err, "open command audit log")
+		}
+		commandAudit.file = f
+	}
+
+	rec.Command = tiuputils.RedactSecrets(rec.Command)
+	rec.Stdout = truncateOutput(stdout)
+	rec.Stderr = truncateOutput(stderr)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := commandAudit.file.Write(append(data, '\n')); err != nil {
+		return errors.Annotate(err, "write command audit log")
+	}
+	return nil
+}
+
+// GetAuditCommands returns the commands recorded for the audit log
+func GetAuditCommands(dir, auditID string) ([]CommandRecord, error) {
+	if tiuputils.IsNotExist(filepath.Join(dir, auditID)) {
+		return nil, errors.Errorf("cannot find the audit log '%s'", auditID)
+	}
+
+	records := []CommandRecord{}
+	f, err := os.Open(CommandsFile(dir, auditID))
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*commandOutputLimit+1024*1024)
+	for scanner.Scan() {
+		var rec CommandRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.Annotate(err, "unknown command audit log format")
+		}
+		records = append(records, rec)
+	}
+	return records, errors.Trace(scanner.Err())
+}
+
+// ShowAuditCommands shows the commands recorded for the audit log, in JSON or
+// YAML if the display mode is structured
+func ShowAuditCommands(dir, auditID, displayMode string) error {
+	records, err := GetAuditCommands(dir, auditID)
+	if err != nil {
+		return err
+	}
+
+	if mode := logprinter.ParseDisplayMode(displayMode); mode.Structured() {
+		data, err := logprinter.Marshal(mode, records)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	table := [][]string{{"Time", "Host", "User", "Sudo", "Kind", "Exit Code", "Duration", "Command"}}
+	for _, rec := range records {
+		table = append(table, []string{
+			rec.Time.Format(time.RFC3339),
+			rec.Host,
+			rec.User,
+			strconv.FormatBool(rec.Sudo),
+			rec.Kind,
+			strconv.Itoa(rec.ExitCode),
+			rec.Duration.Round(time.Millisecond).String(),
+			rec.Command,
+		})
+	}
+	tui.PrintTable(table, true)
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordCommand(t *testing.T) {
+	dir := t.TempDir()
+	defer EnableCommandAudit("")
+
+	// nothing is recorded if it's not enabled
+	require.NoError(t, RecordCommand(CommandRecord{Host: "h0", Command: "ls"}, nil, nil))
+
+	EnableCommandAudit(dir)
+	id := ReserveAuditID()
+	require.NoError(t, RecordCommand(CommandRecord{
+		Time:     time.Now(),
+		Host:     "h1",
+		User:     "tidb",
+		Kind:     CommandKindExecute,
+		Command:  "systemctl start tikv",
+		Sudo:     true,
+		Duration: time.Second,
+	}, []byte("ok"), nil))
+	require.NoError(t, RecordCommand(CommandRecord{
+		Host:     "h2",
+		Kind:     CommandKindExecute,
+		Command:  "cat log",
+		ExitCode: 1,
+		Error:    "failed",
+	}, bytes.Repeat([]byte("x"), commandOutputLimit+10), []byte("no such file")))
+	require.NoError(t, RecordCommand(CommandRecord{
+		Host:    "h1",
+		Kind:    CommandKindExecute,
+		Command: "mysql --password=abc",
+	}, []byte("[security]\nadmin_password = abc\n"), []byte("root:abc@tcp(h1:4000)")))
+	info, err := os.Stat(CommandsFile(dir, id))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.NoError(t, OutputAuditLog(dir, "", []byte("audit log")))
+
+	// the commands recorded later belong to the next audit log
+	require.NoError(t, RecordCommand(CommandRecord{Host: "h3", Command: "ls"}, nil, nil))
+	next := ReserveAuditID()
+	require.NotEqual(t, id, next)
+	require.NoError(t, OutputAuditLog(dir, "", []byte("audit log")))
+
+	records, err := GetAuditCommands(dir, id)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "h1", records[0].Host)
+	require.True(t, records[0].Sudo)
+	require.Equal(t, "ok", records[0].Stdout)
+	require.Equal(t, time.Second, records[0].Duration)
+	require.Equal(t, 1, records[1].ExitCode)
+	require.True(t, strings.HasSuffix(records[1].Stdout, "...(10 bytes truncated)"))
+	require.Equal(t, "no such file", records[1].Stderr)
+	// the secrets are redacted
+	require.Equal(t, "mysql --password=******", records[2].Command)
+	require.Equal(t, "[security]\nadmin_password = \"******\"\n", records[2].Stdout)
+	require.Equal(t, "root:******@tcp(h1:4000)", records[2].Stderr)
+
+	records, err = GetAuditCommands(dir, next)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "h3", records[0].Host)
+
+	// the audit list doesn't include the commands
+	list, err := GetAuditList(dir)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	_, err = GetAuditCommands(dir, "unknown")
+	require.Error(t, err)
+
+	// the commands are deleted with the audit log
+	require.NoError(t, DeleteAuditLog(dir, 0, true, "json"))
+	_, err = GetAuditCommands(dir, id)
+	require.Error(t, err)
+	require.NoFileExists(t, CommandsFile(dir, id))
+}
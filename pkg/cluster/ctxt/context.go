@@ -76,6 +76,19 @@ type (
 		PrivateKeyPath string
 		PublicKeyPath  string
 
+		// The known_hosts file to verify the host keys of the remote servers with,
+		// the keys of unknown hosts are added to it if TrustUnknownHosts is set
+		KnownHostsPath    string
+		TrustUnknownHosts bool
+
+		// The jump hosts to connect to the remote servers through, by the hosts,
+		// in the ProxyJump format of OpenSSH
+		SSHProxies map[string]string
+
+		// The SSH connections shared by the executors of the builtin SSH client,
+		// the connections are not shared if it's nil
+		SSHPool *SSHPool
+
 		Concurrency int // max number of parallel tasks running at the same time
 	}
 )
@@ -113,6 +126,16 @@ func New(ctx context.Context, limit int, logger *logprinter.Logger) context.Cont
 	)
 }
 
+// NewWithSSHPool creates a context instance whose SSH connections are shared
+// through a SSHPool, the returned function closes the connections and must be
+// called once the tasks are done.
+func NewWithSSHPool(ctx context.Context, limit int, logger *logprinter.Logger) (context.Context, func()) {
+	ctx = New(ctx, limit, logger)
+	pool := NewSSHPool(0)
+	GetInner(ctx).SSHPool = pool
+	return ctx, pool.Close
+}
+
 // GetInner return *Context from context.Context's value
 func GetInner(ctx context.Context) *Context {
 	return ctx.Value(ctxKey).(*Context)
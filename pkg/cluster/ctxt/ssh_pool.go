@@ -0,0 +1,123 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxt
+
+import (
+	"os"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSSHMaxSessions is the default max number of concurrent sessions over
+// the connection to a SSH server, it's lower than the MaxSessions of sshd (10)
+// so there is room for the sessions not from the pool.
+const DefaultSSHMaxSessions = 8
+
+// EnvNameSSHMaxSessions overrides the max number of concurrent sessions over
+// the connection to a SSH server
+const EnvNameSSHMaxSessions = "TIUP_CLUSTER_SSH_MAX_SESSIONS"
+
+// SSHPool keeps one SSH connection per server and multiplexes the sessions to
+// the server over it, so large operations don't open a connection for every
+// command and trip the MaxStartups limit of sshd. The number of concurrent
+// sessions over a connection is capped by maxSessions.
+type SSHPool struct {
+	maxSessions int
+	mutex       sync.Mutex
+	conns       map[string]*pooledSSHConn
+}
+
+type pooledSSHConn struct {
+	mutex  sync.Mutex // serializes the dialing
+	client *ssh.Client
+	close  func()
+	slots  chan struct{}
+}
+
+// NewSSHPool creates a SSHPool, maxSessions is read from the environment
+// variable TIUP_CLUSTER_SSH_MAX_SESSIONS or set to the default if it's not
+// positive.
+func NewSSHPool(maxSessions int) *SSHPool {
+	if maxSessions <= 0 {
+		maxSessions = DefaultSSHMaxSessions
+		if v, err := strconv.Atoi(os.Getenv(EnvNameSSHMaxSessions)); err == nil && v > 0 {
+			maxSessions = v
+		}
+	}
+	return &SSHPool{
+		maxSessions: maxSessions,
+		conns:       make(map[string]*pooledSSHConn),
+	}
+}
+
+// Acquire returns the connection of the key, it's established with dial if
+// there is none or the previous one is broken. It waits until the number of
+// the sessions over the connection is below the cap, the returned function
+// must be called when the session is done, with broken set if the connection
+// doesn't work any more so it's closed and established again on next use.
+func (p *SSHPool) Acquire(key string, dial func() (*ssh.Client, func(), error)) (*ssh.Client, func(broken bool), error) {
+	p.mutex.Lock()
+	conn, ok := p.conns[key]
+	if !ok {
+		conn = &pooledSSHConn{slots: make(chan struct{}, p.maxSessions)}
+		p.conns[key] = conn
+	}
+	p.mutex.Unlock()
+
+	conn.slots <- struct{}{}
+	conn.mutex.Lock()
+	if conn.client == nil {
+		client, closeFn, err := dial()
+		if err != nil {
+			conn.mutex.Unlock()
+			<-conn.slots
+			return nil, nil, err
+		}
+		conn.client, conn.close = client, closeFn
+	}
+	client := conn.client
+	conn.mutex.Unlock()
+
+	var once sync.Once
+	return client, func(broken bool) {
+		once.Do(func() {
+			if broken {
+				conn.mutex.Lock()
+				if conn.client == client {
+					conn.close()
+					conn.client, conn.close = nil, nil
+				}
+				conn.mutex.Unlock()
+			}
+			<-conn.slots
+		})
+	}, nil
+}
+
+// Close closes all connections in the pool
+func (p *SSHPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for key, conn := range p.conns {
+		conn.mutex.Lock()
+		if conn.client != nil {
+			conn.close()
+			conn.client, conn.close = nil, nil
+		}
+		conn.mutex.Unlock()
+		delete(p.conns, key)
+	}
+}
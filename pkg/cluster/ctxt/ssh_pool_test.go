@@ -0,0 +1,102 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHPool(t *testing.T) {
+	pool := NewSSHPool(2)
+	dialed, closed := 0, 0
+	dial := func() (*ssh.Client, func(), error) {
+		dialed++
+		return new(ssh.Client), func() { closed++ }, nil
+	}
+
+	c1, release1, err := pool.Acquire("a", dial)
+	require.NoError(t, err)
+	c2, release2, err := pool.Acquire("a", dial)
+	require.NoError(t, err)
+	require.Same(t, c1, c2)
+	require.Equal(t, 1, dialed)
+
+	// the third session waits for a free slot
+	acquired := make(chan func(bool))
+	go func() {
+		_, release, _ := pool.Acquire("a", dial)
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the sessions over the connection exceed the cap")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release1(false)
+	release3 := <-acquired
+
+	// the broken connection is closed once and established again on next use
+	release2(true)
+	release2(true)
+	release3(false)
+	require.Equal(t, 1, closed)
+	c4, release4, err := pool.Acquire("a", dial)
+	require.NoError(t, err)
+	require.NotSame(t, c1, c4)
+	require.Equal(t, 2, dialed)
+	release4(false)
+
+	// the connections of other keys are not shared
+	_, _, err = pool.Acquire("b", func() (*ssh.Client, func(), error) {
+		return nil, nil, errors.New("refused")
+	})
+	require.Error(t, err)
+
+	pool.Close()
+	require.Equal(t, 2, closed)
+}
+
+func TestSSHPoolMaxSessions(t *testing.T) {
+	t.Setenv(EnvNameSSHMaxSessions, "3")
+	require.Equal(t, 3, NewSSHPool(0).maxSessions)
+	require.Equal(t, 5, NewSSHPool(5).maxSessions)
+
+	t.Setenv(EnvNameSSHMaxSessions, "invalid")
+	require.Equal(t, DefaultSSHMaxSessions, NewSSHPool(0).maxSessions)
+}
+
+func TestNewWithSSHPool(t *testing.T) {
+	require.Nil(t, GetInner(New(context.Background(), 0, nil)).SSHPool)
+
+	ctx, closeSSH := NewWithSSHPool(context.Background(), 0, nil)
+	pool := GetInner(ctx).SSHPool
+	require.NotNil(t, pool)
+	closed := 0
+	_, release, err := pool.Acquire("a", func() (*ssh.Client, func(), error) {
+		return new(ssh.Client), func() { closed++ }, nil
+	})
+	require.NoError(t, err)
+	release(false)
+
+	// the connections are closed once the operation ends
+	closeSSH()
+	require.Equal(t, 1, closed)
+	require.Empty(t, pool.conns)
+}
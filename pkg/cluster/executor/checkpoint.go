@@ -15,13 +15,18 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
 	"reflect"
 	"time"
 
+	"github.com/joomcode/errorx"
 	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
 )
 
 var (
@@ -83,7 +88,10 @@ func (c *CheckPointExecutor) Execute(ctx context.Context, cmd string, sudo bool,
 		return []byte(point.Hit()["stdout"].(string)), []byte(point.Hit()["stderr"].(string)), nil
 	}
 
-	return c.Executor.Execute(ctx, cmd, sudo, timeout...)
+	start := time.Now()
+	stdout, stderr, err = c.Executor.Execute(ctx, cmd, sudo, timeout...)
+	c.audit(start, audit.CommandKindExecute, cmd, sudo, stdout, stderr, err)
+	return stdout, stderr, err
 }
 
 // Transfer implements Executer interface.
@@ -111,5 +119,57 @@ func (c *CheckPointExecutor) Transfer(ctx context.Context, src, dst string, down
 		return nil
 	}
 
-	return c.Executor.Transfer(ctx, src, dst, download, limit, compress)
+	start := time.Now()
+	err = c.Executor.Transfer(ctx, src, dst, download, limit, compress)
+	kind := audit.CommandKindUpload
+	if download {
+		kind = audit.CommandKindDownload
+	}
+	c.audit(start, kind, fmt.Sprintf("%s -> %s", src, dst), false, nil, nil, err)
+	return err
+}
+
+// audit records the command executed on the host in the command audit log
+func (c *CheckPointExecutor) audit(start time.Time, kind, cmd string, sudo bool, stdout, stderr []byte, err error) {
+	rec := audit.CommandRecord{
+		Time:     start,
+		Host:     c.config.Host,
+		Port:     c.config.Port,
+		User:     c.config.User,
+		Kind:     kind,
+		Command:  cmd,
+		Sudo:     sudo,
+		ExitCode: exitCode(err),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if aerr := audit.RecordCommand(rec, stdout, stderr); aerr != nil {
+		zap.L().Warn("Failed to record the command in the audit log", zap.String("host", c.config.Host), zap.Error(aerr))
+	}
+}
+
+// exitCode returns the exit code of the command from the error returned by the
+// executor, 0 if it succeeded or -1 if the exit code is unknown
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	for err != nil {
+		var sshErr *ssh.ExitError
+		if errors.As(err, &sshErr) {
+			return sshErr.ExitStatus()
+		}
+		var execErr *exec.ExitError
+		if errors.As(err, &execErr) && execErr.Exited() {
+			return execErr.ExitCode()
+		}
+		xerr := errorx.Cast(err)
+		if xerr == nil {
+			break
+		}
+		err = xerr.Cause()
+	}
+	return -1
 }
@@ -0,0 +1,60 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"os/user"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandAudit(t *testing.T) {
+	dir := t.TempDir()
+	audit.EnableCommandAudit(dir)
+	defer audit.EnableCommandAudit("")
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	e, err := New(SSHTypeNone, false, SSHConfig{Host: "127.0.0.1", User: current.Username})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	id := audit.ReserveAuditID()
+	_, _, err = e.Execute(ctx, "echo hello", false)
+	require.NoError(t, err)
+	_, _, err = e.Execute(ctx, "echo oops >&2; exit 3", false)
+	require.Error(t, err)
+	require.NoError(t, audit.OutputAuditLog(dir, "", nil))
+
+	records, err := audit.GetAuditCommands(dir, id)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "127.0.0.1", records[0].Host)
+	assert.Equal(t, current.Username, records[0].User)
+	assert.Equal(t, audit.CommandKindExecute, records[0].Kind)
+	assert.Equal(t, 0, records[0].ExitCode)
+	assert.Equal(t, "hello\n", records[0].Stdout)
+	assert.Equal(t, 3, records[1].ExitCode)
+	assert.Equal(t, "oops\n", records[1].Stderr)
+	assert.NotEmpty(t, records[1].Error)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, -1, exitCode(ErrSSHExecuteTimedout.New("timed out")))
+}
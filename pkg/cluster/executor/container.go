@@ -0,0 +1,207 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	// SSHTypeDocker is the type of the executor running the commands in the
+	// docker containers named after the hosts, no ssh will be used
+	SSHTypeDocker SSHType = "docker"
+
+	// SSHTypePodman is the type of the executor running the commands in the
+	// podman containers named after the hosts, no ssh will be used
+	SSHTypePodman SSHType = "podman"
+
+	// SSHTypeKubectl is the type of the executor running the commands in the
+	// kubernetes pods named after the hosts, no ssh will be used
+	SSHTypeKubectl SSHType = "kubectl"
+)
+
+func init() {
+	for _, etype := range []SSHType{SSHTypeDocker, SSHTypePodman, SSHTypeKubectl} {
+		Register(etype, func(sudo bool, c *SSHConfig) (ctxt.Executor, error) {
+			return &ContainerExecutor{
+				Config:  c,
+				Runtime: string(etype),
+				Locale:  "C",
+				Sudo:    sudo,
+			}, nil
+		}, false)
+	}
+}
+
+// ContainerExecutor implements Executor by running the commands in the
+// container named after the host of the config with the CLI of the container
+// runtime, e.g. `docker exec`, for the clusters in containers without sshd.
+// The files are transferred through the stdin and stdout of the commands, so
+// only regular files are supported.
+type ContainerExecutor struct {
+	Config  *SSHConfig
+	Runtime string // the CLI of the container runtime: docker, podman or kubectl
+	Locale  string // the locale used when executing the command
+	Sudo    bool   // all commands run with this executor will be using sudo
+}
+
+var _ ctxt.Executor = &ContainerExecutor{}
+
+// execArgs returns the arguments of the runtime CLI to run the command in the
+// container. The commands run as the user of the config, or root if sudo is
+// set. kubectl runs the commands as the default user of the container, root
+// is expected, and switches to the user with su.
+func (e *ContainerExecutor) execArgs(cmd string, sudo, stdin bool) []string {
+	user := e.Config.User
+	if e.Sudo || sudo || user == "" {
+		user = "root"
+	}
+	// change wd to default home
+	cmd = fmt.Sprintf("cd; %s", cmd)
+	if e.Locale != "" {
+		cmd = fmt.Sprintf("export LANG=%s; %s", e.Locale, cmd)
+	}
+
+	args := []string{"exec"}
+	if stdin {
+		args = append(args, "-i")
+	}
+	if e.Runtime == string(SSHTypeKubectl) {
+		args = append(args, e.Config.Host, "--")
+		if user != "root" {
+			return append(args, "su", user, "-s", "/bin/bash", "-c", cmd)
+		}
+		return append(args, "/bin/bash", "-c", cmd)
+	}
+	return append(args, "-u", user, e.Config.Host, "/bin/bash", "-c", cmd)
+}
+
+// run runs the command in the container with the runtime CLI
+func (e *ContainerExecutor) run(ctx context.Context, cmd string, sudo bool, stdin io.Reader, stdout io.Writer, timeout time.Duration) (string, []byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	args := e.execArgs(cmd, sudo, stdin != nil)
+	command := exec.CommandContext(ctx, e.Runtime, args...)
+	stderr := new(bytes.Buffer)
+	command.Stdin = stdin
+	command.Stdout = stdout
+	command.Stderr = stderr
+	err := command.Run()
+	return fmt.Sprintf("%s %s", e.Runtime, strings.Join(args, " ")), stderr.Bytes(), err
+}
+
+// Execute implements Executor interface.
+func (e *ContainerExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	if len(timeout) == 0 {
+		timeout = append(timeout, executeDefaultTimeout)
+	}
+
+	stdout := new(bytes.Buffer)
+	command, stderr, err := e.run(ctx, cmd, sudo, nil, stdout, timeout[0])
+
+	logfn := zap.L().Info
+	if err != nil {
+		logfn = zap.L().Error
+	}
+	logfn("ContainerCommand",
+		zap.String("container", e.Config.Host),
+		zap.String("cmd", command),
+		zap.Error(err),
+		zap.String("stdout", stdout.String()),
+		zap.String("stderr", string(stderr)))
+
+	if err != nil {
+		baseErr := ErrSSHExecuteFailed.
+			Wrap(err, "Failed to execute command in container %s", e.Config.Host).
+			WithProperty(ErrPropSSHCommand, command).
+			WithProperty(ErrPropSSHStdout, stdout.String()).
+			WithProperty(ErrPropSSHStderr, string(stderr))
+		if stdout.Len() > 0 || len(stderr) > 0 {
+			output := strings.TrimSpace(strings.Join([]string{stdout.String(), string(stderr)}, "\n"))
+			baseErr = baseErr.
+				WithProperty(tui.SuggestionFromFormat("Command output in container %s:\n%s\n",
+					e.Config.Host,
+					color.YellowString(output)))
+		}
+		return stdout.Bytes(), stderr, baseErr
+	}
+	return stdout.Bytes(), stderr, nil
+}
+
+// Transfer implements Executer interface, limit and compress are ignored. The
+// file is copied into dst with the name of src if dst is a directory, as scp does.
+func (e *ContainerExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	var (
+		command string
+		stderr  []byte
+		err     error
+	)
+	if download {
+		if fi, err := os.Stat(dst); err == nil && fi.IsDir() {
+			dst = filepath.Join(dst, path.Base(src))
+		}
+		if err := utils.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		f, ferr := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if ferr != nil {
+			return ferr
+		}
+		defer f.Close()
+		command, stderr, err = e.run(ctx, fmt.Sprintf("cat %s", src), false, nil, f, 0)
+	} else {
+		f, ferr := os.Open(src)
+		if ferr != nil {
+			return ferr
+		}
+		defer f.Close()
+		cmd := fmt.Sprintf("if [ -d %[1]s ]; then cat > %[1]s/%[2]s; else cat > %[1]s; fi", dst, filepath.Base(src))
+		command, stderr, err = e.run(ctx, cmd, false, f, io.Discard, 0)
+	}
+
+	zap.L().Info("ContainerTransfer",
+		zap.String("container", e.Config.Host),
+		zap.String("src", src),
+		zap.String("dst", dst),
+		zap.Bool("download", download),
+		zap.Error(err),
+		zap.String("stderr", string(stderr)))
+
+	if err != nil {
+		return ErrSSHExecuteFailed.
+			Wrap(err, "Failed to transfer file %s to %s in container %s", src, dst, e.Config.Host).
+			WithProperty(ErrPropSSHCommand, command).
+			WithProperty(ErrPropSSHStderr, string(stderr))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntime is a stand-in for the CLI of the container runtimes, it records
+// the container and runs the command locally
+const fakeRuntime = `#!/bin/bash
+shift # exec
+[ "$1" = "-i" ] && shift
+[ "$1" = "-u" ] && shift 2
+echo "$1" >> "$(dirname "$0")/containers"
+shift
+[ "$1" = "--" ] && shift
+exec "$@"
+`
+
+func installFakeRuntime(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(fakeRuntime), 0755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestContainerExecArgs(t *testing.T) {
+	e := &ContainerExecutor{
+		Config:  &SSHConfig{Host: "tidb-1", User: "tidb"},
+		Runtime: "docker",
+		Locale:  "C",
+	}
+	assert.Equal(t,
+		[]string{"exec", "-u", "tidb", "tidb-1", "/bin/bash", "-c", "export LANG=C; cd; ls"},
+		e.execArgs("ls", false, false))
+	assert.Equal(t,
+		[]string{"exec", "-i", "-u", "root", "tidb-1", "/bin/bash", "-c", "export LANG=C; cd; ls"},
+		e.execArgs("ls", true, true))
+
+	e.Runtime = "kubectl"
+	assert.Equal(t,
+		[]string{"exec", "tidb-1", "--", "su", "tidb", "-s", "/bin/bash", "-c", "export LANG=C; cd; ls"},
+		e.execArgs("ls", false, false))
+	assert.Equal(t,
+		[]string{"exec", "tidb-1", "--", "/bin/bash", "-c", "export LANG=C; cd; ls"},
+		e.execArgs("ls", true, false))
+}
+
+func TestContainerExecutor(t *testing.T) {
+	dir := installFakeRuntime(t, "docker", "kubectl")
+	ctx := context.Background()
+
+	for _, etype := range []SSHType{SSHTypeDocker, SSHTypeKubectl} {
+		e, err := New(etype, true, SSHConfig{Host: "tidb-1"})
+		require.NoError(t, err)
+
+		stdout, _, err := e.Execute(ctx, "echo $LANG", false)
+		require.NoError(t, err)
+		assert.Equal(t, "C\n", string(stdout))
+
+		_, _, err = e.Execute(ctx, "echo oops >&2; exit 1", false)
+		require.Error(t, err)
+		assert.True(t, errorx.IsOfType(err, ErrSSHExecuteFailed))
+		stderr, _ := errorx.Cast(err).Property(ErrPropSSHStderr)
+		assert.Equal(t, "oops\n", stderr)
+
+		_, _, err = e.Execute(ctx, "sleep 5", false, 100*time.Millisecond)
+		assert.Error(t, err)
+
+		// upload and download the file through the container
+		src := filepath.Join(t.TempDir(), "src")
+		require.NoError(t, os.WriteFile(src, []byte("hello"), 0644))
+		remote := filepath.Join(t.TempDir(), "remote")
+		require.NoError(t, e.Transfer(ctx, src, remote, false, 0, false))
+		dst := filepath.Join(t.TempDir(), "sub", "dst")
+		require.NoError(t, e.Transfer(ctx, remote, dst, true, 0, false))
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+
+		// the file is copied into the directory with the name of the source
+		remoteDir := t.TempDir()
+		require.NoError(t, e.Transfer(ctx, src, remoteDir, false, 0, false))
+		data, err = os.ReadFile(filepath.Join(remoteDir, "src"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		dstDir := t.TempDir()
+		require.NoError(t, e.Transfer(ctx, remote, dstDir, true, 0, false))
+		data, err = os.ReadFile(filepath.Join(dstDir, "remote"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+
+		err = e.Transfer(ctx, filepath.Join(t.TempDir(), "missing"), dst, true, 0, false)
+		assert.Error(t, err)
+	}
+
+	containers, err := os.ReadFile(filepath.Join(dir, "containers"))
+	require.NoError(t, err)
+	assert.Contains(t, string(containers), "tidb-1")
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	assert.Equal(t,
+		[]string{"builtin", "docker", "kubectl", "none", "podman", "system"},
+		RegisteredTypes())
+
+	_, err := New("lxc", false, SSHConfig{Host: "tidb-1"})
+	assert.ErrorContains(t, err, "unregistered executor: lxc")
+	assert.Panics(t, func() { Register(SSHTypeDocker, nil, false) })
+
+	for _, etype := range []SSHType{"", SSHTypeBuiltin, SSHTypeSystem} {
+		assert.True(t, etype.UsesSSH(), etype)
+	}
+	for _, etype := range []SSHType{SSHTypeNone, SSHTypeDocker, SSHTypePodman, SSHTypeKubectl} {
+		assert.False(t, etype.UsesSSH(), etype)
+	}
+}
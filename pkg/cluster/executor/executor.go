@@ -54,6 +54,47 @@ var (
 	defaultSSHAuthorizedKeys = "~/.ssh/authorized_keys"
 )
 
+// UsesSSH returns false if the executor type is registered to run the commands
+// without SSH, so no SSH credentials or proxies are needed
+func (t SSHType) UsesSSH() bool {
+	if r, ok := lookup(t); ok {
+		return r.usesSSH
+	}
+	return true
+}
+
+func init() {
+	Register(SSHTypeBuiltin, func(sudo bool, c *SSHConfig) (ctxt.Executor, error) {
+		e := &EasySSHExecutor{
+			Locale: "C",
+			Sudo:   sudo,
+		}
+		e.initialize(*c)
+		return e, nil
+	}, true)
+	Register(SSHTypeSystem, func(sudo bool, c *SSHConfig) (ctxt.Executor, error) {
+		e := &NativeSSHExecutor{
+			Config: c,
+			Locale: "C",
+			Sudo:   sudo,
+		}
+		if c.Password != "" || (c.KeyFile != "" && c.Passphrase != "") {
+			_, _, e.ConnectionTestResult = e.Execute(context.Background(), connectionTestCommand, false, executeDefaultTimeout)
+		}
+		return e, nil
+	}, true)
+	Register(SSHTypeNone, func(sudo bool, c *SSHConfig) (ctxt.Executor, error) {
+		if err := checkLocalIP(c.Host); err != nil {
+			return nil, err
+		}
+		return &Local{
+			Config: c,
+			Sudo:   sudo,
+			Locale: "C",
+		}, nil
+	}, false)
+}
+
 // New create a new Executor
 func New(etype SSHType, sudo bool, c SSHConfig) (ctxt.Executor, error) {
 	if etype == "" {
@@ -82,37 +123,47 @@ func New(etype SSHType, sudo bool, c SSHConfig) (ctxt.Executor, error) {
 		c.Timeout = time.Second * 5 // default timeout is 5 sec
 	}
 
-	var executor ctxt.Executor
-	switch etype {
-	case SSHTypeBuiltin:
-		e := &EasySSHExecutor{
-			Locale: "C",
-			Sudo:   sudo,
-		}
-		e.initialize(c)
-		executor = e
-	case SSHTypeSystem:
-		e := &NativeSSHExecutor{
-			Config: &c,
-			Locale: "C",
-			Sudo:   sudo,
-		}
-		if c.Password != "" || (c.KeyFile != "" && c.Passphrase != "") {
-			_, _, e.ConnectionTestResult = e.Execute(context.Background(), connectionTestCommand, false, executeDefaultTimeout)
+	// serve the recorded results instead of connecting to the host
+	if path := os.Getenv(localdata.EnvNameExecutorReplay); path != "" {
+		replayer, err := LoadReplayer(path)
+		if err != nil {
+			return nil, err
 		}
-		executor = e
-	case SSHTypeNone:
-		if err := checkLocalIP(c.Host); err != nil {
+		return &CheckPointExecutor{replayer.Executor(c.Host), &c}, nil
+	}
+
+	// the proxies are verified with the same known_hosts file
+	for p := &c; p.Proxy != nil; p = p.Proxy {
+		hop := *p.Proxy
+		hop.KnownHosts, hop.TrustOnFirstUse = c.KnownHosts, c.TrustOnFirstUse
+		p.Proxy = &hop
+	}
+
+	// the builtin client verifies the host keys when it connects, the keys are
+	// verified before the system client connects, which trusts the known_hosts
+	// file only and can't add the unknown keys to it
+	if c.KnownHosts != "" && etype == SSHTypeSystem {
+		if _, err := scanHostKey(c); err != nil {
 			return nil, err
 		}
-		e := &Local{
-			Config: &c,
-			Sudo:   sudo,
-			Locale: "C",
+	}
+
+	r, ok := lookup(etype)
+	if !ok {
+		return nil, errors.Errorf("unregistered executor: %s, available: %s", etype, strings.Join(RegisteredTypes(), ", "))
+	}
+	executor, err := r.factory(sudo, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	// record the calls and their results
+	if path := os.Getenv(localdata.EnvNameExecutorRecord); path != "" {
+		recorder, err := OpenRecorder(path)
+		if err != nil {
+			return nil, err
 		}
-		executor = e
-	default:
-		return nil, errors.Errorf("unregistered executor: %s", etype)
+		executor = recorder.Wrap(c.Host, executor)
 	}
 
 	return &CheckPointExecutor{executor, &c}, nil
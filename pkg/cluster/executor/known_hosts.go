@@ -0,0 +1,231 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	// ErrSSHHostKeyMismatch is ErrSSHHostKeyMismatch
+	ErrSSHHostKeyMismatch = errNSSSH.NewType("host_key_mismatch")
+	// ErrSSHHostKeyUnknown is ErrSSHHostKeyUnknown
+	ErrSSHHostKeyUnknown = errNSSSH.NewType("host_key_unknown")
+
+	// the known_hosts files are shared by the executors created concurrently
+	knownHostsMutex sync.Mutex
+)
+
+// HostKey is a host key recorded in a known_hosts file
+type HostKey struct {
+	Hosts []string
+	Key   ssh.PublicKey
+}
+
+// ListKnownHosts returns the host keys in the known_hosts file, an empty list
+// is returned if the file does not exist.
+func ListKnownHosts(file string) ([]HostKey, error) {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, perrs.AddStack(err)
+	}
+
+	var keys []HostKey
+	for len(data) > 0 {
+		var hosts []string
+		var key ssh.PublicKey
+		_, hosts, key, _, data, err = ssh.ParseKnownHosts(data)
+		if err == nil {
+			keys = append(keys, HostKey{Hosts: hosts, Key: key})
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		return nil, perrs.Annotatef(err, "failed to parse %s", file)
+	}
+	return keys, nil
+}
+
+// RemoveKnownHosts removes the keys of the hosts from the known_hosts file, the
+// hosts are in the form of host or host:port, and all ports of the host are
+// matched if the port is not specified.
+func RemoveKnownHosts(file string, hosts ...string) (int, error) {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, perrs.AddStack(err)
+	}
+
+	removed := 0
+	lines := bytes.Split(data, []byte("\n"))
+	kept := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(string(line))
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "#") && matchKnownHost(fields[0], hosts) {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, utils.WriteFile(file, bytes.Join(kept, []byte("\n")), 0600)
+}
+
+// matchKnownHost checks if the comma separated host patterns of a known_hosts
+// line match any of the hosts
+func matchKnownHost(patterns string, hosts []string) bool {
+	for p := range strings.SplitSeq(patterns, ",") {
+		for _, h := range hosts {
+			if p == knownhosts.Normalize(h) {
+				return true
+			}
+			if _, _, err := net.SplitHostPort(h); err != nil &&
+				strings.HasPrefix(p, "["+strings.Trim(h, "[]")+"]:") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyHostKey checks the host key of the SSH server at the address against the
+// known_hosts file. The key of an unknown host is added to the file if trust is set.
+func verifyHostKey(file, address string, remote net.Addr, key ssh.PublicKey, trust bool) error {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	if utils.IsExist(file) {
+		callback, err := knownhosts.New(file)
+		if err != nil {
+			return perrs.Annotatef(err, "failed to load %s", file)
+		}
+		err = callback(address, remote, key)
+		if err == nil {
+			return nil
+		}
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			host, _, _ := net.SplitHostPort(address)
+			return ErrSSHHostKeyMismatch.
+				New("Host key %s of %s does not match the one in %s", ssh.FingerprintSHA256(key), address, file).
+				WithProperty(tui.SuggestionFromFormat(
+					"The host may be reinstalled, or someone is intercepting the connection.\n"+
+						"If the host key is changed on purpose, run `%s ssh-hostkeys refresh <cluster-name> --host %s` to trust the new one.",
+					tui.OsArgs0(), host))
+		}
+	}
+
+	if !trust {
+		return ErrSSHHostKeyUnknown.
+			New("Host key of %s is not found in %s", address, file).
+			WithProperty(tui.SuggestionFromFormat(
+				"Run `%s ssh-hostkeys refresh <cluster-name>` to trust the host keys of the cluster.",
+				tui.OsArgs0()))
+	}
+
+	if err := utils.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, knownhosts.Line([]string{address}, key))
+	return perrs.AddStack(err)
+}
+
+// scanHostKey connects to the SSH server, through the chain of its proxies if
+// they are set, and verifies the host keys of them with the known_hosts file of
+// the config. The verified key of the server is returned.
+func scanHostKey(c SSHConfig) (ssh.PublicKey, error) {
+	address := utils.JoinHostPort(c.Host, c.Port)
+	conn, closeProxy, err := dialThrough(c.Proxy, address, c.Timeout)
+	if err != nil {
+		if xerr := errorx.Cast(perrs.Cause(err)); xerr != nil {
+			return nil, xerr
+		}
+		return nil, err
+	}
+	defer closeProxy()
+	defer conn.Close()
+	if c.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	// the handshake is aborted once the host key is verified, the user
+	// is authenticated by the executor later
+	var hostKey ssh.PublicKey
+	var verifyErr error
+	_, _, _, err = ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User:    c.User,
+		Timeout: c.Timeout,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			if verifyErr = verifyHostKey(c.KnownHosts, hostname, remote, key, c.TrustOnFirstUse); verifyErr != nil {
+				return verifyErr
+			}
+			return errHandshakeAborted
+		},
+	})
+	switch {
+	case verifyErr != nil:
+		return nil, verifyErr
+	case hostKey == nil:
+		return nil, perrs.Annotatef(err, "failed to get the host key of %s", address)
+	}
+	return hostKey, nil
+}
+
+var errHandshakeAborted = errors.New("handshake aborted after the host key is verified")
+
+// knownHostsArgs returns the options of the system ssh client to verify the
+// host keys with the known_hosts file
+func knownHostsArgs(file string) []string {
+	return []string{
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + file,
+		"-o", "GlobalKnownHostsFile=/dev/null",
+	}
+}
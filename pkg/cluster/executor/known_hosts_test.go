@@ -0,0 +1,145 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	return signer
+}
+
+// serveHostKey starts a SSH server which presents the host key and refuses
+// all users, the port it listens on is returned
+func serveHostKey(t *testing.T, signer ssh.Signer) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, errors.New("refused")
+		},
+	}
+	config.AddHostKey(signer)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestScanHostKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	signer := newTestSigner(t)
+	c := SSHConfig{
+		Host:       "127.0.0.1",
+		Port:       serveHostKey(t, signer),
+		User:       "tidb",
+		Timeout:    5 * time.Second,
+		KnownHosts: file,
+	}
+
+	// unknown host is refused without trust on first use
+	_, err := scanHostKey(c)
+	assert.True(t, errorx.IsOfType(err, ErrSSHHostKeyUnknown), "%v", err)
+
+	c.TrustOnFirstUse = true
+	key, err := scanHostKey(c)
+	require.NoError(t, err)
+	assert.Equal(t, ssh.FingerprintSHA256(signer.PublicKey()), ssh.FingerprintSHA256(key))
+
+	// the recorded key is accepted
+	c.TrustOnFirstUse = false
+	_, err = scanHostKey(c)
+	require.NoError(t, err)
+
+	keys, err := ListKnownHosts(file)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, []string{"[127.0.0.1]:" + strconv.Itoa(c.Port)}, keys[0].Hosts)
+
+	// the host presenting another key is refused even with trust on first use
+	c.Port = serveHostKey(t, newTestSigner(t))
+	address := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	require.NoError(t, verifyHostKey(file, address, &net.TCPAddr{IP: net.ParseIP(c.Host), Port: c.Port}, signer.PublicKey(), true))
+	c.TrustOnFirstUse = true
+	_, err = scanHostKey(c)
+	assert.True(t, errorx.IsOfType(err, ErrSSHHostKeyMismatch), "%v", err)
+}
+
+func TestVerifyHostKeyMismatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.1.1"), Port: 22}
+	key := newTestSigner(t).PublicKey()
+
+	require.NoError(t, verifyHostKey(file, "10.0.1.1:22", remote, key, true))
+	require.NoError(t, verifyHostKey(file, "10.0.1.1:22", remote, key, false))
+
+	err := verifyHostKey(file, "10.0.1.1:22", remote, newTestSigner(t).PublicKey(), true)
+	assert.True(t, errorx.IsOfType(err, ErrSSHHostKeyMismatch), "%v", err)
+}
+
+func TestRemoveKnownHosts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	for _, addr := range []string{"10.0.1.1:22", "10.0.1.1:2222", "10.0.1.2:22", "10.0.1.3:2222"} {
+		host, port, _ := net.SplitHostPort(addr)
+		p, _ := strconv.Atoi(port)
+		remote := &net.TCPAddr{IP: net.ParseIP(host), Port: p}
+		require.NoError(t, verifyHostKey(file, addr, remote, newTestSigner(t).PublicKey(), true))
+	}
+
+	// all ports of the host are matched if the port is not specified
+	removed, err := RemoveKnownHosts(file, "10.0.1.1", "10.0.1.3:22")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	removed, err = RemoveKnownHosts(file, "10.0.1.3:2222")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	keys, err := ListKnownHosts(file)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, []string{"10.0.1.2"}, keys[0].Hosts)
+
+	keys, err = ListKnownHosts(filepath.Join(t.TempDir(), "not_exist"))
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	perrs "github.com/pingcap/errors"
+)
+
+// ParseProxyJump parses the jump hosts in the ProxyJump format of OpenSSH,
+// `[user@]host[:port][,[user@]host[:port]...]`, the hosts are connected one
+// through another in the order they are listed. The user and port of a hop
+// default to the ones of base, and the other fields are copied from base.
+// The config of the last hop is returned, with the previous hops as its proxy.
+func ParseProxyJump(jump string, base SSHConfig) (*SSHConfig, error) {
+	var proxy *SSHConfig
+	for hop := range strings.SplitSeq(jump, ",") {
+		hop = strings.TrimSpace(hop)
+		c := base
+		c.Proxy = proxy
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			c.User, hop = hop[:i], hop[i+1:]
+		}
+
+		c.Host = hop
+		if host, port, err := net.SplitHostPort(hop); err == nil {
+			p, err := strconv.Atoi(port)
+			if err != nil || p <= 0 || p > 65535 {
+				return nil, perrs.Errorf("invalid port of jump host '%s' in '%s'", hop, jump)
+			}
+			c.Host, c.Port = host, p
+		} else if strings.HasPrefix(hop, "[") && strings.HasSuffix(hop, "]") {
+			c.Host = hop[1 : len(hop)-1]
+		}
+		if c.Host == "" {
+			return nil, perrs.Errorf("empty jump host in '%s'", jump)
+		}
+		if c.Port <= 0 {
+			c.Port = 22
+		}
+		proxy = &c
+	}
+	return proxy, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxyJump(t *testing.T) {
+	base := SSHConfig{User: "tidb", KeyFile: "id_rsa", Timeout: 5 * time.Second}
+
+	c, err := ParseProxyJump("bastion", base)
+	require.NoError(t, err)
+	assert.Equal(t, &SSHConfig{Host: "bastion", Port: 22, User: "tidb", KeyFile: "id_rsa", Timeout: 5 * time.Second}, c)
+
+	c, err = ParseProxyJump("ops@10.0.0.1:2222, zone-b , root@[fe80::1]:22,[::1]", base)
+	require.NoError(t, err)
+	var hops []string
+	for ; c != nil; c = c.Proxy {
+		assert.Equal(t, "id_rsa", c.KeyFile)
+		hops = append([]string{fmt.Sprintf("%s@%s:%d", c.User, c.Host, c.Port)}, hops...)
+	}
+	assert.Equal(t, []string{"ops@10.0.0.1:2222", "tidb@zone-b:22", "root@fe80::1:22", "tidb@::1:22"}, hops)
+
+	base.Port = 2022
+	c, err = ParseProxyJump("a,b:23", base)
+	require.NoError(t, err)
+	assert.Equal(t, 23, c.Port)
+	assert.Equal(t, 2022, c.Proxy.Port)
+
+	for _, jump := range []string{"", "a,,b", "a:port", "a:0", "user@"} {
+		_, err = ParseProxyJump(jump, base)
+		assert.Error(t, err, jump)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// the kinds of the recorded calls
+const (
+	recordExecute  = "execute"
+	recordTransfer = "transfer"
+)
+
+var (
+	errNSReplay = errNS.NewSubNamespace("replay")
+	// ErrReplayMismatch is the error returned when a call is not in the recording
+	ErrReplayMismatch = errNSReplay.NewType("mismatch")
+)
+
+// Record is a call to the executor of a host and its result, the recordings
+// are files of the records in JSON, one record per line.
+type Record struct {
+	Host     string `json:"host"`
+	Kind     string `json:"kind"` // execute or transfer
+	Cmd      string `json:"cmd,omitempty"`
+	Sudo     bool   `json:"sudo,omitempty"`
+	Src      string `json:"src,omitempty"`
+	Dst      string `json:"dst,omitempty"`
+	Download bool   `json:"download,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Data     []byte `json:"data,omitempty"` // the content of the downloaded file
+	Error    string `json:"error,omitempty"`
+	Timedout bool   `json:"timedout,omitempty"`
+}
+
+// matches returns true if the record is the result of the call r, the local
+// paths of the transfers are not compared as they are often temporary
+func (rec *Record) matches(r *Record) bool {
+	if rec.Host != r.Host || rec.Kind != r.Kind {
+		return false
+	}
+	if rec.Kind == recordExecute {
+		return rec.Cmd == r.Cmd && rec.Sudo == r.Sudo
+	}
+	if rec.Download != r.Download {
+		return false
+	}
+	if rec.Download {
+		return rec.Src == r.Src
+	}
+	return rec.Dst == r.Dst
+}
+
+// Recorder writes the calls to the executors and their results to a file
+type Recorder struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+var (
+	recordersMutex sync.Mutex
+	recorders      = make(map[string]*Recorder)
+	replayers      = make(map[string]*Replayer)
+)
+
+// OpenRecorder opens the recording file to append the records, the recorder
+// of a file is shared by all executors in the process
+func OpenRecorder(path string) (*Recorder, error) {
+	recordersMutex.Lock()
+	defer recordersMutex.Unlock()
+	if r, ok := recorders[path]; ok {
+		return r, nil
+	}
+
+	if err := utils.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "open recording %s", path)
+	}
+	r := &Recorder{file: f}
+	recorders[path] = r
+	return r, nil
+}
+
+// Wrap returns the executor of the host which records the calls to e
+func (r *Recorder) Wrap(host string, e ctxt.Executor) ctxt.Executor {
+	return &RecordingExecutor{Executor: e, host: host, recorder: r}
+}
+
+func (r *Recorder) write(rec *Record, err error) error {
+	if err != nil {
+		rec.Error = err.Error()
+		rec.Timedout = errorx.IsOfType(err, ErrSSHExecuteTimedout)
+	}
+	line, jerr := json.Marshal(rec)
+	if jerr != nil {
+		return perrs.AddStack(jerr)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, werr := r.file.Write(append(line, '\n'))
+	return perrs.AddStack(werr)
+}
+
+// RecordingExecutor wraps Executor and records the calls and their results
+type RecordingExecutor struct {
+	ctxt.Executor
+	host     string
+	recorder *Recorder
+}
+
+// Execute implements Executor interface.
+func (e *RecordingExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	stdout, stderr, err := e.Executor.Execute(ctx, cmd, sudo, timeout...)
+	rec := &Record{
+		Host:   e.host,
+		Kind:   recordExecute,
+		Cmd:    cmd,
+		Sudo:   sudo,
+		Stdout: string(stdout),
+		Stderr: string(stderr),
+	}
+	if werr := e.recorder.write(rec, err); werr != nil && err == nil {
+		err = werr
+	}
+	return stdout, stderr, err
+}
+
+// Transfer implements Executer interface.
+func (e *RecordingExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	err := e.Executor.Transfer(ctx, src, dst, download, limit, compress)
+	rec := &Record{
+		Host:     e.host,
+		Kind:     recordTransfer,
+		Src:      src,
+		Dst:      dst,
+		Download: download,
+	}
+	if download && err == nil {
+		// only the content of the downloaded files is recorded
+		if data, rerr := os.ReadFile(dst); rerr == nil {
+			rec.Data = data
+		}
+	}
+	if werr := e.recorder.write(rec, err); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+// Replayer serves the results in a recording, each record is served once. The
+// calls to a host are matched with the records of the host in order, so the
+// calls to different hosts may be made in any order.
+type Replayer struct {
+	mutex   sync.Mutex
+	path    string
+	records map[string][]*Record // by host
+}
+
+// LoadReplayer loads the recording file, the replayer of a file is shared by
+// all executors in the process
+func LoadReplayer(path string) (*Replayer, error) {
+	recordersMutex.Lock()
+	defer recordersMutex.Unlock()
+	if r, ok := replayers[path]; ok {
+		return r, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "open recording %s", path)
+	}
+	defer f.Close()
+
+	r := &Replayer{path: path, records: make(map[string][]*Record)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		rec := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return nil, perrs.Annotatef(err, "invalid record at line %d of %s", line, path)
+		}
+		r.records[rec.Host] = append(r.records[rec.Host], rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, perrs.Annotatef(err, "read recording %s", path)
+	}
+	replayers[path] = r
+	return r, nil
+}
+
+// Executor returns the executor of the host which serves the recorded results
+func (r *Replayer) Executor(host string) ctxt.Executor {
+	return &ReplayExecutor{host: host, replayer: r}
+}
+
+// Remaining returns the records not served yet
+func (r *Replayer) Remaining() []Record {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var remaining []Record
+	for _, records := range r.records {
+		for _, rec := range records {
+			remaining = append(remaining, *rec)
+		}
+	}
+	return remaining
+}
+
+// take removes and returns the first record matches the call
+func (r *Replayer) take(call *Record) (*Record, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	records := r.records[call.Host]
+	for i, rec := range records {
+		if rec.matches(call) {
+			r.records[call.Host] = append(records[:i:i], records[i+1:]...)
+			return rec, nil
+		}
+	}
+
+	if call.Kind == recordExecute {
+		return nil, ErrReplayMismatch.New("Command '%s' (sudo: %v) on %s is not in the recording %s", call.Cmd, call.Sudo, call.Host, r.path)
+	}
+	return nil, ErrReplayMismatch.New("Transfer from %s to %s (download: %v) on %s is not in the recording %s", call.Src, call.Dst, call.Download, call.Host, r.path)
+}
+
+// ReplayExecutor implements Executor by serving the results recorded by the
+// RecordingExecutor, nothing is executed on the host.
+type ReplayExecutor struct {
+	host     string
+	replayer *Replayer
+}
+
+var _ ctxt.Executor = &ReplayExecutor{}
+
+// replayErr returns the recorded error of rec
+func replayErr(rec *Record) error {
+	if rec.Error == "" {
+		return nil
+	}
+	if rec.Timedout {
+		return ErrSSHExecuteTimedout.New("%s", rec.Error)
+	}
+	return ErrSSHExecuteFailed.New("%s", rec.Error).
+		WithProperty(ErrPropSSHCommand, rec.Cmd).
+		WithProperty(ErrPropSSHStdout, rec.Stdout).
+		WithProperty(ErrPropSSHStderr, rec.Stderr)
+}
+
+// Execute implements Executor interface.
+func (e *ReplayExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	rec, err := e.replayer.take(&Record{
+		Host: e.host,
+		Kind: recordExecute,
+		Cmd:  cmd,
+		Sudo: sudo,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(rec.Stdout), []byte(rec.Stderr), replayErr(rec)
+}
+
+// Transfer implements Executer interface, the downloaded files are written
+// with the recorded content.
+func (e *ReplayExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	rec, err := e.replayer.take(&Record{
+		Host:     e.host,
+		Kind:     recordTransfer,
+		Src:      src,
+		Dst:      dst,
+		Download: download,
+	})
+	if err != nil {
+		return err
+	}
+	if err := replayErr(rec); err != nil || !download {
+		return err
+	}
+
+	if err := utils.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return perrs.AddStack(os.WriteFile(dst, rec.Data, 0644))
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHost echoes the commands and writes the path of the source as the
+// content of the downloaded files
+type fakeHost struct{}
+
+func (fakeHost) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	switch cmd {
+	case "fail":
+		return nil, []byte("failed"), ErrSSHExecuteFailed.New("Failed to execute command")
+	case "sleep":
+		return nil, nil, ErrSSHExecuteTimedout.New("Execute command timed out")
+	}
+	return []byte(cmd), nil, nil
+}
+
+func (fakeHost) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	if download {
+		return os.WriteFile(dst, []byte(src), 0644)
+	}
+	return nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recording.jsonl")
+
+	recorder, err := OpenRecorder(path)
+	require.NoError(t, err)
+	for _, host := range []string{"10.0.1.1", "10.0.1.2"} {
+		e := recorder.Wrap(host, fakeHost{})
+		_, _, err = e.Execute(ctx, "ls "+host, false)
+		require.NoError(t, err)
+		_, _, err = e.Execute(ctx, "ls "+host, true)
+		require.NoError(t, err)
+		_, _, err = e.Execute(ctx, "fail", false)
+		require.Error(t, err)
+		_, _, err = e.Execute(ctx, "sleep", false)
+		require.Error(t, err)
+		require.NoError(t, e.Transfer(ctx, "/remote/"+host, filepath.Join(dir, host), true, 0, false))
+		require.NoError(t, e.Transfer(ctx, "/local/file", "/remote/file", false, 0, false))
+	}
+
+	replayer, err := LoadReplayer(path)
+	require.NoError(t, err)
+	assert.Len(t, replayer.Remaining(), 12)
+
+	// the calls to the hosts are replayed in a different order
+	for _, host := range []string{"10.0.1.2", "10.0.1.1"} {
+		e := replayer.Executor(host)
+		stdout, _, err := e.Execute(ctx, "ls "+host, true)
+		require.NoError(t, err)
+		assert.Equal(t, "ls "+host, string(stdout))
+
+		_, stderr, err := e.Execute(ctx, "fail", false)
+		assert.True(t, errorx.IsOfType(err, ErrSSHExecuteFailed))
+		assert.Equal(t, "failed", string(stderr))
+		_, _, err = e.Execute(ctx, "sleep", false)
+		assert.True(t, errorx.IsOfType(err, ErrSSHExecuteTimedout))
+
+		dst := filepath.Join(t.TempDir(), "sub", host)
+		require.NoError(t, e.Transfer(ctx, "/remote/"+host, dst, true, 0, false))
+		data, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		assert.Equal(t, "/remote/"+host, string(data))
+		require.NoError(t, e.Transfer(ctx, "/local/file", "/remote/file", false, 0, false))
+
+		// each record is served once
+		_, _, err = e.Execute(ctx, "fail", false)
+		assert.True(t, errorx.IsOfType(err, ErrReplayMismatch))
+		_, _, err = e.Execute(ctx, "rm -rf /", false)
+		assert.True(t, errorx.IsOfType(err, ErrReplayMismatch))
+	}
+	assert.Len(t, replayer.Remaining(), 2)
+}
+
+func TestNewReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"host":"10.0.1.1","kind":"execute","cmd":"uname","stdout":"Linux"}`+"\n"), 0644))
+	t.Setenv(localdata.EnvNameExecutorReplay, path)
+
+	// nothing is connected
+	e, err := New(SSHTypeBuiltin, false, SSHConfig{Host: "10.0.1.1", User: "tidb"})
+	require.NoError(t, err)
+	stdout, _, err := e.Execute(context.Background(), "uname", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Linux", string(stdout))
+}
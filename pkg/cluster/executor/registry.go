@@ -0,0 +1,69 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+)
+
+// Factory creates the executor to run the commands on the host of the config,
+// with sudo for all commands if sudo is set
+type Factory func(sudo bool, c *SSHConfig) (ctxt.Executor, error)
+
+// registration is the factory of a registered executor type
+type registration struct {
+	factory Factory
+	usesSSH bool // the executors connect to the hosts over SSH
+}
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = make(map[SSHType]registration)
+)
+
+// Register registers the factory of the executor type, so the type can be used
+// as the SSH type of the topology and the --ssh flag. The SSH credentials and
+// proxies are only prepared for the executors if usesSSH is set. It panics if
+// the type is registered already.
+func Register(etype SSHType, factory Factory, usesSSH bool) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	if _, ok := factories[etype]; ok {
+		panic(fmt.Sprintf("executor %s is registered already", etype))
+	}
+	factories[etype] = registration{factory: factory, usesSSH: usesSSH}
+}
+
+// RegisteredTypes returns the registered executor types in order
+func RegisteredTypes() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	types := make([]string, 0, len(factories))
+	for etype := range factories {
+		types = append(types, string(etype))
+	}
+	sort.Strings(types)
+	return types
+}
+
+func lookup(etype SSHType) (registration, bool) {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	r, ok := factories[etype]
+	return r, ok
+}
@@ -24,7 +24,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/appleboy/easyssh-proxy"
 	"github.com/fatih/color"
 	"github.com/joomcode/errorx"
 	"github.com/pingcap/errors"
@@ -65,11 +64,12 @@ func init() {
 }
 
 type (
-	// EasySSHExecutor implements Executor with EasySSH as transportation layer.
+	// EasySSHExecutor implements Executor with the builtin SSH client as transportation layer.
 	EasySSHExecutor struct {
-		Config *easyssh.MakeConfig
 		Locale string // the locale used when executing the command
 		Sudo   bool   // all commands run with this executor will be using sudo
+
+		sshConfig SSHConfig
 	}
 
 	// NativeSSHExecutor implements Excutor with native SSH transportation layer.
@@ -86,11 +86,19 @@ type (
 		Port       int           // port of the SSH server
 		User       string        // username to login to the SSH server
 		Password   string        // password of the user
-		KeyFile    string        // path to the private key file
+		KeyFile    string        // path to the private key file, its OpenSSH certificate is <KeyFile>-cert.pub
 		Passphrase string        // passphrase of the private key file
 		Timeout    time.Duration // Timeout is the maximum amount of time for the TCP connection to establish.
 		ExeTimeout time.Duration // ExeTimeout is the maximum amount of time for the command to finish
-		Proxy      *SSHConfig    // ssh proxy config
+		SSHAgent   bool          // authenticate with the keys in the ssh-agent of SSH_AUTH_SOCK as well
+		Proxy      *SSHConfig    // ssh proxy config, the proxy may have its own proxy to form a chain of jump hosts
+		// KnownHosts is the path to the known_hosts file to verify the host keys
+		// with, the host keys are not checked if it's empty.
+		KnownHosts      string
+		TrustOnFirstUse bool // add the keys of unknown hosts to the known_hosts file
+		// Pool shares the connection to the server among the executors of the
+		// builtin SSH client, the connection is established per command if nil.
+		Pool *ctxt.SSHPool
 	}
 )
 
@@ -99,40 +107,10 @@ var _ ctxt.Executor = &NativeSSHExecutor{}
 
 // initialize builds and initializes a EasySSHExecutor
 func (e *EasySSHExecutor) initialize(config SSHConfig) {
-	// build easyssh config
-	e.Config = &easyssh.MakeConfig{
-		Server:  config.Host,
-		Port:    strconv.Itoa(config.Port),
-		User:    config.User,
-		Timeout: config.Timeout, // timeout when connecting to remote
-	}
-
+	e.sshConfig = config
 	if config.ExeTimeout > 0 {
 		executeDefaultTimeout = config.ExeTimeout
 	}
-
-	// prefer private key authentication
-	if len(config.KeyFile) > 0 {
-		e.Config.KeyPath = config.KeyFile
-		e.Config.Passphrase = config.Passphrase
-	} else if len(config.Password) > 0 {
-		e.Config.Password = config.Password
-	}
-
-	if proxy := config.Proxy; proxy != nil {
-		e.Config.Proxy = easyssh.DefaultConfig{
-			Server:  proxy.Host,
-			Port:    strconv.Itoa(proxy.Port),
-			User:    proxy.User,
-			Timeout: proxy.Timeout, // timeout when connecting to remote
-		}
-		if len(proxy.KeyFile) > 0 {
-			e.Config.Proxy.KeyPath = proxy.KeyFile
-			e.Config.Proxy.Passphrase = proxy.Passphrase
-		} else if len(proxy.Password) > 0 {
-			e.Config.Proxy.Password = proxy.Password
-		}
-	}
 }
 
 // Execute run the command via SSH, it's not invoking any specific shell by default.
@@ -155,15 +133,16 @@ func (e *EasySSHExecutor) Execute(ctx context.Context, cmd string, sudo bool, ti
 		timeout = append(timeout, executeDefaultTimeout)
 	}
 
-	stdout, stderr, done, err := e.Config.Run(cmd, timeout...)
+	outBytes, errBytes, timedout, err := e.run(cmd, timeout[0])
+	stdout, stderr := string(outBytes), string(errBytes)
 
 	logfn := zap.L().Info
 	if err != nil {
 		logfn = zap.L().Error
 	}
 	logfn("SSHCommand",
-		zap.String("host", e.Config.Server),
-		zap.String("port", e.Config.Port),
+		zap.String("host", e.sshConfig.Host),
+		zap.Int("port", e.sshConfig.Port),
 		zap.String("cmd", cmd),
 		zap.Error(err),
 		zap.String("stdout", stdout),
@@ -171,7 +150,7 @@ func (e *EasySSHExecutor) Execute(ctx context.Context, cmd string, sudo bool, ti
 
 	if err != nil {
 		baseErr := ErrSSHExecuteFailed.
-			Wrap(err, "Failed to execute command over SSH for '%s@%s:%s'", e.Config.User, e.Config.Server, e.Config.Port).
+			Wrap(err, "Failed to execute command over SSH for '%s@%s:%d'", e.sshConfig.User, e.sshConfig.Host, e.sshConfig.Port).
 			WithProperty(ErrPropSSHCommand, cmd).
 			WithProperty(ErrPropSSHStdout, stdout).
 			WithProperty(ErrPropSSHStderr, stderr)
@@ -179,16 +158,16 @@ func (e *EasySSHExecutor) Execute(ctx context.Context, cmd string, sudo bool, ti
 			output := strings.TrimSpace(strings.Join([]string{stdout, stderr}, "\n"))
 			baseErr = baseErr.
 				WithProperty(tui.SuggestionFromFormat("Command output on remote host %s:\n%s\n",
-					e.Config.Server,
+					e.sshConfig.Host,
 					color.YellowString(output)))
 		}
 
 		return []byte(stdout), []byte(stderr), baseErr
 	}
 
-	if !done { // timeout case,
+	if timedout {
 		return []byte(stdout), []byte(stderr), ErrSSHExecuteTimedout.
-			Wrap(err, "Execute command over SSH timedout for '%s@%s:%s'", e.Config.User, e.Config.Server, e.Config.Port).
+			New("Execute command over SSH timedout for '%s@%s:%d'", e.sshConfig.User, e.sshConfig.Host, e.sshConfig.Port).
 			WithProperty(ErrPropSSHCommand, cmd).
 			WithProperty(ErrPropSSHStdout, stdout).
 			WithProperty(ErrPropSSHStderr, stderr)
@@ -203,19 +182,19 @@ func (e *EasySSHExecutor) Execute(ctx context.Context, cmd string, sudo bool, ti
 // file from remote to local.
 func (e *EasySSHExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
 	if !download {
-		err := e.Config.Scp(src, dst)
+		err := e.upload(src, dst)
 		if err != nil {
-			return errors.Annotatef(err, "failed to scp %s to %s@%s:%s", src, e.Config.User, e.Config.Server, dst)
+			return errors.Annotatef(err, "failed to scp %s to %s@%s:%s", src, e.sshConfig.User, e.sshConfig.Host, dst)
 		}
 		return nil
 	}
 
 	// download file from remote
-	session, client, err := e.Config.Connect()
+	client, session, release, err := e.session()
 	if err != nil {
 		return err
 	}
-	defer client.Close()
+	defer release(false)
 	defer session.Close()
 
 	err = utils.MkdirAll(filepath.Dir(dst), 0755)
@@ -232,6 +211,15 @@ func (e *NativeSSHExecutor) prompt(def string) string {
 	return def
 }
 
+// hostKeyArgs returns the options to check the host keys with the known_hosts
+// file of the config, or not to check them if it's not set
+func (e *NativeSSHExecutor) hostKeyArgs() []string {
+	if e.Config.KnownHosts == "" {
+		return []string{"-o", "StrictHostKeyChecking=no"}
+	}
+	return knownHostsArgs(e.Config.KnownHosts)
+}
+
 func (e *NativeSSHExecutor) configArgs(args []string, isScp bool) []string {
 	if e.Config.Port != 0 && e.Config.Port != 22 {
 		if isScp {
@@ -252,27 +240,41 @@ func (e *NativeSSHExecutor) configArgs(args []string, isScp bool) []string {
 		}
 	}
 
-	proxy := e.Config.Proxy
-	if proxy != nil {
-		proxyArgs := []string{"ssh"}
-		if proxy.Timeout != 0 {
-			proxyArgs = append(proxyArgs, "-o", fmt.Sprintf("ConnectTimeout=%d", int64(proxy.Timeout.Seconds())))
-		}
-		if proxy.Password != "" {
-			proxyArgs = append([]string{"sshpass", "-p", proxy.Password, "-P", e.prompt("password")}, proxyArgs...)
-		} else if proxy.KeyFile != "" {
-			proxyArgs = append(proxyArgs, "-i", proxy.KeyFile)
-			if proxy.Passphrase != "" {
-				proxyArgs = append([]string{"sshpass", "-p", proxy.Passphrase, "-P", e.prompt("passphrase")}, proxyArgs...)
-			}
-		}
+	if proxy := e.Config.Proxy; proxy != nil {
 		// Don't need to extra quote it, exec.Command will handle it right
 		// ref https://stackoverflow.com/a/26473771/2298986
-		args = append(args, []string{"-o", fmt.Sprintf(`ProxyCommand=%s %s@%s -p %d -W %%h:%%p`, strings.Join(proxyArgs, " "), proxy.User, proxy.Host, proxy.Port)}...)
+		args = append(args, "-o", "ProxyCommand="+e.proxyCommand(proxy))
 	}
 	return args
 }
 
+// proxyCommand returns the command to connect through the proxy, the proxy of
+// the proxy is connected through in its own ProxyCommand. The command is run by
+// the shell after its %-tokens are expanded by ssh, so the nested command is
+// escaped and quoted for each level of the chain.
+func (e *NativeSSHExecutor) proxyCommand(proxy *SSHConfig) string {
+	proxyArgs := []string{"ssh"}
+	if e.Config.KnownHosts != "" {
+		proxyArgs = append(proxyArgs, knownHostsArgs(e.Config.KnownHosts)...)
+	}
+	if proxy.Timeout != 0 {
+		proxyArgs = append(proxyArgs, "-o", fmt.Sprintf("ConnectTimeout=%d", int64(proxy.Timeout.Seconds())))
+	}
+	if proxy.Password != "" {
+		proxyArgs = append([]string{"sshpass", "-p", proxy.Password, "-P", e.prompt("password")}, proxyArgs...)
+	} else if proxy.KeyFile != "" {
+		proxyArgs = append(proxyArgs, "-i", proxy.KeyFile)
+		if proxy.Passphrase != "" {
+			proxyArgs = append([]string{"sshpass", "-p", proxy.Passphrase, "-P", e.prompt("passphrase")}, proxyArgs...)
+		}
+	}
+	if proxy.Proxy != nil {
+		nested := strings.ReplaceAll("ProxyCommand="+e.proxyCommand(proxy.Proxy), "%", "%%")
+		proxyArgs = append(proxyArgs, "-o", "'"+strings.ReplaceAll(nested, "'", `'\''`)+"'")
+	}
+	return fmt.Sprintf(`%s %s@%s -p %d -W %%h:%%p`, strings.Join(proxyArgs, " "), proxy.User, proxy.Host, proxy.Port)
+}
+
 // Execute run the command via SSH, it's not invoking any specific shell by default.
 func (e *NativeSSHExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
 	if e.ConnectionTestResult != nil {
@@ -312,7 +314,7 @@ func (e *NativeSSHExecutor) Execute(ctx context.Context, cmd string, sudo bool,
 		ssh = val
 	}
 
-	args := []string{ssh, "-o", "StrictHostKeyChecking=no"}
+	args := append([]string{ssh}, e.hostKeyArgs()...)
 
 	args = e.configArgs(args, false) // prefix and postfix args
 	args = append(args, fmt.Sprintf("%s@%s", e.Config.User, e.Config.Host), cmd)
@@ -373,7 +375,7 @@ func (e *NativeSSHExecutor) Transfer(ctx context.Context, src, dst string, downl
 		scp = val
 	}
 
-	args := []string{scp, "-r", "-o", "StrictHostKeyChecking=no"}
+	args := append([]string{scp, "-r"}, e.hostKeyArgs()...)
 	if limit > 0 {
 		args = append(args, "-l", fmt.Sprint(limit))
 	}
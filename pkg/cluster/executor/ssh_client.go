@@ -0,0 +1,343 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ScaleFT/sshkeys"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sessionCloseTimeout is the time to wait for a timed out session to be closed
+const sessionCloseTimeout = 5 * time.Second
+
+// certFileSuffix is the suffix OpenSSH looks for the certificate of a private key with
+const certFileSuffix = "-cert.pub"
+
+// sshAuthMethods returns the methods to authenticate with the SSH server of the
+// config: the password, the private key along with its OpenSSH certificate if
+// there is one next to it, and the keys in the ssh-agent of SSH_AUTH_SOCK if
+// SSHAgent is set. The returned closer releases the connection to the agent,
+// it's nil if no agent is connected.
+func sshAuthMethods(c *SSHConfig) ([]ssh.AuthMethod, io.Closer, error) {
+	var auths []ssh.AuthMethod
+	if len(c.Password) > 0 {
+		auths = append(auths, ssh.Password(c.Password))
+	}
+
+	var signers []ssh.Signer
+	if len(c.KeyFile) > 0 {
+		var err error
+		if signers, err = keyFileSigners(c.KeyFile, c.Passphrase); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var closer io.Closer
+	var agentClient agent.ExtendedAgent
+	if c.SSHAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, perrs.Annotatef(err, "failed to connect to the ssh-agent of SSH_AUTH_SOCK '%s'", sock)
+		}
+		agentClient, closer = agent.NewClient(conn), conn
+	}
+
+	// the public keys are offered in one method, as the client does not try
+	// another method of the same name once the first one is refused
+	if len(signers) > 0 || agentClient != nil {
+		auths = append(auths, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if agentClient == nil {
+				return signers, nil
+			}
+			agentSigners, err := agentClient.Signers()
+			if err != nil {
+				return nil, err
+			}
+			return append(signers[:len(signers):len(signers)], agentSigners...), nil
+		}))
+	}
+	return auths, closer, nil
+}
+
+// keyFileSigners returns the signers of the private key, the certificate of the
+// key is tried first if it exists
+func keyFileSigners(keyFile, passphrase string) ([]ssh.Signer, error) {
+	buf, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = sshkeys.ParseEncryptedPrivateKey(buf, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(buf)
+	}
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to parse private key %s", keyFile)
+	}
+
+	certFile := keyFile + certFileSuffix
+	if !utils.IsExist(certFile) {
+		return []ssh.Signer{signer}, nil
+	}
+	buf, err = os.ReadFile(certFile)
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(buf)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to parse certificate %s", certFile)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, perrs.Errorf("%s is not an OpenSSH certificate", certFile)
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "certificate %s does not match private key %s", certFile, keyFile)
+	}
+	return []ssh.Signer{certSigner, signer}, nil
+}
+
+// clientConfig returns the config to connect to the SSH server of c, the host
+// key of the server is verified with the known_hosts file of c if it's set
+func clientConfig(c *SSHConfig) (*ssh.ClientConfig, io.Closer, error) {
+	auths, closer, err := sshAuthMethods(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	callback := ssh.InsecureIgnoreHostKey()
+	if c.KnownHosts != "" {
+		callback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return verifyHostKey(c.KnownHosts, hostname, remote, key, c.TrustOnFirstUse)
+		}
+	}
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            auths,
+		Timeout:         c.Timeout,
+		HostKeyCallback: callback,
+	}, closer, nil
+}
+
+// dialThrough connects to the address through the chain of SSH proxies, or
+// directly if proxy is nil. The returned function closes the connections to
+// the proxies.
+func dialThrough(proxy *SSHConfig, address string, timeout time.Duration) (net.Conn, func(), error) {
+	if proxy == nil {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return nil, nil, perrs.AddStack(err)
+		}
+		return conn, func() {}, nil
+	}
+
+	client, closeFn, err := ConnectSSH(proxy)
+	if err != nil {
+		return nil, nil, perrs.Annotatef(err, "failed to connect to proxy %s", utils.JoinHostPort(proxy.Host, proxy.Port))
+	}
+	conn, err := client.Dial("tcp", address)
+	if err != nil {
+		closeFn()
+		return nil, nil, perrs.Annotatef(err, "failed to connect to %s via proxy %s", address, utils.JoinHostPort(proxy.Host, proxy.Port))
+	}
+	return conn, closeFn, nil
+}
+
+// ConnectSSH establishes the SSH connection to the server of c, through the
+// chain of its proxies if they are set. The returned function closes the
+// connection and the ones to the proxies.
+func ConnectSSH(c *SSHConfig) (*ssh.Client, func(), error) {
+	config, closer, err := clientConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	address := utils.JoinHostPort(c.Host, c.Port)
+	conn, closeProxy, err := dialThrough(c.Proxy, address, c.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		closeProxy()
+		// keep the host key errors as they are for the suggestions
+		var xerr *errorx.Error
+		if errors.As(err, &xerr) {
+			return nil, nil, xerr
+		}
+		return nil, nil, err
+	}
+	client := ssh.NewClient(ncc, chans, reqs)
+	return client, func() {
+		client.Close()
+		closeProxy()
+	}, nil
+}
+
+// connect returns the SSH connection to the server, through the proxies if
+// they are set. The connection is shared through the pool of the config if it's
+// set, otherwise it's established for the caller. The returned function releases
+// the connection, it's closed if it's not shared or broken is set.
+func (e *EasySSHExecutor) connect() (*ssh.Client, func(broken bool), error) {
+	pool := e.sshConfig.Pool
+	if pool == nil {
+		client, closeFn, err := ConnectSSH(&e.sshConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func(bool) { closeFn() }, nil
+	}
+	return pool.Acquire(poolKey(&e.sshConfig), func() (*ssh.Client, func(), error) {
+		return ConnectSSH(&e.sshConfig)
+	})
+}
+
+// poolKey returns the key of the connection to the server in the pool, the
+// connections of different credentials or proxies are not shared
+func poolKey(c *SSHConfig) string {
+	key := fmt.Sprintf("%s@%s key=%s", c.User, utils.JoinHostPort(c.Host, c.Port), c.KeyFile)
+	for p := c.Proxy; p != nil; p = p.Proxy {
+		key += fmt.Sprintf(" via %s@%s", p.User, utils.JoinHostPort(p.Host, p.Port))
+	}
+	return key
+}
+
+// session opens a session to the server, the shared connection is established
+// again once if it's broken. The returned function releases the connection.
+func (e *EasySSHExecutor) session() (*ssh.Client, *ssh.Session, func(broken bool), error) {
+	for retry := e.sshConfig.Pool != nil; ; retry = false {
+		client, release, err := e.connect()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		session, err := client.NewSession()
+		if err == nil {
+			return client, session, release, nil
+		}
+
+		// the session is refused by the server, the connection still works
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			release(false)
+			return nil, nil, nil, err
+		}
+		release(true)
+		if !retry {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+// run runs the command on the server, the session is closed and timedout is
+// returned if the command does not finish in time
+func (e *EasySSHExecutor) run(cmd string, timeout time.Duration) (stdout, stderr []byte, timedout bool, err error) {
+	_, session, release, err := e.session()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	broken := false
+	defer func() { release(broken) }()
+	defer session.Close()
+
+	outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+	session.Stdout = outBuf
+	session.Stderr = errBuf
+	if err := session.Start(cmd); err != nil {
+		return nil, nil, false, err
+	}
+
+	waitC := make(chan error, 1)
+	go func() {
+		waitC <- session.Wait()
+	}()
+	select {
+	case err = <-waitC:
+	case <-time.After(timeout):
+		// the output is read after the copying is stopped by the close, the
+		// connection is dropped if the session is not closed in time
+		_ = session.Close()
+		select {
+		case <-waitC:
+		case <-time.After(sessionCloseTimeout):
+			broken = true
+			release(true)
+			<-waitC
+		}
+		timedout = true
+	}
+	return outBuf.Bytes(), errBuf.Bytes(), timedout, err
+}
+
+// upload copies the local file to the server with the scp protocol
+func (e *EasySSHExecutor) upload(src, dst string) error {
+	_, session, release, err := e.session()
+	if err != nil {
+		return err
+	}
+	defer release(false)
+	defer session.Close()
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	copyErrC := make(chan error, 1)
+	go func() {
+		defer w.Close()
+		if _, err := fmt.Fprintln(w, "C0644", stat.Size(), filepath.Base(dst)); err != nil {
+			copyErrC <- err
+			return
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			copyErrC <- err
+			return
+		}
+		_, err := fmt.Fprint(w, "\x00")
+		copyErrC <- err
+	}()
+
+	if err := session.Run(fmt.Sprintf("scp -tr %s", dst)); err != nil {
+		return err
+	}
+	return <-copyErrC
+}
@@ -0,0 +1,410 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// serveCertAuth starts a SSH server which only accepts the users with a
+// certificate signed by the CA, and echoes the commands executed. The port it
+// listens on and the number of the connections accepted are returned.
+func serveCertAuth(t *testing.T, ca ssh.PublicKey) (int, *atomic.Int32) {
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.Marshal())
+		},
+	}
+	config := &ssh.ServerConfig{PublicKeyCallback: checker.Authenticate}
+	config.AddHostKey(newTestSigner(t))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	accepted := new(atomic.Int32)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					ch, chReqs, err := nc.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range chReqs {
+							if req.Type != "exec" {
+								_ = req.Reply(false, nil)
+								continue
+							}
+							var payload struct{ Command string }
+							_ = ssh.Unmarshal(req.Payload, &payload)
+							_ = req.Reply(true, nil)
+							_, _ = ch.Write([]byte(payload.Command))
+							_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+							ch.Close()
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port, accepted
+}
+
+func newTestCert(t *testing.T, ca ssh.Signer, key ssh.PublicKey) *ssh.Certificate {
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"tidb"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+func TestBuiltinSSHCertificate(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ca := newTestSigner(t)
+	port, _ := serveCertAuth(t, ca.PublicKey())
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	e := &EasySSHExecutor{}
+	e.initialize(SSHConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		User:    "tidb",
+		KeyFile: keyFile,
+		Timeout: 5 * time.Second,
+	})
+
+	// the key alone is refused
+	_, _, err = e.Execute(context.Background(), "true", false)
+	assert.Error(t, err)
+
+	cert := newTestCert(t, ca, signer.PublicKey())
+	require.NoError(t, os.WriteFile(keyFile+certFileSuffix, ssh.MarshalAuthorizedKey(cert), 0644))
+	stdout, _, err := e.Execute(context.Background(), "true", false)
+	require.NoError(t, err)
+	assert.Contains(t, string(stdout), "true")
+}
+
+func TestBuiltinSSHHostKey(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ca := newTestSigner(t)
+	port, accepted := serveCertAuth(t, ca.PublicKey())
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	cert := newTestCert(t, ca, signer.PublicKey())
+	require.NoError(t, os.WriteFile(keyFile+certFileSuffix, ssh.MarshalAuthorizedKey(cert), 0644))
+
+	file := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	c := SSHConfig{
+		Host:       "127.0.0.1",
+		Port:       port,
+		User:       "tidb",
+		KeyFile:    keyFile,
+		Timeout:    5 * time.Second,
+		KnownHosts: file,
+	}
+
+	// the host key is verified by the connection of the command, the host is
+	// not connected in advance
+	e, err := New(SSHTypeBuiltin, false, c)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), accepted.Load())
+	_, _, err = e.Execute(context.Background(), "true", false)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), accepted.Load())
+
+	c.TrustOnFirstUse = true
+	e, err = New(SSHTypeBuiltin, false, c)
+	require.NoError(t, err)
+	_, _, err = e.Execute(context.Background(), "true", false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), accepted.Load())
+
+	keys, err := ListKnownHosts(file)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, []string{"[127.0.0.1]:" + strconv.Itoa(port)}, keys[0].Hosts)
+}
+
+func TestBuiltinSSHAgent(t *testing.T) {
+	ca := newTestSigner(t)
+	port, _ := serveCertAuth(t, ca.PublicKey())
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{
+		PrivateKey:  priv,
+		Certificate: newTestCert(t, ca, signer.PublicKey()),
+	}))
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	// the agent is not used unless it's enabled
+	e := &EasySSHExecutor{}
+	e.initialize(SSHConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		User:    "tidb",
+		Timeout: 5 * time.Second,
+	})
+	_, _, err = e.Execute(context.Background(), "true", false)
+	assert.Error(t, err)
+
+	// the keys in the agent are offered after the refused key file
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(other, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+
+	e.initialize(SSHConfig{
+		Host:     "127.0.0.1",
+		Port:     port,
+		User:     "tidb",
+		KeyFile:  keyFile,
+		SSHAgent: true,
+		Timeout:  5 * time.Second,
+	})
+	stdout, _, err := e.Execute(context.Background(), "true", false)
+	require.NoError(t, err)
+	assert.Contains(t, string(stdout), "true")
+}
+
+// serveJumpHost starts a SSH server which accepts the password and forwards
+// the direct-tcpip channels, the port it listens on is returned
+func serveJumpHost(t *testing.T, password string) int {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, errors.New("refused")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(newTestSigner(t))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					var target struct {
+						Host     string
+						Port     uint32
+						OrigHost string
+						OrigPort uint32
+					}
+					if nc.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nc.ExtraData(), &target) != nil {
+						_ = nc.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						_ = nc.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, chReqs, err := nc.Accept()
+					if err != nil {
+						upstream.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() {
+						defer ch.Close()
+						defer upstream.Close()
+						go func() { _, _ = io.Copy(upstream, ch) }()
+						_, _ = io.Copy(ch, upstream)
+					}()
+				}
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestBuiltinSSHProxyJump(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	ca := newTestSigner(t)
+	port, _ := serveCertAuth(t, ca.PublicKey())
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	cert := newTestCert(t, ca, signer.PublicKey())
+	require.NoError(t, os.WriteFile(keyFile+certFileSuffix, ssh.MarshalAuthorizedKey(cert), 0644))
+
+	jump := fmt.Sprintf("a@127.0.0.1:%d,b@127.0.0.1:%d", serveJumpHost(t, "pass-a"), serveJumpHost(t, "pass-a"))
+	proxy, err := ParseProxyJump(jump, SSHConfig{Password: "pass-a", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	e, err := New(SSHTypeBuiltin, false, SSHConfig{
+		Host:            "127.0.0.1",
+		Port:            port,
+		User:            "tidb",
+		KeyFile:         keyFile,
+		Timeout:         5 * time.Second,
+		Proxy:           proxy,
+		KnownHosts:      knownHosts,
+		TrustOnFirstUse: true,
+	})
+	require.NoError(t, err)
+	stdout, _, err := e.Execute(context.Background(), "true", false)
+	require.NoError(t, err)
+	assert.Contains(t, string(stdout), "true")
+
+	// the host keys of the jump hosts and the server are all recorded
+	keys, err := ListKnownHosts(knownHosts)
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+}
+
+func TestBuiltinSSHPool(t *testing.T) {
+	ca := newTestSigner(t)
+	port, accepted := serveCertAuth(t, ca.PublicKey())
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	cert := newTestCert(t, ca, signer.PublicKey())
+	require.NoError(t, os.WriteFile(keyFile+certFileSuffix, ssh.MarshalAuthorizedKey(cert), 0644))
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	pool := ctxt.NewSSHPool(2)
+	defer pool.Close()
+	e := &EasySSHExecutor{}
+	e.initialize(SSHConfig{
+		Host:    "127.0.0.1",
+		Port:    port,
+		User:    "tidb",
+		KeyFile: keyFile,
+		Timeout: 5 * time.Second,
+		Pool:    pool,
+	})
+
+	// the commands run concurrently share one connection
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := fmt.Sprintf("echo %d", i)
+			stdout, _, err := e.Execute(context.Background(), cmd, false)
+			assert.NoError(t, err)
+			assert.Contains(t, string(stdout), cmd)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted.Load())
+
+	// the connection is established again after the pool is closed
+	pool.Close()
+	_, _, err = e.Execute(context.Background(), "true", false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), accepted.Load())
+}
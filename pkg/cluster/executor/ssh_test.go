@@ -125,6 +125,39 @@ func TestNativeSSHConfigArgs(t *testing.T) {
 			false,
 			"sshpass -p pass -P password -o ConnectTimeout=60 -o ProxyCommand=sshpass -p word -P password ssh -o ConnectTimeout=10 root@proxy1 -p 222 -W %h:%p",
 		},
+		{
+			&SSHConfig{
+				KeyFile:    "id_rsa",
+				KnownHosts: "known_hosts",
+				Proxy: &SSHConfig{
+					User:    "root",
+					Host:    "proxy1",
+					Port:    222,
+					KeyFile: "b.id_rsa",
+				},
+			},
+			false,
+			"-i id_rsa -o ProxyCommand=ssh -o StrictHostKeyChecking=yes -o UserKnownHostsFile=known_hosts -o GlobalKnownHostsFile=/dev/null -i b.id_rsa root@proxy1 -p 222 -W %h:%p",
+		},
+		{
+			&SSHConfig{
+				KeyFile: "id_rsa",
+				Proxy: &SSHConfig{
+					User:    "root",
+					Host:    "proxy2",
+					Port:    222,
+					KeyFile: "b.id_rsa",
+					Proxy: &SSHConfig{
+						User:    "jump",
+						Host:    "proxy1",
+						Port:    22,
+						KeyFile: "a.id_rsa",
+					},
+				},
+			},
+			false,
+			"-i id_rsa -o ProxyCommand=ssh -i b.id_rsa -o 'ProxyCommand=ssh -i a.id_rsa jump@proxy1 -p 22 -W %%h:%%p' root@proxy2 -p 222 -W %h:%p",
+		},
 	}
 
 	e := &NativeSSHExecutor{}
@@ -0,0 +1,242 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"go.uber.org/zap"
+)
+
+const (
+	// the suffix of the partial file on the remote host while uploading
+	partialSuffix = ".tiup-part"
+	// the checksum of the remote directories
+	remoteDir = "directory"
+)
+
+var (
+	// uploadChunkSize is the size of the chunks the large files are uploaded in
+	uploadChunkSize int64 = 64 << 20
+	// uploadChunkRetry is the number of attempts to upload a chunk
+	uploadChunkRetry = 3
+	// uploadCheckTimeout is the timeout of the commands reading the whole
+	// remote file, e.g. computing the checksum
+	uploadCheckTimeout = 10 * time.Minute
+
+	errNSTransfer = errNS.NewSubNamespace("transfer")
+	// ErrTransferChecksumMismatch is the error returned when the uploaded file
+	// differs from the local one
+	ErrTransferChecksumMismatch = errNSTransfer.NewType("checksum_mismatch")
+)
+
+// Upload copies the local file to the host in chunks with the executor. The
+// chunks are appended to a partial file next to dst, which is verified with
+// sha256 and renamed to dst at last. The failed chunks are retried, and the
+// partial file left by a failed upload is resumed from the next time. The
+// upload is skipped if dst is identical to src already. The directories, the
+// empty files and the hosts without sha256sum are copied with Transfer as is.
+func Upload(ctx context.Context, e ctxt.Executor, src, dst string, limit int, compress bool) error {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	if stat.IsDir() || stat.Size() == 0 {
+		return e.Transfer(ctx, src, dst, false, limit, compress)
+	}
+
+	// the chunks can't be verified on the hosts without sha256sum
+	if _, _, err := e.Execute(ctx, "command -v sha256sum", false); err != nil {
+		zap.L().Debug("Checksum is not supported on the host, upload the file at once", zap.String("dst", dst), zap.Error(err))
+		return e.Transfer(ctx, src, dst, false, limit, compress)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer f.Close()
+	remote, err := remoteChecksum(ctx, e, dst)
+	if err != nil {
+		return err
+	}
+	// the file is uploaded into the directory like scp
+	if remote == remoteDir {
+		dst = path.Join(dst, filepath.Base(src))
+		if remote, err = remoteChecksum(ctx, e, dst); err != nil {
+			return err
+		}
+	}
+
+	u := &uploader{
+		e:        e,
+		f:        f,
+		src:      src,
+		part:     dst + partialSuffix,
+		size:     stat.Size(),
+		limit:    limit,
+		compress: compress,
+	}
+	checksum, err := u.localChecksum(u.size)
+	if err != nil {
+		return err
+	}
+	if remote == checksum {
+		zap.L().Debug("Skip uploading the identical file", zap.String("src", src), zap.String("dst", dst))
+		return nil
+	}
+
+	offset, err := u.resumeOffset(ctx)
+	if err != nil {
+		return err
+	}
+	for offset < u.size {
+		size := min(uploadChunkSize, u.size-offset)
+		for attempt := 1; ; attempt++ {
+			err = u.uploadChunk(ctx, offset, size)
+			if err == nil || attempt >= uploadChunkRetry || ctx.Err() != nil {
+				break
+			}
+			zap.L().Warn("Failed to upload the chunk, retry",
+				zap.String("dst", dst), zap.Int64("offset", offset), zap.Int("attempt", attempt), zap.Error(err))
+		}
+		if err != nil {
+			return perrs.Annotatef(err, "upload %s to %s at offset %d", src, dst, offset)
+		}
+		offset += size
+	}
+
+	if remote, err = remoteChecksum(ctx, e, u.part); err != nil {
+		return err
+	}
+	if remote != checksum {
+		_, _, _ = e.Execute(ctx, fmt.Sprintf("rm -f %s", u.part), false)
+		return ErrTransferChecksumMismatch.New("Checksum of the uploaded file %s is %s, but %s is expected", dst, remote, checksum)
+	}
+	_, _, err = e.Execute(ctx, fmt.Sprintf("mv -f %s %s", u.part, dst), false)
+	return err
+}
+
+// remoteChecksum returns the sha256 of the remote file, remoteDir if it's a
+// directory, or empty if it does not exist
+func remoteChecksum(ctx context.Context, e ctxt.Executor, path string) (string, error) {
+	cmd := fmt.Sprintf("if [ -d %[1]s ]; then echo %[2]s; elif [ -f %[1]s ]; then sha256sum %[1]s; fi", path, remoteDir)
+	stdout, _, err := e.Execute(ctx, cmd, false, uploadCheckTimeout)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(stdout))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], nil
+}
+
+// uploader uploads the local file to the partial file on the host
+type uploader struct {
+	e        ctxt.Executor
+	f        *os.File
+	src      string
+	part     string
+	size     int64
+	limit    int
+	compress bool
+}
+
+// localChecksum returns the sha256 of the first n bytes of the local file
+func (u *uploader) localChecksum(n int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(u.f, 0, n)); err != nil {
+		return "", perrs.AddStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resumeOffset returns the offset to resume the upload from, the partial file
+// is truncated to the last complete chunk, and removed if it differs from the
+// local file
+func (u *uploader) resumeOffset(ctx context.Context) (int64, error) {
+	stdout, _, err := u.e.Execute(ctx, fmt.Sprintf("stat -c %%s %s 2>/dev/null || echo 0", u.part), false)
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(string(bytes.TrimSpace(stdout)), 10, 64)
+	if err != nil {
+		return 0, perrs.Annotatef(err, "invalid size of %s", u.part)
+	}
+
+	if offset := min(size/uploadChunkSize*uploadChunkSize, u.size); offset > 0 {
+		checksum, err := u.localChecksum(offset)
+		if err != nil {
+			return 0, err
+		}
+		cmd := fmt.Sprintf("truncate -s %[1]d %[2]s && head -c %[1]d %[2]s | sha256sum", offset, u.part)
+		stdout, _, err := u.e.Execute(ctx, cmd, false, uploadCheckTimeout)
+		if err == nil && strings.HasPrefix(string(stdout), checksum) {
+			zap.L().Info("Resume uploading", zap.String("part", u.part), zap.Int64("offset", offset))
+			return offset, nil
+		}
+	}
+
+	_, _, err = u.e.Execute(ctx, fmt.Sprintf("rm -f %s", u.part), false)
+	return 0, err
+}
+
+// uploadChunk appends the chunk of the local file at offset to the partial file
+func (u *uploader) uploadChunk(ctx context.Context, offset, size int64) error {
+	chunk := fmt.Sprintf("%s.%d", u.part, offset)
+	src := u.src
+	if size < u.size {
+		tmp, err := os.CreateTemp("", "tiup-chunk-*")
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = io.Copy(tmp, io.NewSectionReader(u.f, offset, size))
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+		src = tmp.Name()
+	}
+	if err := u.e.Transfer(ctx, src, chunk, false, u.limit, u.compress); err != nil {
+		return err
+	}
+
+	// the partial file is truncated in case the previous attempt is appended partly
+	cmd := fmt.Sprintf("truncate -s %[1]d %[2]s && cat %[3]s >> %[2]s && rm -f %[3]s && stat -c %%s %[2]s", offset, u.part, chunk)
+	stdout, _, err := u.e.Execute(ctx, cmd, false)
+	if err != nil {
+		return err
+	}
+	if got := string(bytes.TrimSpace(stdout)); got != strconv.FormatInt(offset+size, 10) {
+		return perrs.Errorf("size of %s is %s after appending the chunk, %d is expected", u.part, got, offset+size)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyTransfer counts the chunks transferred, and fails the transfers after
+// the first ok ones
+type flakyTransfer struct {
+	ctxt.Executor
+	ok     int
+	chunks int
+}
+
+func (e *flakyTransfer) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	if e.ok == 0 {
+		return errors.New("connection reset")
+	}
+	e.ok--
+	if strings.Contains(dst, partialSuffix) {
+		e.chunks++
+	}
+	return e.Executor.Transfer(ctx, src, dst, download, limit, compress)
+}
+
+// noChecksum fails the commands probing sha256sum as the hosts without it do
+type noChecksum struct {
+	ctxt.Executor
+	transfers int
+}
+
+func (e *noChecksum) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	if strings.Contains(cmd, "sha256sum") {
+		return nil, nil, errors.New("sha256sum: command not found")
+	}
+	return e.Executor.Execute(ctx, cmd, sudo, timeout...)
+}
+
+func (e *noChecksum) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	e.transfers++
+	return e.Executor.Transfer(ctx, src, dst, download, limit, compress)
+}
+
+func TestUpload(t *testing.T) {
+	ctx := context.Background()
+	current, err := user.Current()
+	require.NoError(t, err)
+	local, err := New(SSHTypeNone, false, SSHConfig{Host: "127.0.0.1", User: current.Username})
+	require.NoError(t, err)
+
+	defer func(size int64) { uploadChunkSize = size }(uploadChunkSize)
+	uploadChunkSize = 4
+
+	src := filepath.Join(t.TempDir(), "src")
+	data := []byte("0123456789abcdef01")
+	require.NoError(t, os.WriteFile(src, data, 0644))
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	// the upload fails after 2 chunks and leaves the partial file
+	e := &flakyTransfer{Executor: local, ok: 2}
+	err = Upload(ctx, e, src, dst, 0, false)
+	require.Error(t, err)
+	assert.Equal(t, 2, e.chunks)
+	part, err := os.ReadFile(dst + partialSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, data[:8], part)
+
+	// the upload is resumed from the third chunk
+	e = &flakyTransfer{Executor: local, ok: 100}
+	require.NoError(t, Upload(ctx, e, src, dst, 0, false))
+	assert.Equal(t, 3, e.chunks)
+	got, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.NoFileExists(t, dst+partialSuffix)
+
+	// the identical file is not uploaded again
+	e = &flakyTransfer{Executor: local}
+	require.NoError(t, Upload(ctx, e, src, dst, 0, false))
+
+	// the partial file of another file is not resumed
+	require.NoError(t, os.WriteFile(dst+partialSuffix, bytes.Repeat([]byte("x"), 9), 0644))
+	require.NoError(t, os.WriteFile(src, append(data, '!'), 0644))
+	e = &flakyTransfer{Executor: local, ok: 100}
+	require.NoError(t, Upload(ctx, e, src, dst, 0, false))
+	assert.Equal(t, 5, e.chunks)
+	got, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, append(data, '!'), got)
+
+	// the file is uploaded into the directory
+	dir := t.TempDir()
+	require.NoError(t, Upload(ctx, local, src, dir, 0, false))
+	got, err = os.ReadFile(filepath.Join(dir, "src"))
+	require.NoError(t, err)
+	assert.Equal(t, append(data, '!'), got)
+
+	// the file is uploaded at once to the host without sha256sum
+	dst = filepath.Join(t.TempDir(), "dst")
+	nc := &noChecksum{Executor: local}
+	require.NoError(t, Upload(ctx, nc, src, dst, 0, false))
+	assert.Equal(t, 1, nc.transfers)
+	got, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, append(data, '!'), got)
+	assert.NoFileExists(t, dst+partialSuffix)
+}
@@ -0,0 +1,557 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyOptions contains the options for applying a topology file to a cluster.
+type ApplyOptions struct {
+	Version string // target version of the cluster, empty to keep the current one
+}
+
+// ApplyChange is a single changed field found when comparing topologies.
+type ApplyChange struct {
+	Target string // instance ID or server_configs
+	Key    string // flattened key of the changed field
+	From   any
+	To     any
+}
+
+// ApplyPlan describes the operations needed to reconcile a deployed cluster
+// with the desired topology.
+type ApplyPlan struct {
+	CurrentVersion    string
+	TargetVersion     string
+	ComponentVersions map[string]string // component versions changed in the desired topology
+
+	ScaleOut *spec.Specification // instances defined in the desired topology but not deployed
+	ScaleIn  []string            // IDs of deployed instances not defined in the desired topology
+
+	Changes     []ApplyChange
+	ReloadNodes []string // instances whose own spec changed
+	ReloadRoles []string // roles whose server_configs changed
+
+	topo *spec.Specification // the deployed topology with changes of existing instances applied
+}
+
+// HasUpgrade returns true if the plan upgrades any component.
+func (p *ApplyPlan) HasUpgrade() bool {
+	return p.CurrentVersion != p.TargetVersion || len(p.ComponentVersions) > 0
+}
+
+// HasReload returns true if the plan changes configs of existing instances.
+func (p *ApplyPlan) HasReload() bool {
+	return len(p.ReloadNodes) > 0 || len(p.ReloadRoles) > 0
+}
+
+// HasScaleOut returns true if the plan adds any instance.
+func (p *ApplyPlan) HasScaleOut() bool {
+	return p.ScaleOut != nil && countInstances(p.ScaleOut) > 0
+}
+
+// Empty returns true if there is nothing to do.
+func (p *ApplyPlan) Empty() bool {
+	return !p.HasUpgrade() && !p.HasReload() && !p.HasScaleOut() && len(p.ScaleIn) == 0
+}
+
+// Apply reconciles the cluster with the topology file: instances missing from the
+// cluster are scaled out, instances missing from the file are scaled in, changed
+// configs are reloaded and version bumps are upgraded.
+func (m *Manager) Apply(
+	name string,
+	topoFile string,
+	opt ApplyOptions,
+	deployOpt DeployOptions,
+	afterDeploy func(b *task.Builder, newPart spec.Topology, gOpt operator.Options),
+	final func(b *task.Builder, name string, meta spec.Metadata, gOpt operator.Options),
+	skipConfirm bool,
+	gOpt operator.Options,
+) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	// check locked
+	if err := m.specManager.ScaleOutLockedErr(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	clusterMeta, ok := metadata.(*spec.ClusterMeta)
+	if !ok {
+		return perrs.Errorf("apply is not supported for %s clusters", m.sysName)
+	}
+
+	desired := &spec.Specification{}
+	if err := spec.ParseTopologyYaml(topoFile, desired); err != nil {
+		return err
+	}
+	spec.ExpandRelativeDir(desired)
+
+	targetVersion := clusterMeta.Version
+	if opt.Version != "" {
+		if targetVersion, err = utils.FmtVer(opt.Version); err != nil {
+			return err
+		}
+		if err := versionCompare(clusterMeta.Version, targetVersion); err != nil {
+			return err
+		}
+	}
+
+	plan, err := PlanApply(clusterMeta.Topology, desired, clusterMeta.Version, targetVersion)
+	if err != nil {
+		return err
+	}
+
+	if plan.Empty() {
+		m.logger.Infof("Cluster `%s` is already up to date with %s", name, topoFile)
+		return nil
+	}
+
+	if err := m.printApplyPlan(name, plan); err != nil {
+		return err
+	}
+	if gOpt.DryRun {
+		return nil
+	}
+	if !skipConfirm {
+		if err := tui.PromptForConfirmOrAbortError("Do you want to apply the plan? [y/N]: "); err != nil {
+			return err
+		}
+	}
+
+	// Persist the changes of existing instances first, so that the following
+	// upgrade or reload renders the new configs.
+	if plan.HasReload() {
+		m.logger.Infof("Applying config changes...")
+		clusterMeta.Topology = plan.topo
+		if err := m.specManager.SaveMeta(name, clusterMeta); err != nil {
+			return perrs.Annotate(err, "failed to save meta")
+		}
+	}
+
+	switch {
+	case plan.HasUpgrade():
+		// upgrade refreshes configs and restarts every instance, no need to reload
+		if err := m.Upgrade(name, plan.TargetVersion, plan.ComponentVersions, gOpt, true, false, false, 0); err != nil {
+			return err
+		}
+	case plan.HasReload():
+		rOpt := gOpt
+		rOpt.Nodes = plan.ReloadNodes
+		rOpt.Roles = plan.ReloadRoles
+		if len(rOpt.Nodes) > 0 && len(rOpt.Roles) > 0 {
+			// a role filter would skip the changed nodes of other roles
+			rOpt.Nodes, rOpt.Roles = nil, nil
+		}
+		if err := m.Reload(name, rOpt, false, true); err != nil {
+			return err
+		}
+	}
+
+	if plan.HasScaleOut() {
+		f, err := os.CreateTemp("", fmt.Sprintf("tiup-apply-%s-*.yaml", name))
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+		defer os.Remove(f.Name())
+
+		data, err := yaml.Marshal(plan.ScaleOut)
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return perrs.AddStack(err)
+		}
+		if err := f.Close(); err != nil {
+			return perrs.AddStack(err)
+		}
+
+		if err := m.ScaleOut(name, f.Name(), afterDeploy, final, deployOpt, true, gOpt); err != nil {
+			return err
+		}
+	}
+
+	if len(plan.ScaleIn) > 0 {
+		sOpt := gOpt
+		sOpt.Nodes = plan.ScaleIn
+		if err := m.ScaleIn(name, true, sOpt, m.scaleInNodes(name, sOpt)); err != nil {
+			return err
+		}
+	}
+
+	m.logger.Infof("Applied topology to cluster `%s` successfully", name)
+	return nil
+}
+
+// scaleInNodes returns the function to build the scale-in tasks of the nodes
+// of options in a TiDB cluster, the async offline nodes are kept in the
+// topology unless the scale-in is forced.
+func (m *Manager) scaleInNodes(name string, gOpt operator.Options) func(b *task.Builder, metadata spec.Metadata, tlsCfg *tls.Config) {
+	return func(b *task.Builder, imetadata spec.Metadata, tlsCfg *tls.Config) {
+		metadata := imetadata.(*spec.ClusterMeta)
+
+		nodes := gOpt.Nodes
+		if !gOpt.Force {
+			nodes = operator.AsyncNodes(metadata.Topology, nodes, false)
+		}
+
+		b.ClusterOperate(metadata.Topology, operator.ScaleInOperation, gOpt, tlsCfg).
+			UpdateMeta(name, metadata, nodes).
+			UpdateTopology(name, m.specManager.Path(name), metadata, nodes)
+	}
+}
+
+// applyAction is an operation of the apply plan
+type applyAction struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail"`
+}
+
+func (m *Manager) printApplyPlan(name string, plan *ApplyPlan) error {
+	actions := []applyAction{}
+	if plan.CurrentVersion != plan.TargetVersion {
+		actions = append(actions, applyAction{"upgrade", "cluster", fmt.Sprintf("%s -> %s", plan.CurrentVersion, plan.TargetVersion)})
+	}
+	comps := make([]string, 0, len(plan.ComponentVersions))
+	for comp := range plan.ComponentVersions {
+		comps = append(comps, comp)
+	}
+	sort.Strings(comps)
+	for _, comp := range comps {
+		actions = append(actions, applyAction{"upgrade", comp, fmt.Sprintf("-> %s", plan.ComponentVersions[comp])})
+	}
+	for _, c := range plan.Changes {
+		actions = append(actions, applyAction{"config", c.Target, fmt.Sprintf("%s: %v -> %v", c.Key, c.From, c.To)})
+	}
+	if plan.ScaleOut != nil {
+		plan.ScaleOut.IterInstance(func(inst spec.Instance) {
+			actions = append(actions, applyAction{"scale-out", inst.ID(), inst.ComponentName()})
+		})
+	}
+	for _, id := range plan.ScaleIn {
+		actions = append(actions, applyAction{"scale-in", id, ""})
+	}
+
+	if m.logger.GetDisplayMode().Structured() {
+		return m.logger.Output(struct {
+			ClusterName    string        `json:"cluster_name"`
+			ClusterVersion string        `json:"cluster_version"`
+			Actions        []applyAction `json:"actions"`
+		}{name, plan.CurrentVersion, actions})
+	}
+
+	cyan := color.New(color.FgCyan, color.Bold)
+	fmt.Printf("Cluster name:    %s\n", cyan.Sprint(name))
+	fmt.Printf("Cluster version: %s\n", cyan.Sprint(plan.CurrentVersion))
+	planTable := [][]string{{"Action", "Target", "Detail"}}
+	for _, a := range actions {
+		planTable = append(planTable, []string{a.Action, a.Target, a.Detail})
+	}
+	tui.PrintTable(planTable, true)
+	return nil
+}
+
+// PlanApply compares the deployed topology with the desired one and returns
+// the operations needed to reconcile them. Neither of the inputs is modified.
+func PlanApply(current, desired *spec.Specification, currentVersion, targetVersion string) (*ApplyPlan, error) {
+	if err := checkApplyGlobal(current, desired); err != nil {
+		return nil, err
+	}
+
+	plan := &ApplyPlan{
+		CurrentVersion:    currentVersion,
+		TargetVersion:     targetVersion,
+		ComponentVersions: map[string]string{},
+		ScaleOut:          &spec.Specification{},
+	}
+
+	// work on a copy so that the deployed topology is kept untouched until
+	// the plan is confirmed
+	topo := &spec.Specification{}
+	data, err := yaml.Marshal(current)
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	if err := yaml.Unmarshal(data, topo); err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	plan.topo = topo
+
+	curComps := componentVersionMap(current.ComponentVersions)
+	for comp, ver := range componentVersionMap(desired.ComponentVersions) {
+		if ver != "" && ver != curComps[comp] {
+			plan.ComponentVersions[comp] = ver
+		}
+	}
+
+	// server_configs
+	changes, err := diffFlattened(current.ServerConfigs, desired.ServerConfigs)
+	if err != nil {
+		return nil, err
+	}
+	roles := set.NewStringSet()
+	for _, c := range changes {
+		c.Target = "server_configs"
+		plan.Changes = append(plan.Changes, c)
+		roles.Insert(serverConfigsRole(strings.SplitN(c.Key, ".", 2)[0]))
+	}
+	if len(changes) > 0 {
+		topo.ServerConfigs = desired.ServerConfigs
+	}
+	plan.ReloadRoles = roles.Slice()
+	sort.Strings(plan.ReloadRoles)
+
+	desiredIDs := set.NewStringSet()
+	var iterErr error
+	iterInstanceSpecs(desired, func(field string, id string, newSpec reflect.Value) {
+		if iterErr != nil {
+			return
+		}
+		desiredIDs.Insert(id)
+
+		oldSpec, idx, found := findInstanceSpec(topo, field, id)
+		if !found {
+			appendInstanceSpec(plan.ScaleOut, field, newSpec)
+			return
+		}
+
+		// fields maintained by TiUP itself are not expected in the topology file
+		newSpec = copyInstanceSpec(newSpec)
+		for _, f := range []string{"Arch", "OS", "Patched", "Imported"} {
+			dst := newSpec.Elem().FieldByName(f)
+			if dst.IsValid() && dst.IsZero() {
+				dst.Set(oldSpec.Elem().FieldByName(f))
+			}
+		}
+
+		if err := utils.ValidateSpecDiff(oldSpec.Interface(), newSpec.Interface()); err != nil {
+			iterErr = perrs.Annotatef(err, "instance %s can not be changed by apply", id)
+			return
+		}
+		changes, err := diffFlattened(oldSpec.Interface(), newSpec.Interface())
+		if err != nil {
+			iterErr = err
+			return
+		}
+		if len(changes) == 0 {
+			return
+		}
+		for _, c := range changes {
+			c.Target = id
+			plan.Changes = append(plan.Changes, c)
+		}
+		plan.ReloadNodes = append(plan.ReloadNodes, id)
+		setInstanceSpec(topo, field, idx, newSpec)
+	})
+	if iterErr != nil {
+		return nil, iterErr
+	}
+
+	current.IterInstance(func(inst spec.Instance) {
+		if !desiredIDs.Exist(inst.ID()) {
+			plan.ScaleIn = append(plan.ScaleIn, inst.ID())
+		}
+	})
+
+	return plan, nil
+}
+
+// checkApplyGlobal makes sure the options shared by all instances are not
+// changed, as they can not be applied without redeploying the cluster.
+func checkApplyGlobal(current, desired *spec.Specification) error {
+	for _, pair := range [][2]any{
+		{current.GlobalOptions, desired.GlobalOptions},
+		{current.MonitoredOptions, desired.MonitoredOptions},
+	} {
+		changes, err := diffFlattened(pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			keys := make([]string, 0, len(changes))
+			for _, c := range changes {
+				keys = append(keys, c.Key)
+			}
+			return perrs.Errorf("global or monitored options changed (%s), please use `edit-config` to modify them",
+				strings.Join(keys, ", "))
+		}
+	}
+	return nil
+}
+
+// diffFlattened compares the YAML representations of two values and returns the changed keys
+func diffFlattened(from, to any) ([]ApplyChange, error) {
+	fromMap, err := flattenYAML(from)
+	if err != nil {
+		return nil, err
+	}
+	toMap, err := flattenYAML(to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := set.NewStringSet()
+	for k := range fromMap {
+		keys.Insert(k)
+	}
+	for k := range toMap {
+		keys.Insert(k)
+	}
+
+	var changes []ApplyChange
+	for _, k := range keys.Slice() {
+		f, t := fromMap[k], toMap[k]
+		if reflect.DeepEqual(f, t) {
+			continue
+		}
+		changes = append(changes, ApplyChange{Key: k, From: f, To: t})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+func flattenYAML(v any) (map[string]any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	m := map[string]any{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, perrs.AddStack(err)
+	}
+	return spec.FlattenMap(m), nil
+}
+
+// serverConfigsRole returns the role affected by a section of server_configs
+func serverConfigsRole(section string) string {
+	switch section {
+	case "tiflash-learner":
+		return spec.ComponentTiFlash
+	case "kvcdc":
+		return spec.ComponentTiKVCDC
+	case "tidb_dashboard":
+		return spec.ComponentDashboard
+	default:
+		return section
+	}
+}
+
+func componentVersionMap(v spec.ComponentVersions) map[string]string {
+	return map[string]string{
+		spec.ComponentTiDB:         v.TiDB,
+		spec.ComponentTiKV:         v.TiKV,
+		spec.ComponentTiFlash:      v.TiFlash,
+		spec.ComponentPD:           v.PD,
+		spec.ComponentTSO:          v.TSO,
+		spec.ComponentScheduling:   v.Scheduling,
+		spec.ComponentDashboard:    v.Dashboard,
+		spec.ComponentPump:         v.Pump,
+		spec.ComponentDrainer:      v.Drainer,
+		spec.ComponentCDC:          v.CDC,
+		spec.ComponentTiKVCDC:      v.TiKVCDC,
+		spec.ComponentTiProxy:      v.TiProxy,
+		spec.ComponentPrometheus:   v.Prometheus,
+		spec.ComponentGrafana:      v.Grafana,
+		spec.ComponentAlertmanager: v.AlertManager,
+	}
+}
+
+// iterInstanceSpecs calls fn with every instance spec of the topology, the field
+// is the name of the Specification field holding the spec.
+func iterInstanceSpecs(topo *spec.Specification, fn func(field, id string, s reflect.Value)) {
+	v := reflect.ValueOf(topo).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+		for j := 0; j < field.Len(); j++ {
+			s := field.Index(j)
+			if s.IsNil() {
+				continue
+			}
+			fn(v.Type().Field(i).Name, instanceSpecID(s), s)
+		}
+	}
+}
+
+func instanceSpecID(s reflect.Value) string {
+	is := s.Interface().(spec.InstanceSpec)
+	return utils.JoinHostPort(s.Elem().FieldByName("Host").String(), is.GetMainPort())
+}
+
+func findInstanceSpec(topo *spec.Specification, field, id string) (reflect.Value, int, bool) {
+	slice := reflect.ValueOf(topo).Elem().FieldByName(field)
+	for i := 0; i < slice.Len(); i++ {
+		if s := slice.Index(i); !s.IsNil() && instanceSpecID(s) == id {
+			return s, i, true
+		}
+	}
+	return reflect.Value{}, -1, false
+}
+
+func appendInstanceSpec(topo *spec.Specification, field string, s reflect.Value) {
+	slice := reflect.ValueOf(topo).Elem().FieldByName(field)
+	slice.Set(reflect.Append(slice, s))
+}
+
+func setInstanceSpec(topo *spec.Specification, field string, idx int, s reflect.Value) {
+	reflect.ValueOf(topo).Elem().FieldByName(field).Index(idx).Set(s)
+}
+
+func copyInstanceSpec(s reflect.Value) reflect.Value {
+	c := reflect.New(s.Elem().Type())
+	c.Elem().Set(s.Elem())
+	return c
+}
+
+func countInstances(topo *spec.Specification) int {
+	count := 0
+	iterInstanceSpecs(topo, func(string, string, reflect.Value) {
+		count++
+	})
+	return count
+}
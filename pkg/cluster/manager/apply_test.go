@@ -0,0 +1,161 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func parseApplyTopo(t *testing.T, content string) *spec.Specification {
+	topo := &spec.Specification{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), topo))
+	return topo
+}
+
+func TestPlanApply(t *testing.T) {
+	current := parseApplyTopo(t, `
+server_configs:
+  tikv:
+    storage.reserve-space: 1GB
+tidb_servers:
+  - host: 172.16.5.1
+    arch: amd64
+pd_servers:
+  - host: 172.16.5.1
+    arch: amd64
+tikv_servers:
+  - host: 172.16.5.2
+    arch: amd64
+  - host: 172.16.5.3
+    arch: amd64
+`)
+
+	// nothing changed, the fields maintained by TiUP are ignored
+	desired := parseApplyTopo(t, `
+server_configs:
+  tikv:
+    storage.reserve-space: 1GB
+tidb_servers:
+  - host: 172.16.5.1
+pd_servers:
+  - host: 172.16.5.1
+tikv_servers:
+  - host: 172.16.5.2
+  - host: 172.16.5.3
+`)
+	plan, err := PlanApply(current, desired, "v8.1.0", "v8.1.0")
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+
+	desired = parseApplyTopo(t, `
+component_versions:
+  tikv: v8.1.1
+server_configs:
+  tikv:
+    storage.reserve-space: 2GB
+tidb_servers:
+  - host: 172.16.5.1
+    config:
+      log.level: warn
+pd_servers:
+  - host: 172.16.5.1
+tikv_servers:
+  - host: 172.16.5.2
+  - host: 172.16.5.4
+`)
+	plan, err = PlanApply(current, desired, "v8.1.0", "v8.1.0")
+	require.NoError(t, err)
+	require.False(t, plan.Empty())
+	require.Equal(t, map[string]string{spec.ComponentTiKV: "v8.1.1"}, plan.ComponentVersions)
+	require.Equal(t, []string{"172.16.5.3:20160"}, plan.ScaleIn)
+	require.True(t, plan.HasScaleOut())
+	require.Len(t, plan.ScaleOut.TiKVServers, 1)
+	require.Equal(t, "172.16.5.4", plan.ScaleOut.TiKVServers[0].Host)
+	require.Equal(t, []string{"172.16.5.1:4000"}, plan.ReloadNodes)
+	require.Equal(t, []string{spec.ComponentTiKV}, plan.ReloadRoles)
+	require.Len(t, plan.Changes, 2)
+	require.Equal(t, "server_configs", plan.Changes[0].Target)
+	require.Equal(t, "tikv.storage.reserve-space", plan.Changes[0].Key)
+	require.Equal(t, "172.16.5.1:4000", plan.Changes[1].Target)
+	require.Equal(t, "config.log.level", plan.Changes[1].Key)
+
+	// the deployed topology is not modified, the planned one is
+	require.Nil(t, current.TiDBServers[0].Config)
+	require.Equal(t, "warn", plan.topo.TiDBServers[0].Config["log.level"])
+	require.Equal(t, "amd64", plan.topo.TiDBServers[0].Arch)
+
+	// immutable fields can not be changed
+	desired = parseApplyTopo(t, `
+tidb_servers:
+  - host: 172.16.5.1
+    status_port: 10081
+pd_servers:
+  - host: 172.16.5.1
+tikv_servers:
+  - host: 172.16.5.2
+  - host: 172.16.5.3
+`)
+	_, err = PlanApply(current, desired, "v8.1.0", "v8.1.0")
+	require.Error(t, err)
+
+	// global options can not be changed
+	desired = parseApplyTopo(t, `
+global:
+  user: tidb2
+tidb_servers:
+  - host: 172.16.5.1
+pd_servers:
+  - host: 172.16.5.1
+tikv_servers:
+  - host: 172.16.5.2
+  - host: 172.16.5.3
+`)
+	_, err = PlanApply(current, desired, "v8.1.0", "v8.1.0")
+	require.Error(t, err)
+}
+
+func TestPrintApplyPlanOutput(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewManager("tidb", nil, logprinter.NewLogger(""))
+	m.logger.SetDisplayMode(logprinter.DisplayModeJSON)
+	m.logger.SetStdout(buf)
+
+	plan := &ApplyPlan{
+		CurrentVersion:    "v8.1.0",
+		TargetVersion:     "v8.5.0",
+		ComponentVersions: map[string]string{"tikv": "v8.5.1"},
+		ScaleIn:           []string{"172.16.5.2:4000"},
+	}
+	require.NoError(t, m.printApplyPlan("test", plan))
+	var output struct {
+		ClusterName    string        `json:"cluster_name"`
+		ClusterVersion string        `json:"cluster_version"`
+		Actions        []applyAction `json:"actions"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+	require.Equal(t, "test", output.ClusterName)
+	require.Equal(t, "v8.1.0", output.ClusterVersion)
+	require.Equal(t, []applyAction{
+		{"upgrade", "cluster", "v8.1.0 -> v8.5.0"},
+		{"upgrade", "tikv", "-> v8.5.1"},
+		{"scale-in", "172.16.5.2:4000", ""},
+	}, output.Actions)
+}
@@ -44,6 +44,12 @@ func (m *Manager) EnableCluster(name string, gOpt operator.Options, isEnable boo
 		m.logger.Infof("Disabling cluster %s...", name)
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) {
 		return err
@@ -69,11 +75,12 @@ func (m *Manager) EnableCluster(name string, gOpt operator.Options, isEnable boo
 
 	t := b.Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -100,10 +107,19 @@ func (m *Manager) StartCluster(name string, gOpt operator.Options, restoreLeader
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) {
 		return err
 	}
+	if err := checkMaintenance(name, metadata, gOpt); err != nil {
+		return err
+	}
 
 	topo := metadata.GetTopology()
 	base := metadata.GetBaseMeta()
@@ -128,11 +144,12 @@ func (m *Manager) StartCluster(name string, gOpt operator.Options, restoreLeader
 
 	t := b.Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -157,6 +174,12 @@ func (m *Manager) StopCluster(
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) {
 		return err
@@ -193,11 +216,12 @@ func (m *Manager) StopCluster(
 		}).
 		Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -217,10 +241,19 @@ func (m *Manager) RestartCluster(name string, gOpt operator.Options, skipConfirm
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) {
 		return err
 	}
+	if err := checkMaintenance(name, metadata, gOpt); err != nil {
+		return err
+	}
 
 	topo := metadata.GetTopology()
 	base := metadata.GetBaseMeta()
@@ -243,6 +276,9 @@ func (m *Manager) RestartCluster(name string, gOpt operator.Options, skipConfirm
 			nodesToRestart = strings.Join(gOpt.Nodes, ",")
 			rolesToRestart = strings.Join(gOpt.Roles, ",")
 		}
+		if gOpt.RollingRestart {
+			availabilityMessage = "Instances will be restarted one by one, each of them is checked to be ready before the next one is restarted"
+		}
 
 		confirmationMessage := fmt.Sprintf("Will restart the cluster %s with nodes: %s roles: %s.\n%s\nDo you want to continue? [y/N]:",
 			color.HiYellowString(name),
@@ -261,15 +297,19 @@ func (m *Manager) RestartCluster(name string, gOpt operator.Options, skipConfirm
 	}
 	t := b.
 		Func("RestartCluster", func(ctx context.Context) error {
+			if gOpt.RollingRestart {
+				return operator.RollingRestart(ctx, topo, gOpt, tlsCfg, base.Version)
+			}
 			return operator.Restart(ctx, topo, gOpt, tlsCfg)
 		}).
 		Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -345,6 +385,12 @@ func (m *Manager) RestoreClusterMeta(clusterName, filePath string, skipConfirm b
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(clusterName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	fi, err := os.Stat(m.specManager.Path(clusterName))
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tiup/pkg/environment"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
+	"github.com/pingcap/tiup/pkg/proxy"
 	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
@@ -153,6 +154,7 @@ func buildScaleOutTask(
 				s.Password,
 				s.IdentityFile,
 				s.IdentityFilePassphrase,
+				s.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -161,6 +163,7 @@ func buildScaleOutTask(
 				p.Password,
 				p.IdentityFile,
 				p.IdentityFilePassphrase,
+				p.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				globalOptions.SSHType,
@@ -275,7 +278,7 @@ func buildScaleOutTask(
 	gOpt.IgnoreConfigCheck = true
 	refreshConfigTasks, hasImported := buildInitConfigTasks(m, name, mergedTopo, base, gOpt, nil)
 	// handle dir scheme changes
-	if hasImported {
+	if hasImported && !gOpt.DryRun {
 		if err := spec.HandleImportPathMigration(name); err != nil {
 			return task.NewBuilder(m.logger).Build(), err
 		}
@@ -335,9 +338,24 @@ func buildScaleOutTask(
 	if err != nil {
 		return nil, err
 	}
+	// the host keys of the new hosts are trusted on first use
+	builder.SSHKnownHosts(m.specManager.Path(name, "ssh", "known_hosts"), true)
+	// the new hosts are connected through their jump hosts as well
+	builder.SSHProxies(spec.SSHProxies(mergedTopo))
+	knownHosts := m.specManager.Path(name, "ssh", "known_hosts")
+	if err := proxy.MaybeRouteProxy(spec.SSHProxies(mergedTopo), sshProxyConfig(gOpt, p, knownHosts, true), m.logger); err != nil {
+		return nil, err
+	}
 
 	// stage2 just start and init config
 	if !opt.Stage2 {
+		if opt.NoSudo {
+			prepareCheckTasks, err := buildPrepareCheckTasks(m, name, newPart, opt, gOpt, s, p)
+			if err != nil {
+				return nil, err
+			}
+			builder.ParallelStep("+ Check the preparation of target hosts", false, prepareCheckTasks...)
+		}
 		builder.
 			ParallelStep("+ Download TiDB components", gOpt.Force, downloadCompTasks...).
 			ParallelStep("+ Initialize target host environments", gOpt.Force, envInitTasks...).
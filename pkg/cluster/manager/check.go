@@ -15,9 +15,7 @@ package manager
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -26,7 +24,6 @@ import (
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/api"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/cluster/task"
@@ -41,6 +38,7 @@ type CheckOptions struct {
 	User         string // username to login to the SSH server
 	IdentityFile string // path to the private key file
 	UsePassword  bool   // use password instead of identity file for ssh connection
+	UseSSHAgent  bool   // use the keys in the ssh-agent instead of identity file for ssh connection
 	Opr          *operator.CheckOptions
 	ApplyFix     bool   // try to apply fixes of failed checks
 	ExistCluster bool   // check an exist cluster
@@ -50,11 +48,12 @@ type CheckOptions struct {
 // CheckCluster check cluster before deploying or upgrading
 func (m *Manager) CheckCluster(clusterOrTopoName, scaleoutTopo string, opt CheckOptions, gOpt operator.Options) error {
 	var topo spec.Specification
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	var currTopo *spec.Specification
 
 	if opt.ExistCluster { // check for existing cluster
@@ -113,15 +112,15 @@ func (m *Manager) CheckCluster(clusterOrTopoName, scaleoutTopo string, opt Check
 		sshConnProps  *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 		sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 	)
-	if gOpt.SSHType != executor.SSHTypeNone {
+	if gOpt.SSHType.UsesSSH() {
 		var err error
-		if sshConnProps, err = tui.ReadIdentityFileOrPassword(opt.IdentityFile, opt.UsePassword); err != nil {
+		// the key of the cluster is used to check an exist cluster
+		useAgent := (opt.UseSSHAgent || topo.GlobalOptions.SSHAgent) && !opt.ExistCluster
+		if sshConnProps, err = readSSHConnProps(opt.IdentityFile, opt.UsePassword, useAgent); err != nil {
 			return err
 		}
-		if len(gOpt.SSHProxyHost) != 0 {
-			if sshProxyProps, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
-				return err
-			}
+		if sshProxyProps, err = readSSHProxyProps(&topo, gOpt); err != nil {
+			return err
 		}
 	}
 
@@ -181,6 +180,55 @@ func checkSystemInfo(
 	opt *CheckOptions,
 	fullTopo *spec.Specification,
 ) error {
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+	checkResults, applyFixTasks, err := runSystemChecks(ctx, s, p, topo, gOpt, opt, fullTopo)
+	if err != nil {
+		return err
+	}
+
+	if logger.GetDisplayMode().Structured() {
+		if err := logger.Output(struct {
+			Result []HostCheckResult `json:"result"`
+		}{Result: checkResults}); err != nil {
+			return err
+		}
+	} else {
+		checkResultTable := [][]string{
+			// Header
+			{"Node", "Check", "Result", "Message"},
+		}
+		checkResultTable = append(checkResultTable, formatHostCheckResults(checkResults)...)
+		// print check results *before* trying to applying checks
+		// FIXME: add fix result to output, and display the table after fixing
+		tui.PrintTable(checkResultTable, true)
+	}
+
+	if opt.ApplyFix {
+		tc := task.NewBuilder(logger).
+			ParallelStep("+ Try to apply changes to fix failed checks", false, applyFixTasks...).
+			Build()
+		if err := tc.Execute(ctx); err != nil {
+			if errorx.Cast(err) != nil {
+				// FIXME: Map possible task errors and give suggestions.
+				return err
+			}
+			return perrs.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// runSystemChecks runs the checks on the deploy servers and returns the results
+// with the tasks to fix the failed checks
+func runSystemChecks(
+	ctx context.Context,
+	s, p *tui.SSHConnectionProps,
+	topo *spec.Specification,
+	gOpt *operator.Options,
+	opt *CheckOptions,
+	fullTopo *spec.Specification,
+) ([]HostCheckResult, []*task.StepDisplay, error) {
 	var (
 		collectTasks       []*task.StepDisplay
 		checkTimeZoneTasks []*task.StepDisplay
@@ -191,10 +239,8 @@ func checkSystemInfo(
 	)
 	task.CheckToolsPathDir = opt.TempDir
 	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
-	insightVer := ""
 
-	uniqueHosts := map[string]int{}             // host -> ssh-port
-	uniqueArchList := make(map[string]struct{}) // map["os-arch"]{}
+	uniqueHosts := map[string]int{} // host -> ssh-port
 	insightNodes := []spec.Instance{}
 
 	roleFilter := set.NewStringSet(gOpt.Roles...)
@@ -215,20 +261,6 @@ func checkSystemInfo(
 		}
 
 		for _, inst := range instances {
-			archKey := fmt.Sprintf("%s-%s", inst.OS(), inst.Arch())
-			if _, found := uniqueArchList[archKey]; !found {
-				uniqueArchList[archKey] = struct{}{}
-				t0 := task.NewBuilder(logger).
-					Download(
-						spec.ComponentCheckCollector,
-						inst.OS(),
-						inst.Arch(),
-						insightVer,
-					).
-					BuildAsStep(fmt.Sprintf("  - Downloading check tools for %s/%s", inst.OS(), inst.Arch()))
-				downloadTasks = append(downloadTasks, t0)
-			}
-
 			t1 := task.NewBuilder(logger)
 			// checks that applies to each instance
 			if opt.ExistCluster {
@@ -309,13 +341,18 @@ func checkSystemInfo(
 
 	existPD := (&spec.PDComponent{Topology: fullTopo}).Instances()
 	if len(existPD) < 1 {
-		return fmt.Errorf("cannot find PD in exist cluster")
+		return nil, nil, fmt.Errorf("cannot find PD in exist cluster")
 	}
 	if _, found := uniqueHosts[existPD[0].GetManageHost()]; !found {
 		insightNodes = append(insightNodes, existPD[0])
 	}
 
+	tools := newInsightTools(logger, task.CheckToolsPathDir, opt.User, systemdMode != spec.UserMode)
 	for _, inst := range insightNodes {
+		if t0 := tools.download(inst); t0 != nil {
+			downloadTasks = append(downloadTasks, t0)
+		}
+
 		// build system info collecting tasks
 		t2 := task.NewBuilder(logger).
 			RootSSH(
@@ -325,6 +362,7 @@ func checkSystemInfo(
 				s.Password,
 				s.IdentityFile,
 				s.IdentityFilePassphrase,
+				s.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -333,29 +371,15 @@ func checkSystemInfo(
 				p.Password,
 				p.IdentityFile,
 				p.IdentityFilePassphrase,
+				p.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				topo.GlobalOptions.SSHType,
 				opt.User != "root" && systemdMode != spec.UserMode,
-			).
-			Mkdir(opt.User, inst.GetManageHost(), systemdMode != spec.UserMode, filepath.Join(task.CheckToolsPathDir, "bin")).
-			CopyComponent(
-				spec.ComponentCheckCollector,
-				inst.OS(),
-				inst.Arch(),
-				insightVer,
-				"", // use default srcPath
-				inst.GetManageHost(),
-				task.CheckToolsPathDir,
-			).
-			Shell(
-				inst.GetManageHost(),
-				filepath.Join(task.CheckToolsPathDir, "bin", "insight"),
-				"",
-				false,
-			).
-			BuildAsStep("  - Getting system info of " + utils.JoinHostPort(inst.GetManageHost(), inst.GetSSHPort()))
-		collectTasks = append(collectTasks, t2)
+			)
+		t2 = tools.install(t2, inst).
+			Shell(inst.GetManageHost(), insightBinary(task.CheckToolsPathDir), "", false)
+		collectTasks = append(collectTasks, t2.BuildAsStep("  - Getting system info of "+utils.JoinHostPort(inst.GetManageHost(), inst.GetSSHPort())))
 
 		t3 := task.NewBuilder(logger).
 			RootSSH(
@@ -365,6 +389,7 @@ func checkSystemInfo(
 				s.Password,
 				s.IdentityFile,
 				s.IdentityFilePassphrase,
+				s.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -373,14 +398,14 @@ func checkSystemInfo(
 				p.Password,
 				p.IdentityFile,
 				p.IdentityFilePassphrase,
+				p.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				topo.GlobalOptions.SSHType,
 				opt.User != "root" && systemdMode != spec.UserMode,
-			).
-			Rmdir(inst.GetManageHost(), task.CheckToolsPathDir).
-			BuildAsStep("  - Cleanup check files on " + utils.JoinHostPort(inst.GetManageHost(), inst.GetSSHPort()))
-		cleanTasks = append(cleanTasks, t3)
+			)
+		cleanTasks = append(cleanTasks, tools.clean(t3, inst.GetManageHost()).
+			BuildAsStep("  - Cleanup check files on "+utils.JoinHostPort(inst.GetManageHost(), inst.GetSSHPort())))
 	}
 
 	for host := range uniqueHosts {
@@ -484,6 +509,7 @@ func checkSystemInfo(
 	}
 
 	t := task.NewBuilder(logger).
+		SSHProxies(spec.SSHProxies(fullTopo)).
 		ParallelStep("+ Download necessary tools", false, downloadTasks...).
 		ParallelStep("+ Collect basic system information", false, collectTasks...).
 		ParallelStep("+ Check time zone", false, checkTimeZoneTasks...).
@@ -494,15 +520,11 @@ func checkSystemInfo(
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
-			return err
+			return nil, nil, err
 		}
-		return perrs.Trace(err)
+		return nil, nil, perrs.Trace(err)
 	}
 
-	checkResultTable := [][]string{
-		// Header
-		{"Node", "Check", "Result", "Message"},
-	}
 	checkResults := make([]HostCheckResult, 0)
 	for host := range uniqueHosts {
 		tf := task.NewBuilder(logger).
@@ -513,6 +535,7 @@ func checkSystemInfo(
 				s.Password,
 				s.IdentityFile,
 				s.IdentityFilePassphrase,
+				s.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -521,6 +544,7 @@ func checkSystemInfo(
 				p.Password,
 				p.IdentityFile,
 				p.IdentityFilePassphrase,
+				p.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				topo.GlobalOptions.SSHType,
@@ -534,48 +558,7 @@ func checkSystemInfo(
 		applyFixTasks = append(applyFixTasks, tf.BuildAsStep(fmt.Sprintf("  - Applying changes on %s", host)))
 	}
 
-	checkResults = deduplicateCheckResult(checkResults)
-
-	if gOpt.DisplayMode == "json" {
-		checkResultStruct := make([]HostCheckResult, 0)
-
-		for _, r := range checkResults {
-			checkResultStruct = append(checkResultStruct, HostCheckResult{
-				r.Node,
-				r.Name,
-				r.Status,
-				r.Message,
-			})
-		}
-		data, err := json.Marshal(struct {
-			Result []HostCheckResult `json:"result"`
-		}{Result: checkResultStruct})
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-	} else {
-		resLines := formatHostCheckResults(checkResults)
-		checkResultTable = append(checkResultTable, resLines...)
-		// print check results *before* trying to applying checks
-		// FIXME: add fix result to output, and display the table after fixing
-		tui.PrintTable(checkResultTable, true)
-	}
-
-	if opt.ApplyFix {
-		tc := task.NewBuilder(logger).
-			ParallelStep("+ Try to apply changes to fix failed checks", false, applyFixTasks...).
-			Build()
-		if err := tc.Execute(ctx); err != nil {
-			if errorx.Cast(err) != nil {
-				// FIXME: Map possible task errors and give suggestions.
-				return err
-			}
-			return perrs.Trace(err)
-		}
-	}
-
-	return nil
+	return deduplicateCheckResult(checkResults), applyFixTasks, nil
 }
 
 // handleCheckResults parses the result of checks
@@ -648,6 +631,13 @@ func formatHostCheckResults(results []HostCheckResult) [][]string {
 func fixFailedChecks(host string, res *operator.CheckResult, t *task.Builder, systemdMode string) (string, error) {
 	msg := ""
 	sudo := systemdMode != string(spec.UserMode)
+	// the deploy user may have no sudo in user mode, the fixes needing root are
+	// reported rather than executed
+	if !sudo {
+		if cmd := rootFixCommand(res); cmd != "" {
+			return fmt.Sprintf("%s, root is needed to fix it, please run '%s' on the host as root", res.Error(), color.HiBlueString(cmd)), nil
+		}
+	}
 	switch res.Name {
 	case operator.CheckNameSysService:
 		if strings.Contains(res.Msg, "not found") {
@@ -704,6 +694,36 @@ func fixFailedChecks(host string, res *operator.CheckResult, t *task.Builder, sy
 	return msg, nil
 }
 
+// rootFixCommand returns the command to fix the failed check as root, or empty
+// if it could not be fixed automatically
+func rootFixCommand(res *operator.CheckResult) string {
+	fields := strings.Fields(res.Msg)
+	switch res.Name {
+	case operator.CheckNameSysService:
+		if strings.Contains(res.Msg, "not found") || len(fields) < 2 {
+			return ""
+		}
+		return fmt.Sprintf("systemctl %s %s", fields[0], fields[1])
+	case operator.CheckNameSysctl:
+		if len(fields) < 3 {
+			return ""
+		}
+		return fmt.Sprintf("echo '%[1]s=%[2]s' >> /etc/sysctl.d/99-sysctl.conf && sysctl -w %[1]s=%[2]s", fields[0], fields[2])
+	case operator.CheckNameLimits:
+		if len(fields) < 4 {
+			return ""
+		}
+		return fmt.Sprintf("echo '%s' >> /etc/security/limits.conf", strings.Join(fields, "    "))
+	case operator.CheckNameSELinux:
+		return "sed -i 's/^[[:blank:]]*SELINUX=enforcing/SELINUX=disabled/g' /etc/selinux/config && setenforce 0"
+	case operator.CheckNameTHP:
+		return "echo never > /sys/kernel/mm/transparent_hugepage/enabled"
+	case operator.CheckNameSwap:
+		return "swapoff -a"
+	}
+	return ""
+}
+
 // checkRegionsInfo checks peer status from PD
 func (m *Manager) checkRegionsInfo(clusterName string, topo *spec.Specification, gOpt *operator.Options) error {
 	m.logger.Infof("Checking region status of the cluster %s...", clusterName)
@@ -43,6 +43,12 @@ func (m *Manager) CleanCluster(name string, gOpt operator.Options, cleanOpt oper
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil {
 		return err
@@ -90,11 +96,12 @@ func (m *Manager) CleanCluster(name string, gOpt operator.Options, cleanOpt oper
 		}).
 		Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
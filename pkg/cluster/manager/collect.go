@@ -0,0 +1,493 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// CollectOptions contains the options for collecting the diagnostic bundle of
+// a cluster.
+type CollectOptions struct {
+	Since    time.Duration // collect the logs in the duration, the whole files for the formats other than the unified one
+	Output   string        // the path of the bundle, generated from the cluster name if empty
+	Limit    int           // rate limit in Kbit/s
+	Compress bool          // enable compress
+}
+
+// the kinds of the files in the diagnostic bundle
+const (
+	collectLog     = "log"
+	collectConfig  = "config"
+	collectScript  = "script"
+	collectInsight = "insight"
+	collectDmesg   = "dmesg"
+	collectMeta    = "meta"
+	collectAudit   = "audit"
+)
+
+// collectToolsDir is where the insight collector is copied to on the hosts
+var collectToolsDir = filepath.Join(task.CheckToolsPathDir, "collect")
+
+// collectedFile is a file in the diagnostic bundle
+type collectedFile struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Host     string `json:"host,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Trimmed  bool   `json:"trimmed,omitempty"` // only the lines since the time of the bundle are collected
+	Size     int64  `json:"size"`
+}
+
+// collectIndex is the index of the files in the diagnostic bundle, it is saved
+// as index.json in the bundle
+type collectIndex struct {
+	ClusterName    string          `json:"cluster_name"`
+	ClusterVersion string          `json:"cluster_version"`
+	Time           time.Time       `json:"time"`
+	Since          time.Time       `json:"since"`
+	Files          []collectedFile `json:"files"`
+	Errors         []string        `json:"errors"`
+}
+
+// bundleCollector collects the files into a directory, the files failed to be
+// collected are recorded in the index instead of failing the collection
+type bundleCollector struct {
+	dir         string
+	limit       int
+	compress    bool
+	systemdMode spec.SystemdMode
+	insight     string // the path of the insight collector on the hosts, empty if it is not copied
+
+	mu    sync.Mutex
+	index collectIndex
+}
+
+// Collect gathers the log files, configs and run scripts of the instances, the
+// system information of the hosts, the meta and the audit logs of the cluster
+// into a tar.gz bundle with an index, the secrets in the files are redacted.
+func (m *Manager) Collect(name string, opt CollectOptions, gOpt operator.Options) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	roleFilter := set.NewStringSet(gOpt.Roles...)
+	nodeFilter := set.NewStringSet(gOpt.Nodes...)
+	hosts := make(map[string][]spec.Instance)
+	var hostList []string
+	topo.IterInstance(func(inst spec.Instance) {
+		if len(roleFilter) > 0 && !roleFilter.Exist(inst.Role()) {
+			return
+		}
+		if len(nodeFilter) > 0 && !nodeFilter.Exist(inst.ID()) {
+			return
+		}
+		host := inst.GetManageHost()
+		if _, found := hosts[host]; !found {
+			hostList = append(hostList, host)
+		}
+		hosts[host] = append(hosts[host], inst)
+	})
+	if len(hosts) == 0 {
+		return perrs.Errorf("no instance of cluster '%s' matches the role and node filters", name)
+	}
+
+	now := time.Now()
+	output := opt.Output
+	if output == "" {
+		output = fmt.Sprintf("%s-collect-%s.tar.gz", name, now.Format("20060102150405"))
+	}
+	tmpDir, err := os.MkdirTemp("", "tiup-collect-")
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := &bundleCollector{
+		dir:         filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(output), ".tar.gz")),
+		limit:       opt.Limit,
+		compress:    opt.Compress,
+		systemdMode: topo.BaseTopo().GlobalOptions.SystemdMode,
+		index: collectIndex{
+			ClusterName:    name,
+			ClusterVersion: base.Version,
+			Time:           now,
+			Files:          []collectedFile{},
+			Errors:         []string{},
+		},
+	}
+	if opt.Since > 0 {
+		c.index.Since = now.Add(-opt.Since)
+	}
+
+	m.logger.Infof("Collecting the meta and audit logs of cluster %s...", name)
+	c.copyLocal(m.specManager.Path(name, "meta.yaml"), "meta.yaml", collectMeta)
+	c.collectAudit(spec.AuditDir(), name)
+
+	// the insight collector reports the system information of the hosts
+	b, err := m.sshTaskBuilder(name, topo, base.User, gOpt)
+	if err != nil {
+		return err
+	}
+	insts := make([]spec.Instance, 0, len(hostList))
+	for _, host := range hostList {
+		insts = append(insts, hosts[host][0])
+	}
+	tools := newInsightTools(m.logger, collectToolsDir, base.User, c.systemdMode != spec.UserMode)
+	downloadTasks, copyTasks, cleanTasks := tools.steps(insts)
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	t := b.Build()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+	// the bundle is still useful without the system information
+	t = task.NewBuilder(m.logger).
+		ParallelStep("+ Download the insight collector", false, downloadTasks...).
+		ParallelStep("+ Copy the insight collector to the hosts", false, copyTasks...).
+		Build()
+	if err := t.Execute(ctx); err != nil {
+		m.logger.Warnf("Failed to copy the insight collector to the hosts: %s", err)
+		c.addError("failed to copy the insight collector to the hosts: %s", err)
+	} else {
+		c.insight = insightBinary(collectToolsDir)
+	}
+
+	m.logger.Infof("Collecting the files on the hosts...")
+	var wg sync.WaitGroup
+	limiter := make(chan struct{}, max(gOpt.Concurrency, 1))
+	for _, host := range hostList {
+		wg.Add(1)
+		go func(host string, insts []spec.Instance) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+			c.collectHost(ctx, host, insts)
+		}(host, hosts[host])
+	}
+	wg.Wait()
+
+	if c.insight != "" {
+		t = task.NewBuilder(m.logger).
+			ParallelStep("+ Clean up the insight collector", true, cleanTasks...).
+			Build()
+		if err := t.Execute(ctx); err != nil {
+			m.logger.Warnf("Failed to clean up the insight collector in %s: %s", collectToolsDir, err)
+		}
+	}
+
+	if err := c.redact(); err != nil {
+		return err
+	}
+	if err := c.bundle(output); err != nil {
+		return err
+	}
+
+	if m.logger.GetDisplayMode().Structured() {
+		return m.logger.Output(map[string]any{
+			"bundle": output,
+			"files":  len(c.index.Files),
+			"errors": c.index.Errors,
+		})
+	}
+	for _, e := range c.index.Errors {
+		m.logger.Warnf("%s", e)
+	}
+	m.logger.Infof("Collected %d files into %s", len(c.index.Files), output)
+	return nil
+}
+
+func (c *bundleCollector) add(f collectedFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index.Files = append(c.index.Files, f)
+}
+
+func (c *bundleCollector) addError(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index.Errors = append(c.index.Errors, fmt.Sprintf(format, args...))
+}
+
+// copyLocal copies a file on the control machine into the bundle
+func (c *bundleCollector) copyLocal(src, path, kind string) {
+	dst := filepath.Join(c.dir, path)
+	if err := utils.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		c.addError("failed to copy %s: %s", src, err)
+		return
+	}
+	if err := utils.Copy(src, dst); err != nil {
+		c.addError("failed to copy %s: %s", src, err)
+		return
+	}
+	c.add(collectedFile{Path: path, Kind: kind, Source: src})
+}
+
+// collectAudit copies the audit logs of the commands on the cluster since the
+// time of the index
+func (c *bundleCollector) collectAudit(dir, name string) {
+	items, err := audit.GetAuditList(dir)
+	if err != nil {
+		c.addError("failed to list the audit logs: %s", err)
+		return
+	}
+	for _, item := range items {
+		if t, err := time.Parse(time.RFC3339, item.Time); err != nil || t.Before(c.index.Since) {
+			continue
+		}
+		if !slices.Contains(strings.Fields(item.Command), name) {
+			continue
+		}
+		c.copyLocal(filepath.Join(dir, item.ID), filepath.Join("audit", item.ID), collectAudit)
+		if utils.IsExist(audit.CommandsFile(dir, item.ID)) {
+			c.copyLocal(audit.CommandsFile(dir, item.ID), filepath.Join("audit", "commands", item.ID), collectAudit)
+		}
+	}
+}
+
+// collectHost collects the system information of the host and the files of
+// the instances on it
+func (c *bundleCollector) collectHost(ctx context.Context, host string, insts []spec.Instance) {
+	e, found := ctxt.GetInner(ctx).GetExecutor(host)
+	if !found {
+		c.addError("no executor for host %s", host)
+		return
+	}
+	nctx := checkpoint.NewContext(ctx)
+	hostDir := filepath.Join("hosts", host)
+
+	if c.insight != "" {
+		stdout, stderr, err := e.Execute(nctx, c.insight, false)
+		if err != nil {
+			c.addError("failed to run insight on %s: %s, %s", host, err, stderr)
+		} else {
+			c.write(stdout, collectedFile{Path: filepath.Join(hostDir, "insight.json"), Kind: collectInsight, Host: host})
+		}
+	}
+	stdout, stderr, err := e.Execute(nctx, "dmesg -T", c.systemdMode != spec.UserMode)
+	if err != nil {
+		c.addError("failed to run dmesg on %s: %s, %s", host, err, stderr)
+	} else {
+		c.write(stdout, collectedFile{Path: filepath.Join(hostDir, "dmesg.log"), Kind: collectDmesg, Host: host})
+	}
+
+	for _, inst := range insts {
+		instDir := filepath.Join("instances", fmt.Sprintf("%s-%s", inst.ComponentName(), strings.ReplaceAll(inst.ID(), ":", "-")))
+		for _, d := range []struct {
+			kind, remote, local string
+			logs                bool
+		}{
+			{collectLog, inst.LogDir(), "log", true},
+			{collectConfig, filepath.Join(inst.DeployDir(), "conf"), "conf", false},
+			{collectScript, filepath.Join(inst.DeployDir(), "scripts"), "scripts", false},
+		} {
+			trim := d.logs && !c.index.Since.IsZero()
+			cmd := fmt.Sprintf("find %s -maxdepth 1 -type f", d.remote)
+			if trim {
+				// the files not modified since are skipped, the others are
+				// trimmed below if they are in the unified log format
+				cmd += fmt.Sprintf(" -mmin -%d", int(math.Ceil(time.Since(c.index.Since).Minutes())))
+			}
+			stdout, stderr, err := e.Execute(nctx, cmd, false)
+			if err != nil {
+				c.addError("failed to list the files in %s of %s: %s, %s", d.remote, inst.ID(), err, stderr)
+				continue
+			}
+			for _, file := range strings.Fields(string(stdout)) {
+				f := collectedFile{Path: filepath.Join(instDir, d.local, filepath.Base(file)), Kind: d.kind, Host: host, Instance: inst.ID(), Source: file}
+				if trim {
+					c.downloadLog(nctx, e, file, f)
+				} else {
+					c.download(nctx, e, file, f)
+				}
+			}
+		}
+	}
+}
+
+// download copies the file on the host into the bundle
+func (c *bundleCollector) download(ctx context.Context, e ctxt.Executor, file string, f collectedFile) {
+	if err := e.Transfer(ctx, file, filepath.Join(c.dir, f.Path), true, c.limit, c.compress); err != nil {
+		c.addError("failed to download %s of %s: %s", file, f.Instance, err)
+		return
+	}
+	c.add(f)
+}
+
+// downloadLog copies the lines of the log file since the time of the bundle
+// into it, the log file is trimmed on the host before the transfer if it's in
+// the unified log format, or copied as a whole otherwise
+func (c *bundleCollector) downloadLog(ctx context.Context, e ctxt.Executor, file string, f collectedFile) {
+	stdout, stderr, err := e.Execute(ctx, trimLogCommand(file, c.index.Since), false)
+	if err != nil {
+		c.addError("failed to trim %s of %s: %s, %s", file, f.Instance, err, stderr)
+		return
+	}
+	trimmed := strings.TrimSpace(string(stdout))
+	if trimmed == file {
+		c.download(ctx, e, file, f)
+		return
+	}
+	f.Trimmed = true
+	c.download(ctx, e, trimmed, f)
+	if _, stderr, err := e.Execute(ctx, fmt.Sprintf("rm -f %s", utils.ShellQuote(trimmed)), false); err != nil {
+		c.addError("failed to remove %s on %s: %s, %s", trimmed, f.Host, err, stderr)
+	}
+}
+
+// unifiedLogFilter returns the awk command printing the lines of the unified
+// log format logged since the time, the timestamps of the lines are compared as
+// strings in the time zone of the host, the lines without a timestamp follow
+// the previous line
+func unifiedLogFilter(since time.Time) string {
+	return fmt.Sprintf(`awk -v s="$(date -d @%d '+[%%Y/%%m/%%d %%H:%%M:%%S')" 'BEGIN { p = 1 } /^\[[0-9][0-9][0-9][0-9]\/[0-9][0-9]\/[0-9][0-9] / { p = ($0 >= s) } p'`, since.Unix())
+}
+
+// trimLogCommand returns the command writing the lines of the log file since
+// the time to a temporary file on the host and printing its path, if the log
+// file is in the unified log format. The path of the log file is printed
+// otherwise.
+func trimLogCommand(file string, since time.Time) string {
+	return fmt.Sprintf(
+		`f=%s; if head -n 1 "$f" | grep -q '^\[[0-9]\{4\}/[0-9]\{2\}/[0-9]\{2\} '; then t=$(mktemp) && { %s "$f" > "$t" || { rm -f "$t"; exit 1; }; } && echo "$t"; else echo "$f"; fi`,
+		utils.ShellQuote(file), unifiedLogFilter(since),
+	)
+}
+
+// write saves the output of a command into the bundle
+func (c *bundleCollector) write(data []byte, f collectedFile) {
+	if err := utils.WriteFile(filepath.Join(c.dir, f.Path), data, 0644); err != nil {
+		c.addError("failed to write %s: %s", f.Path, err)
+		return
+	}
+	c.add(f)
+}
+
+// redact removes the secrets from all the files in the bundle
+func (c *bundleCollector) redact() error {
+	return filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return redactFile(path)
+	})
+}
+
+// bundle writes the index and packs the files into the tar.gz file, the sizes
+// in the index are the ones after redaction
+func (c *bundleCollector) bundle(output string) error {
+	slices.SortFunc(c.index.Files, func(a, b collectedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	for i, f := range c.index.Files {
+		if fi, err := os.Stat(filepath.Join(c.dir, f.Path)); err == nil {
+			c.index.Files[i].Size = fi.Size()
+		}
+	}
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	if err := utils.WriteFile(filepath.Join(c.dir, "index.json"), data, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer f.Close()
+	if err := utils.Tar(f, filepath.Dir(c.dir)); err != nil {
+		return perrs.Annotatef(err, "failed to create the bundle %s", output)
+	}
+	return nil
+}
+
+// redactFile replaces the secrets in the file line by line
+func redactFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer src.Close()
+	tmp := path + ".redacted"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer dst.Close()
+
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+	for {
+		line, err := r.ReadString('\n')
+		if _, werr := w.WriteString(utils.RedactSecrets(line)); werr != nil {
+			return perrs.AddStack(werr)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return perrs.AddStack(err)
+	}
+	if err := dst.Close(); err != nil {
+		return perrs.AddStack(err)
+	}
+	return perrs.AddStack(os.Rename(tmp, path))
+}
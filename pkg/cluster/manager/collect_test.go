@@ -0,0 +1,109 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBundle(t *testing.T) {
+	dir := t.TempDir()
+	auditDir := filepath.Join(dir, "audit")
+	require.NoError(t, utils.MkdirAll(auditDir, 0755))
+	// the command line of an audit log is the arguments of the process
+	args := os.Args
+	defer func() { os.Args = args }()
+	for _, name := range []string{"test", "other"} {
+		os.Args = []string{"tiup-cluster", "reload", name}
+		require.NoError(t, audit.OutputAuditLog(auditDir, "", []byte("log")))
+	}
+	meta := filepath.Join(dir, "meta.yaml")
+	require.NoError(t, os.WriteFile(meta, []byte("user: tidb\nmysql_password: abc\n"), 0644))
+
+	c := &bundleCollector{
+		dir: filepath.Join(dir, "bundle", "test-collect"),
+		index: collectIndex{
+			ClusterName: "test",
+			Since:       time.Now().Add(-time.Hour),
+		},
+	}
+	c.copyLocal(meta, "meta.yaml", collectMeta)
+	c.copyLocal(filepath.Join(dir, "missing.yaml"), "missing.yaml", collectMeta)
+	c.collectAudit(auditDir, "test")
+	require.Len(t, c.index.Files, 2)
+	require.Len(t, c.index.Errors, 1)
+
+	require.NoError(t, c.redact())
+	data, err := os.ReadFile(filepath.Join(c.dir, "meta.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "user: tidb\nmysql_password: \"******\"\n", string(data))
+
+	output := filepath.Join(dir, "test-collect.tar.gz")
+	require.NoError(t, c.bundle(output))
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer f.Close()
+	extracted := filepath.Join(dir, "extracted")
+	require.NoError(t, utils.Untar(f, extracted))
+
+	data, err = os.ReadFile(filepath.Join(extracted, "test-collect", "index.json"))
+	require.NoError(t, err)
+	var index collectIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	require.Equal(t, "test", index.ClusterName)
+	require.Equal(t, "audit", index.Files[0].Kind)
+	require.Equal(t, "meta.yaml", index.Files[1].Path)
+	require.Equal(t, int64(len("user: tidb\nmysql_password: \"******\"\n")), index.Files[1].Size)
+	require.FileExists(t, filepath.Join(extracted, "test-collect", index.Files[0].Path))
+}
+
+func TestTrimLogCommand(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	line := func(at time.Time, msg string) string {
+		return fmt.Sprintf("[%s] [INFO] [server.go:100] [\"%s\"]\n", at.Format(unifiedLogTimeLayout), msg)
+	}
+	unified := filepath.Join(dir, "tidb.log")
+	require.NoError(t, os.WriteFile(unified, []byte(
+		line(now.Add(-2*time.Hour), "old")+"old stack\n"+line(now.Add(-10*time.Minute), "new")+"new stack\n",
+	), 0644))
+	slow := filepath.Join(dir, "tidb_slow_query.log")
+	require.NoError(t, os.WriteFile(slow, []byte("# Time: 2025-01-02T15:04:05.123+08:00\nselect 1;\n"), 0644))
+
+	// the log in the unified format is trimmed into a temporary file
+	out, err := exec.Command("bash", "-c", trimLogCommand(unified, now.Add(-time.Hour))).Output()
+	require.NoError(t, err)
+	trimmed := strings.TrimSpace(string(out))
+	require.NotEqual(t, unified, trimmed)
+	defer os.Remove(trimmed)
+	data, err := os.ReadFile(trimmed)
+	require.NoError(t, err)
+	require.Equal(t, line(now.Add(-10*time.Minute), "new")+"new stack\n", string(data))
+
+	// the logs in other formats are collected as a whole
+	out, err = exec.Command("bash", "-c", trimLogCommand(slow, now.Add(-time.Hour))).Output()
+	require.NoError(t, err)
+	require.Equal(t, slow, strings.TrimSpace(string(out)))
+}
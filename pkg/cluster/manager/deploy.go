@@ -25,11 +25,11 @@ import (
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/cluster/task"
 	"github.com/pingcap/tiup/pkg/environment"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 
 	"github.com/pingcap/tiup/pkg/repository"
 	"github.com/pingcap/tiup/pkg/set"
@@ -43,9 +43,21 @@ type DeployOptions struct {
 	SkipCreateUser bool   // don't create the user
 	IdentityFile   string // path to the private key file
 	UsePassword    bool   // use password instead of identity file for ssh connection
+	UseSSHAgent    bool   // use the keys in the ssh-agent instead of identity file for ssh connection
 	NoLabels       bool   // don't check labels for TiKV instance
 	Stage1         bool   // don't start the new instance, just deploy
 	Stage2         bool   // start instances and init Config after stage1
+	NoSudo         bool   // deploy without sudo, the hosts are prepared by root with the generated scripts
+}
+
+// readSSHConnProps reads the credentials to connect to the hosts, the keys in the
+// ssh-agent are used instead of the identity file if useAgent is set, unless the
+// password is used.
+func readSSHConnProps(identityFile string, usePass, useAgent bool) (*tui.SSHConnectionProps, error) {
+	if useAgent && !usePass {
+		return tui.ReadSSHAgent()
+	}
+	return tui.ReadIdentityFileOrPassword(identityFile, usePass)
 }
 
 // DeployerInstance is a instance can deploy to a target deploy directory.
@@ -79,6 +91,12 @@ func (m *Manager) Deploy(
 			WithProperty(tui.SuggestionFromFormat("Please specify another cluster name"))
 	}
 
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata := m.specManager.NewMetadata()
 	topo := metadata.GetTopology()
 
@@ -113,6 +131,15 @@ func (m *Manager) Deploy(
 	if sshType := gOpt.SSHType; sshType != "" {
 		base.GlobalOptions.SSHType = sshType
 	}
+	if opt.NoSudo {
+		if base.GlobalOptions.SystemdMode != spec.UserMode {
+			m.logger.Infof("The value of systemd_mode is set to `%s` to deploy without sudo", spec.UserMode)
+			base.GlobalOptions.SystemdMode = spec.UserMode
+		}
+		if err := checkSudoless(topo, opt); err != nil {
+			return err
+		}
+	}
 
 	if topo, ok := topo.(*spec.Specification); ok {
 		topo.AdjustByVersion(clusterVersion)
@@ -136,21 +163,24 @@ func (m *Manager) Deploy(
 		sshConnProps  *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 		sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 	)
-	if gOpt.SSHType != executor.SSHTypeNone {
+	// no host is connected in dry run, so the credentials are not needed
+	if gOpt.SSHType.UsesSSH() && !gOpt.DryRun {
 		var err error
-		if sshConnProps, err = tui.ReadIdentityFileOrPassword(opt.IdentityFile, opt.UsePassword); err != nil {
+		useAgent := opt.UseSSHAgent || topo.BaseTopo().GlobalOptions.SSHAgent
+		if sshConnProps, err = readSSHConnProps(opt.IdentityFile, opt.UsePassword, useAgent); err != nil {
 			return err
 		}
-		if len(gOpt.SSHProxyHost) != 0 {
-			if sshProxyProps, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
-				return err
-			}
+		if sshProxyProps, err = readSSHProxyProps(topo, gOpt); err != nil {
+			return err
 		}
 	}
 
 	var sudo bool
 	systemdMode := topo.BaseTopo().GlobalOptions.SystemdMode
-	if systemdMode == spec.UserMode {
+	if systemdMode == spec.UserMode && opt.NoSudo {
+		// lingering is enabled by the scripts preparing the hosts
+		sudo = false
+	} else if systemdMode == spec.UserMode {
 		sudo = false
 		hint := fmt.Sprintf("loginctl enable-linger %s", opt.User)
 
@@ -158,36 +188,49 @@ func (m *Manager) Deploy(
 		msg += color.GreenString(hint)
 		msg += "\nYou can read the systemd documentation for reference: https://wiki.archlinux.org/title/Systemd/User#Automatic_start-up_of_systemd_user_instances."
 		m.logger.Warnf("%s", msg)
-		err = tui.PromptForConfirmOrAbortError("Do you want to continue? [y/N]: ")
-		if err != nil {
-			return err
+		if !gOpt.DryRun {
+			if err := tui.PromptForConfirmOrAbortError("Do you want to continue? [y/N]: "); err != nil {
+				return err
+			}
 		}
 	} else {
 		sudo = true
 	}
 
-	if err := m.fillHost(sshConnProps, sshProxyProps, topo, &gOpt, opt.User, opt.User != "root" && systemdMode != spec.UserMode); err != nil {
-		return err
+	// the arch and OS of hosts are detected via SSH, which is not allowed in dry run
+	if !gOpt.DryRun {
+		if err := m.fillHost(sshConnProps, sshProxyProps, topo, &gOpt, opt.User, opt.User != "root" && systemdMode != spec.UserMode); err != nil {
+			return err
+		}
 	}
 
-	if !skipConfirm && strings.ToLower(gOpt.DisplayMode) != "json" {
+	if !skipConfirm && !gOpt.DryRun && !logprinter.ParseDisplayMode(gOpt.DisplayMode).Structured() {
 		if err := m.confirmTopology(name, clusterVersion, topo, set.NewStringSet()); err != nil {
 			return err
 		}
 	}
 
-	if err := utils.MkdirAll(m.specManager.Path(name), 0755); err != nil {
-		return errorx.InitializationFailed.
-			Wrap(err, "Failed to create cluster metadata directory '%s'", m.specManager.Path(name)).
-			WithProperty(tui.SuggestionFromString("Please check file system permissions and try again."))
+	if !gOpt.DryRun {
+		if err := utils.MkdirAll(m.specManager.Path(name), 0755); err != nil {
+			return errorx.InitializationFailed.
+				Wrap(err, "Failed to create cluster metadata directory '%s'", m.specManager.Path(name)).
+				WithProperty(tui.SuggestionFromString("Please check file system permissions and try again."))
+		}
 	}
 
 	var (
+		prepareCheckTasks []*task.StepDisplay // tasks which are used to check the hosts are prepared by root
 		envInitTasks      []*task.StepDisplay // tasks which are used to initialize environment
 		downloadCompTasks []*task.StepDisplay // tasks which are used to download components
 		deployCompTasks   []*task.StepDisplay // tasks which are used to copy components to remote host
 	)
 
+	if opt.NoSudo {
+		if prepareCheckTasks, err = buildPrepareCheckTasks(m, name, topo, opt, gOpt, sshConnProps, sshProxyProps); err != nil {
+			return err
+		}
+	}
+
 	// Initialize environment
 
 	globalOptions := base.GlobalOptions
@@ -211,9 +254,10 @@ func (m *Manager) Deploy(
 	})
 
 	// generate CA and client cert for TLS enabled cluster
-	_, err = m.genAndSaveCertificate(name, globalOptions)
-	if err != nil {
-		return err
+	if !gOpt.DryRun {
+		if _, err = m.genAndSaveCertificate(name, globalOptions); err != nil {
+			return err
+		}
 	}
 
 	uniqueHosts, noAgentHosts := getMonitorHosts(topo)
@@ -242,6 +286,7 @@ func (m *Manager) Deploy(
 				sshConnProps.Password,
 				sshConnProps.IdentityFile,
 				sshConnProps.IdentityFilePassphrase,
+				sshConnProps.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -250,6 +295,7 @@ func (m *Manager) Deploy(
 				sshProxyProps.Password,
 				sshProxyProps.IdentityFile,
 				sshProxyProps.IdentityFilePassphrase,
+				sshProxyProps.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				globalOptions.SSHType,
@@ -326,16 +372,20 @@ func (m *Manager) Deploy(
 		return iterErr
 	}
 
-	// generates certificate for instance and transfers it to the server
-	certificateTasks, err := buildCertificateTasks(m, name, topo, metadata.GetBaseMeta(), gOpt, sshProxyProps)
-	if err != nil {
-		return err
-	}
-	sessionCertTasks, err := buildSessionCertTasks(m, name, nil, topo, metadata.GetBaseMeta(), gOpt, sshProxyProps)
-	if err != nil {
-		return err
+	// generates certificate for instance and transfers it to the server,
+	// the CA is not generated in dry run so the certificates can't be signed
+	var certificateTasks []*task.StepDisplay
+	if !gOpt.DryRun {
+		certificateTasks, err = buildCertificateTasks(m, name, topo, metadata.GetBaseMeta(), gOpt, sshProxyProps)
+		if err != nil {
+			return err
+		}
+		sessionCertTasks, err := buildSessionCertTasks(m, name, nil, topo, metadata.GetBaseMeta(), gOpt, sshProxyProps)
+		if err != nil {
+			return err
+		}
+		certificateTasks = append(certificateTasks, sessionCertTasks...)
 	}
-	certificateTasks = append(certificateTasks, sessionCertTasks...)
 
 	refreshConfigTasks, _ := buildInitConfigTasks(m, name, topo, metadata.GetBaseMeta(), gOpt, nil)
 
@@ -356,20 +406,22 @@ func (m *Manager) Deploy(
 	deployCompTasks = append(deployCompTasks, dpTasks...)
 
 	// monitor tls file
-	moniterCertificateTasks, err := buildMonitoredCertificateTasks(
-		m,
-		name,
-		uniqueHosts,
-		noAgentHosts,
-		topo.BaseTopo().GlobalOptions,
-		topo.GetMonitoredOptions(),
-		gOpt,
-		sshProxyProps,
-	)
-	if err != nil {
-		return err
+	if !gOpt.DryRun {
+		moniterCertificateTasks, err := buildMonitoredCertificateTasks(
+			m,
+			name,
+			uniqueHosts,
+			noAgentHosts,
+			topo.BaseTopo().GlobalOptions,
+			topo.GetMonitoredOptions(),
+			gOpt,
+			sshProxyProps,
+		)
+		if err != nil {
+			return err
+		}
+		certificateTasks = append(certificateTasks, moniterCertificateTasks...)
 	}
-	certificateTasks = append(certificateTasks, moniterCertificateTasks...)
 
 	monitorConfigTasks := buildInitMonitoredConfigTasks(
 		m.specManager,
@@ -385,6 +437,12 @@ func (m *Manager) Deploy(
 		sshProxyProps,
 	)
 	builder := task.NewBuilder(m.logger).
+		SSHKnownHosts(m.specManager.Path(name, "ssh", "known_hosts"), true).
+		SSHProxies(spec.SSHProxies(topo))
+	if len(prepareCheckTasks) > 0 {
+		builder = builder.ParallelStep("+ Check the preparation of target hosts", false, prepareCheckTasks...)
+	}
+	builder = builder.
 		Step("+ Generate SSH keys",
 			task.NewBuilder(m.logger).
 				SSHKeyGen(m.specManager.Path(name, "ssh", "id_rsa")).
@@ -403,11 +461,22 @@ func (m *Manager) Deploy(
 
 	t := builder.Build()
 
-	ctx := ctxt.New(
+	if gOpt.DryRun {
+		var extra []string
+		if globalOptions.TLSEnabled {
+			extra = append(extra, "+ Generate CA and copy TLS certificates to remote hosts")
+		}
+		return m.dryRun(name, "deploy", t, extra...)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
+	ctx, cleanupPeers := task.WithPeerDistributor(ctx, gOpt.PeerFanout, topo)
+	defer cleanupPeers()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
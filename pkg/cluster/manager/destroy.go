@@ -37,6 +37,12 @@ func (m *Manager) DestroyCluster(name string, gOpt operator.Options, destroyOpt
 		return err
 	}
 
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) &&
 		!errors.Is(perrs.Cause(err), spec.ErrNoTiSparkMaster) &&
@@ -53,7 +59,7 @@ func (m *Manager) DestroyCluster(name string, gOpt operator.Options, destroyOpt
 		return err
 	}
 
-	if !skipConfirm {
+	if !skipConfirm && !gOpt.DryRun {
 		m.logger.Warnf("%s", color.HiRedString(tui.ASCIIArtWarning))
 		if err := tui.PromptForAnswerOrAbortError(
 			"Yes, I know my cluster and data will be deleted.",
@@ -87,11 +93,17 @@ func (m *Manager) DestroyCluster(name string, gOpt operator.Options, destroyOpt
 		}).
 		Build()
 
-	ctx := ctxt.New(
+	if gOpt.DryRun {
+		return m.dryRun(name, "destroy", t,
+			instancePlan("Stop and destroy instances", topo.ComponentsByStopOrder(), operator.Options{})...)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -114,6 +126,12 @@ func (m *Manager) DestroyTombstone(
 	gOpt operator.Options,
 	skipConfirm bool,
 ) error {
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	// allow specific validation errors so that user can recover a broken
 	// cluster if it is somehow in a bad state.
@@ -142,11 +160,12 @@ func (m *Manager) DestroyTombstone(
 		return err
 	}
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	nodes, err := operator.DestroyTombstone(ctx, cluster, true /* returnNodesOnly */, gOpt, tlsCfg)
 	if err != nil {
 		return err
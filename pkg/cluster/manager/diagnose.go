@@ -0,0 +1,532 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"cmp"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// DiagnoseOptions contains the options for diagnosing a cluster.
+type DiagnoseOptions struct {
+	SkipCheck      bool   // skip the checks of the system on the hosts
+	CertExpiryDays int    // report the certificates expiring in the days
+	OutputFile     string // write the report in JSON to the file
+	TempDir        string // the temporary directory of the checks on the hosts
+}
+
+// Severity is the severity of a finding of diagnose
+type Severity int
+
+// The severities of the findings, from the lowest to the highest
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+var severityNames = []string{"info", "warning", "critical"}
+
+// String implements the fmt.Stringer interface
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return "unknown"
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// the categories of the findings
+const (
+	diagnoseInstance = "instance"
+	diagnoseStore    = "store"
+	diagnoseRegion   = "region"
+	diagnoseTLS      = "tls"
+	diagnoseVersion  = "version"
+	diagnosePatch    = "patch"
+	diagnoseCheck    = "check"
+)
+
+// the available space of a store below the percentage of its capacity is reported
+const storeLowSpacePercent = 20
+
+// the components which must run the same version as the cluster
+var versionLockedComponents = []string{
+	spec.ComponentPD,
+	spec.ComponentTSO,
+	spec.ComponentScheduling,
+	spec.ComponentTiKV,
+	spec.ComponentTiKVWorker,
+	spec.ComponentTiFlash,
+	spec.ComponentTiDB,
+}
+
+// Finding is a problem of the cluster found by diagnose, with the TiUP command
+// suggested to look into or fix it
+type Finding struct {
+	Severity   Severity `json:"severity"`
+	Category   string   `json:"category"`
+	Target     string   `json:"target"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion"`
+}
+
+// DiagnoseReport is the health report of a cluster
+type DiagnoseReport struct {
+	ClusterName    string         `json:"cluster_name"`
+	ClusterVersion string         `json:"cluster_version"`
+	Time           time.Time      `json:"time"`
+	Summary        map[string]int `json:"summary"`
+	Findings       []Finding      `json:"findings"`
+}
+
+// Diagnose aggregates the status of the instances, the stores and regions in
+// PD, the certificates, the versions and patches of the instances and the
+// checks of the hosts into a report of findings ranked by severity.
+func (m *Manager) Diagnose(name string, opt DiagnoseOptions, gOpt operator.Options) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	topo, ok := metadata.GetTopology().(*spec.Specification)
+	if !ok {
+		return perrs.Errorf("cluster %s is not a TiDB cluster", name)
+	}
+	base := metadata.GetBaseMeta()
+
+	m.logger.Infof("Diagnosing cluster %s...", name)
+	insts, err := m.GetClusterTopology(DisplayOption{ClusterName: name}, gOpt)
+	if err != nil {
+		return err
+	}
+
+	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
+	if err != nil {
+		return err
+	}
+	pdList := topo.GetPDListWithManageHost()
+	pdClient := api.NewPDClient(
+		context.WithValue(context.TODO(), logprinter.ContextKeyLogger, m.logger),
+		pdList,
+		time.Second*time.Duration(gOpt.APITimeout),
+		tlsCfg,
+	)
+
+	var findings []Finding
+	// the instances of the stores are reported by the state of the stores
+	storeIDs := storeInstanceIDs(topo)
+	stores, err := pdClient.GetStores()
+	if err != nil {
+		findings = append(findings, Finding{
+			Severity:   SeverityCritical,
+			Category:   diagnoseStore,
+			Target:     "pd",
+			Message:    fmt.Sprintf("failed to get the stores from PD: %s", err),
+			Suggestion: fmt.Sprintf("tiup cluster display %s -R pd", name),
+		})
+		stores = &api.StoresInfo{}
+	}
+	storeFound, storeResults := storeFindings(name, stores, storeIDs)
+	findings = append(findings, storeResults...)
+	findings = append(findings, instanceFindings(name, base.Version, insts, storeFound)...)
+
+	pdURL := "http://<pd>"
+	if len(pdList) > 0 {
+		pdURL = fmt.Sprintf("%s://%s", utils.Ternary(tlsCfg != nil, "https", "http"), pdList[0])
+	}
+	for _, state := range []string{"miss-peer", "pending-peer", "down-peer"} {
+		f := Finding{
+			Severity:   SeverityWarning,
+			Category:   diagnoseRegion,
+			Target:     state,
+			Suggestion: fmt.Sprintf("tiup ctl:%s pd -u %s region check %s", base.Version, pdURL, state),
+		}
+		rInfo, err := pdClient.CheckRegion(state)
+		switch {
+		case err != nil:
+			f.Message = fmt.Sprintf("failed to check the %s regions: %s", state, err)
+		case rInfo.Count == 0:
+			continue
+		default:
+			f.Message = fmt.Sprintf("%d regions are %s", rInfo.Count, state)
+			if state == "down-peer" {
+				f.Severity = SeverityCritical
+			}
+		}
+		findings = append(findings, f)
+	}
+
+	if topo.GlobalOptions.TLSEnabled {
+		now := time.Now()
+		within := time.Duration(opt.CertExpiryDays) * 24 * time.Hour
+		for _, file := range []string{spec.TLSCACert, spec.TLSClientCert} {
+			f := Finding{
+				Severity:   SeverityWarning,
+				Category:   diagnoseTLS,
+				Target:     file,
+				Suggestion: fmt.Sprintf("tiup cluster tls %s enable --reload-certificate --force", name),
+			}
+			cert, err := readCertificate(m.specManager.Path(name, spec.TLSCertKeyDir, file))
+			if err != nil {
+				f.Message = err.Error()
+				findings = append(findings, f)
+				continue
+			}
+			if f, found := certFinding(f, cert, now, within); found {
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	if !opt.SkipCheck {
+		results, err := m.checkHosts(name, topo, base.User, opt, gOpt)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity:   SeverityWarning,
+				Category:   diagnoseCheck,
+				Target:     name,
+				Message:    fmt.Sprintf("failed to check the hosts: %s", err),
+				Suggestion: fmt.Sprintf("tiup cluster check %s --cluster", name),
+			})
+		}
+		findings = append(findings, checkFindings(name, results)...)
+	}
+
+	report := newDiagnoseReport(name, base.Version, findings)
+	if opt.OutputFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return perrs.AddStack(err)
+		}
+		if err := utils.WriteFile(opt.OutputFile, append(data, '\n'), 0644); err != nil {
+			return perrs.Annotatef(err, "failed to write the report to %s", opt.OutputFile)
+		}
+		m.logger.Infof("The report is written to %s", opt.OutputFile)
+	}
+
+	if m.logger.GetDisplayMode().Structured() {
+		return m.logger.Output(report)
+	}
+	printDiagnoseReport(report)
+	return nil
+}
+
+// checkHosts runs the checks of the system on the hosts of the cluster and
+// returns the results without printing them
+func (m *Manager) checkHosts(name string, topo *spec.Specification, user string, opt DiagnoseOptions, gOpt operator.Options) ([]HostCheckResult, error) {
+	checkOpt := CheckOptions{
+		User:         user,
+		IdentityFile: m.specManager.Path(name, "ssh", "id_rsa"),
+		Opr:          &operator.CheckOptions{},
+		ExistCluster: true,
+		TempDir:      opt.TempDir,
+	}
+
+	var (
+		sshConnProps  = &tui.SSHConnectionProps{}
+		sshProxyProps = &tui.SSHConnectionProps{}
+	)
+	if gOpt.SSHType.UsesSSH() {
+		var err error
+		if sshConnProps, err = readSSHConnProps(checkOpt.IdentityFile, false, false); err != nil {
+			return nil, err
+		}
+		if sshProxyProps, err = readSSHProxyProps(topo, gOpt); err != nil {
+			return nil, err
+		}
+	}
+	sudo := topo.BaseTopo().GlobalOptions.SystemdMode != spec.UserMode && user != "root"
+	if err := m.fillHost(sshConnProps, sshProxyProps, topo, &gOpt, user, sudo); err != nil {
+		return nil, err
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(context.Background(), gOpt.Concurrency, m.logger)
+
+	defer closeSSH()
+	results, _, err := runSystemChecks(ctx, sshConnProps, sshProxyProps, topo, &gOpt, &checkOpt, topo)
+	return results, err
+}
+
+// storeInstanceIDs maps the addresses of the stores to the IDs of the instances
+func storeInstanceIDs(topo *spec.Specification) map[string]string {
+	ids := make(map[string]string)
+	for _, s := range topo.TiKVServers {
+		ids[utils.JoinHostPort(s.Host, s.Port)] = utils.JoinHostPort(s.Host, s.Port)
+	}
+	for _, s := range topo.TiFlashServers {
+		ids[utils.JoinHostPort(s.Host, s.FlashServicePort)] = utils.JoinHostPort(s.Host, s.TCPPort)
+	}
+	return ids
+}
+
+// storeFindings reports the stores which are not up or short of space, it also
+// returns the IDs of the instances found in the stores
+func storeFindings(name string, stores *api.StoresInfo, ids map[string]string) (map[string]struct{}, []Finding) {
+	found := make(map[string]struct{})
+	var findings []Finding
+	for _, s := range stores.Stores {
+		if s.Store == nil || s.Store.Store == nil {
+			continue
+		}
+		target := fmt.Sprintf("store %d (%s)", s.Store.Id, s.Store.Address)
+		id, ok := ids[s.Store.Address]
+		if ok {
+			found[id] = struct{}{}
+		}
+		node := func(cmd string) string {
+			if !ok {
+				return fmt.Sprintf("tiup cluster display %s", name)
+			}
+			return fmt.Sprintf("tiup cluster %s %s -N %s", cmd, name, id)
+		}
+
+		switch s.Store.StateName {
+		case "Down":
+			findings = append(findings, Finding{SeverityCritical, diagnoseStore, target, "the store is down", node("start")})
+		case "Disconnected":
+			findings = append(findings, Finding{SeverityWarning, diagnoseStore, target, "the store is disconnected from PD", node("restart")})
+		case "Offline":
+			findings = append(findings, Finding{SeverityInfo, diagnoseStore, target, "the store is being scaled in", node("display")})
+		case "Tombstone":
+			findings = append(findings, Finding{SeverityInfo, diagnoseStore, target, "the store is tombstone", fmt.Sprintf("tiup cluster prune %s", name)})
+			continue
+		}
+
+		if s.Status != nil && s.Status.Capacity > 0 {
+			percent := float64(s.Status.Available) * 100 / float64(s.Status.Capacity)
+			if percent < storeLowSpacePercent {
+				findings = append(findings, Finding{
+					Severity:   SeverityWarning,
+					Category:   diagnoseStore,
+					Target:     target,
+					Message:    fmt.Sprintf("only %.1f%% of the capacity is available", percent),
+					Suggestion: fmt.Sprintf("tiup cluster scale-out %s <topology.yaml> -R tikv", name),
+				})
+			}
+		}
+	}
+	return found, findings
+}
+
+// instanceFindings reports the instances which are not up, patched or running
+// another version than the cluster, the status of the instances in stores is
+// reported by storeFindings
+func instanceFindings(name, version string, insts []InstInfo, stores map[string]struct{}) []Finding {
+	var findings []Finding
+	versions := make(map[string]string)
+	for _, inst := range insts {
+		if inst.Version != "" && inst.Version != version {
+			versions[inst.ComponentName] = inst.Version
+		}
+		if strings.HasSuffix(inst.Role, " (patched)") {
+			findings = append(findings, Finding{
+				Severity:   SeverityInfo,
+				Category:   diagnosePatch,
+				Target:     inst.ID,
+				Message:    fmt.Sprintf("%s is running a patched binary", inst.ComponentName),
+				Suggestion: fmt.Sprintf("tiup cluster audit %s", name),
+			})
+		}
+		if _, ok := stores[inst.ID]; ok {
+			continue
+		}
+
+		status := strings.ToLower(inst.Status)
+		display := fmt.Sprintf("tiup cluster display %s -N %s", name, inst.ID)
+		f := Finding{Category: diagnoseInstance, Target: inst.ID}
+		switch {
+		case hasAnyPrefix(status, "up", "healthy", "free", "active"):
+			continue
+		case strings.HasSuffix(status, "(maintenance)"):
+			f.Severity, f.Message, f.Suggestion = SeverityInfo, fmt.Sprintf("the %s instance is in maintenance: %s", inst.ComponentName, inst.Status), display
+		case hasAnyPrefix(status, "down", "err", "inactive"):
+			f.Severity, f.Message = SeverityCritical, fmt.Sprintf("the %s instance is %s", inst.ComponentName, inst.Status)
+			f.Suggestion = fmt.Sprintf("tiup cluster start %s -N %s", name, inst.ID)
+		case strings.Contains(status, "offline"):
+			f.Severity, f.Message, f.Suggestion = SeverityInfo, fmt.Sprintf("the %s instance is being scaled in", inst.ComponentName), display
+		case strings.HasPrefix(status, "tombstone"):
+			f.Severity, f.Message = SeverityInfo, fmt.Sprintf("the %s instance is tombstone", inst.ComponentName)
+			f.Suggestion = fmt.Sprintf("tiup cluster prune %s", name)
+		default:
+			f.Severity, f.Message, f.Suggestion = SeverityWarning, fmt.Sprintf("the status of the %s instance is unknown: %s", inst.ComponentName, inst.Status), display
+		}
+		findings = append(findings, f)
+	}
+
+	for comp, v := range versions {
+		severity := SeverityInfo
+		if slices.Contains(versionLockedComponents, comp) {
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Severity:   severity,
+			Category:   diagnoseVersion,
+			Target:     comp,
+			Message:    fmt.Sprintf("%s is running %s while the cluster version is %s", comp, v, version),
+			Suggestion: fmt.Sprintf("tiup cluster edit-config %s", name),
+		})
+	}
+	return findings
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCertificate reads a PEM encoded certificate from the file
+func readCertificate(file string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to read the certificate")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, perrs.Errorf("failed to decode the certificate %s", file)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to parse the certificate %s", file)
+	}
+	return cert, nil
+}
+
+// certFinding fills the finding if the certificate is expired or expires in
+// the duration
+func certFinding(f Finding, cert *x509.Certificate, now time.Time, within time.Duration) (Finding, bool) {
+	expiry := cert.NotAfter.Format(time.RFC3339)
+	switch {
+	case !now.Before(cert.NotAfter):
+		f.Severity = SeverityCritical
+		f.Message = fmt.Sprintf("the certificate expired at %s", expiry)
+	case now.Add(within).After(cert.NotAfter):
+		f.Severity = SeverityWarning
+		f.Message = fmt.Sprintf("the certificate expires at %s", expiry)
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+// checkFindings reports the failed checks as warnings and the checks with
+// warnings as information
+func checkFindings(name string, results []HostCheckResult) []Finding {
+	var findings []Finding
+	for _, r := range results {
+		var severity Severity
+		switch r.Status {
+		case "Fail":
+			severity = SeverityWarning
+		case "Warn":
+			severity = SeverityInfo
+		default:
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity:   severity,
+			Category:   diagnoseCheck,
+			Target:     r.Node,
+			Message:    fmt.Sprintf("%s: %s", r.Name, r.Message),
+			Suggestion: fmt.Sprintf("tiup cluster check %s --cluster --apply", name),
+		})
+	}
+	return findings
+}
+
+// newDiagnoseReport sorts the findings from the most severe one and counts them
+func newDiagnoseReport(name, version string, findings []Finding) *DiagnoseReport {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(b.Severity, a.Severity),
+			cmp.Compare(a.Category, b.Category),
+			cmp.Compare(a.Target, b.Target),
+		)
+	})
+	summary := make(map[string]int)
+	for _, s := range severityNames {
+		summary[s] = 0
+	}
+	for _, f := range findings {
+		summary[f.Severity.String()]++
+	}
+	if findings == nil {
+		findings = []Finding{}
+	}
+	return &DiagnoseReport{
+		ClusterName:    name,
+		ClusterVersion: version,
+		Time:           time.Now(),
+		Summary:        summary,
+		Findings:       findings,
+	}
+}
+
+func printDiagnoseReport(report *DiagnoseReport) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	fmt.Printf("Cluster name:       %s\n", cyan.Sprint(report.ClusterName))
+	fmt.Printf("Cluster version:    %s\n", cyan.Sprint(report.ClusterVersion))
+	fmt.Printf("Diagnosed at:       %s\n", cyan.Sprint(report.Time.Format(time.RFC3339)))
+	fmt.Printf("Summary:            %s, %s, %s\n",
+		color.RedString("%d critical", report.Summary[SeverityCritical.String()]),
+		color.YellowString("%d warning", report.Summary[SeverityWarning.String()]),
+		fmt.Sprintf("%d info", report.Summary[SeverityInfo.String()]),
+	)
+	if len(report.Findings) == 0 {
+		fmt.Println(color.GreenString("No problems found."))
+		return
+	}
+
+	rows := [][]string{{"Severity", "Category", "Target", "Message", "Suggestion"}}
+	for _, f := range report.Findings {
+		severity := f.Severity.String()
+		switch f.Severity {
+		case SeverityCritical:
+			severity = color.RedString(severity)
+		case SeverityWarning:
+			severity = color.YellowString(severity)
+		}
+		rows = append(rows, []string{severity, f.Category, f.Target, f.Message, f.Suggestion})
+	}
+	tui.PrintTable(rows, true)
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/api/typeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseFindings(t *testing.T) {
+	store := func(id uint64, addr, state string, capacity, available uint64) *api.StoreInfo {
+		return &api.StoreInfo{
+			Store:  &api.MetaStore{Store: &metapb.Store{Id: id, Address: addr}, StateName: state},
+			Status: &api.StoreStatus{Capacity: typeutil.ByteSize(capacity), Available: typeutil.ByteSize(available)},
+		}
+	}
+	stores := &api.StoresInfo{Stores: []*api.StoreInfo{
+		store(1, "172.16.5.1:20160", "Up", 100<<30, 10<<30),
+		store(2, "172.16.5.2:20160", "Down", 0, 0),
+		store(3, "172.16.5.3:20160", "Tombstone", 0, 0),
+	}}
+	ids := map[string]string{
+		"172.16.5.1:20160": "172.16.5.1:20160",
+		"172.16.5.2:20160": "172.16.5.2:20160",
+	}
+	found, findings := storeFindings("test", stores, ids)
+	require.Len(t, found, 2)
+	require.Equal(t, []Finding{
+		{SeverityWarning, diagnoseStore, "store 1 (172.16.5.1:20160)", "only 10.0% of the capacity is available", "tiup cluster scale-out test <topology.yaml> -R tikv"},
+		{SeverityCritical, diagnoseStore, "store 2 (172.16.5.2:20160)", "the store is down", "tiup cluster start test -N 172.16.5.2:20160"},
+		{SeverityInfo, diagnoseStore, "store 3 (172.16.5.3:20160)", "the store is tombstone", "tiup cluster prune test"},
+	}, findings)
+
+	insts := []InstInfo{
+		{ID: "172.16.5.1:2379", Role: "pd", ComponentName: "pd", Status: "Up|L|UI", Version: "v8.1.0"},
+		{ID: "172.16.5.1:4000", Role: "tidb (patched)", ComponentName: "tidb", Status: "Down", Version: "v8.1.0"},
+		{ID: "172.16.5.2:20160", Role: "tikv", ComponentName: "tikv", Status: "Down", Version: "v8.1.0"},
+		{ID: "172.16.5.1:8300", Role: "cdc", ComponentName: "cdc", Status: "N/A", Version: "v8.1.1"},
+	}
+	require.Equal(t, []Finding{
+		{SeverityInfo, diagnosePatch, "172.16.5.1:4000", "tidb is running a patched binary", "tiup cluster audit test"},
+		{SeverityCritical, diagnoseInstance, "172.16.5.1:4000", "the tidb instance is Down", "tiup cluster start test -N 172.16.5.1:4000"},
+		{SeverityWarning, diagnoseInstance, "172.16.5.1:8300", "the status of the cdc instance is unknown: N/A", "tiup cluster display test -N 172.16.5.1:8300"},
+		{SeverityInfo, diagnoseVersion, "cdc", "cdc is running v8.1.1 while the cluster version is v8.1.0", "tiup cluster edit-config test"},
+	}, instanceFindings("test", "v8.1.0", insts, found))
+
+	require.Len(t, checkFindings("test", []HostCheckResult{
+		{Node: "172.16.5.1", Name: "swap", Status: "Fail", Message: "swap is enabled"},
+		{Node: "172.16.5.1", Name: "os-version", Status: "Pass"},
+	}), 1)
+}
+
+func TestDiagnoseCertificate(t *testing.T) {
+	now := time.Now()
+	cert := &x509.Certificate{NotAfter: now.Add(10 * 24 * time.Hour)}
+
+	_, found := certFinding(Finding{}, cert, now, 7*24*time.Hour)
+	require.False(t, found)
+	f, found := certFinding(Finding{}, cert, now, 30*24*time.Hour)
+	require.True(t, found)
+	require.Equal(t, SeverityWarning, f.Severity)
+	f, found = certFinding(Finding{}, cert, now.Add(11*24*time.Hour), 0)
+	require.True(t, found)
+	require.Equal(t, SeverityCritical, f.Severity)
+	require.Contains(t, f.Message, "expired")
+}
+
+func TestDiagnoseReport(t *testing.T) {
+	report := newDiagnoseReport("test", "v8.1.0", []Finding{
+		{Severity: SeverityInfo, Category: diagnosePatch, Target: "b"},
+		{Severity: SeverityCritical, Category: diagnoseStore, Target: "a"},
+		{Severity: SeverityWarning, Category: diagnoseRegion, Target: "c"},
+		{Severity: SeverityCritical, Category: diagnoseInstance, Target: "d"},
+	})
+	targets := make([]string, 0)
+	for _, f := range report.Findings {
+		targets = append(targets, f.Target)
+	}
+	require.Equal(t, []string{"d", "a", "c", "b"}, targets)
+	require.Equal(t, map[string]int{"critical": 2, "warning": 1, "info": 1}, report.Summary)
+
+	data, err := json.Marshal(report.Findings[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"severity":"critical","category":"instance","target":"d","message":"","suggestion":""}`, string(data))
+
+	require.Empty(t, newDiagnoseReport("test", "v8.1.0", nil).Findings)
+	require.NotNil(t, newDiagnoseReport("test", "v8.1.0", nil).Findings)
+}
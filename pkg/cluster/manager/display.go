@@ -16,7 +16,6 @@ package manager
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -38,6 +37,7 @@ import (
 	"github.com/pingcap/tiup/pkg/crypto"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
+	"github.com/pingcap/tiup/pkg/proxy"
 	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
@@ -103,6 +103,8 @@ type ClusterMetaInfo struct {
 	DashboardURL   string   `json:"dashboard_url,omitempty"`
 	DashboardURLS  []string `json:"dashboard_urls,omitempty"`
 	GrafanaURLS    []string `json:"grafana_urls,omitempty"`
+	// the hosts under maintenance
+	MaintenanceHosts []string `json:"maintenance_hosts,omitempty"`
 }
 
 // JSONOutput holds the structure for the JSON output of `tiup cluster display --json`
@@ -143,7 +145,7 @@ func (m *Manager) Display(dopt DisplayOption, opt operator.Options) error {
 	statusTimeout := time.Duration(opt.APITimeout) * time.Second
 	// display cluster meta
 	var j *JSONOutput
-	if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON {
+	if m.logger.GetDisplayMode().Structured() {
 		j = &JSONOutput{
 			ClusterMetaInfo: ClusterMetaInfo{
 				m.sysName,
@@ -158,9 +160,13 @@ func (m *Manager) Display(dopt DisplayOption, opt operator.Options) error {
 				"",
 				nil,
 				nil,
+				nil,
 			},
 			InstanceInfos: clusterInstInfos,
 		}
+		if clusterMeta, ok := metadata.(*spec.ClusterMeta); ok {
+			j.ClusterMetaInfo.MaintenanceHosts = clusterMeta.MaintenanceHosts
+		}
 
 		if topo.BaseTopo().GlobalOptions.TLSEnabled {
 			j.ClusterMetaInfo.TLSCACert = m.specManager.Path(name, spec.TLSCertKeyDir, spec.TLSCACert)
@@ -173,6 +179,9 @@ func (m *Manager) Display(dopt DisplayOption, opt operator.Options) error {
 		fmt.Printf("Cluster version:    %s\n", cyan.Sprint(base.Version))
 		fmt.Printf("Deploy user:        %s\n", cyan.Sprint(topo.BaseTopo().GlobalOptions.User))
 		fmt.Printf("SSH type:           %s\n", cyan.Sprint(topo.BaseTopo().GlobalOptions.SSHType))
+		if clusterMeta, ok := metadata.(*spec.ClusterMeta); ok && len(clusterMeta.MaintenanceHosts) > 0 {
+			fmt.Printf("Maintenance hosts:  %s\n", color.YellowString(strings.Join(clusterMeta.MaintenanceHosts, ",")))
+		}
 
 		// display TLS info
 		if topo.BaseTopo().GlobalOptions.TLSEnabled {
@@ -262,16 +271,17 @@ func (m *Manager) Display(dopt DisplayOption, opt operator.Options) error {
 		return err
 	}
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		opt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if t, ok := topo.(*spec.Specification); ok {
 		_ = m.displayDashboards(ctx, t, j, statusTimeout, tlsCfg, "", masterActive...)
 	}
 
-	if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON {
+	if m.logger.GetDisplayMode().Structured() {
 		grafanaURLs := getGrafanaURL(clusterInstInfos)
 		if len(grafanaURLs) != 0 {
 			j.ClusterMetaInfo.GrafanaURLS = grafanaURLs
@@ -283,13 +293,8 @@ func (m *Manager) Display(dopt DisplayOption, opt operator.Options) error {
 		}
 	}
 
-	if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON {
-		d, err := json.MarshalIndent(j, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(d))
-		return nil
+	if m.logger.GetDisplayMode().Structured() {
+		return m.logger.Output(j)
 	}
 
 	tui.PrintTable(clusterTable, true)
@@ -360,7 +365,7 @@ func (m *Manager) DisplayTiKVLabels(dopt DisplayOption, opt operator.Options) er
 	cyan := color.New(color.FgCyan, color.Bold)
 
 	var j *JSONOutput
-	if strings.ToLower(opt.DisplayMode) == "json" {
+	if logprinter.ParseDisplayMode(opt.DisplayMode).Structured() {
 		j = &JSONOutput{
 			ClusterMetaInfo: ClusterMetaInfo{
 				m.sysName,
@@ -375,6 +380,7 @@ func (m *Manager) DisplayTiKVLabels(dopt DisplayOption, opt operator.Options) er
 				"",
 				nil,
 				nil,
+				nil,
 			},
 		}
 
@@ -417,11 +423,12 @@ func (m *Manager) DisplayTiKVLabels(dopt DisplayOption, opt operator.Options) er
 		}
 	}
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		opt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 
 	masterList := topo.BaseTopo().MasterList
 	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
@@ -496,15 +503,10 @@ func (m *Manager) DisplayTiKVLabels(dopt DisplayOption, opt operator.Options) er
 		}
 	}
 
-	if strings.ToLower(opt.DisplayMode) == "json" {
+	if logprinter.ParseDisplayMode(opt.DisplayMode).Structured() {
 		j.LocationLabel = strings.Join(locationLabel, ",")
 		j.LabelInfos = labelInfoArr
-		d, err := json.MarshalIndent(j, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(d))
-		return nil
+		return m.logger.Output(j)
 	}
 	fmt.Printf("Location labels:    %s\n", cyan.Sprint(strings.Join(locationLabel, ",")))
 	tui.PrintTable(clusterTable, true)
@@ -515,11 +517,12 @@ func (m *Manager) DisplayTiKVLabels(dopt DisplayOption, opt operator.Options) er
 
 // GetClusterTopology get the topology of the cluster.
 func (m *Manager) GetClusterTopology(dopt DisplayOption, opt operator.Options) ([]InstInfo, error) {
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		opt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	name := dopt.ClusterName
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) &&
@@ -537,7 +540,22 @@ func (m *Manager) GetClusterTopology(dopt DisplayOption, opt operator.Options) (
 		return nil, err
 	}
 
-	err = SetClusterSSH(ctx, topo, base.User, opt.SSHTimeout, opt.SSHType, topo.BaseTopo().GlobalOptions.SSHType)
+	knownHosts := m.specManager.Path(name, "ssh", "known_hosts")
+	SetSSHKnownHosts(ctx, knownHosts)
+
+	var proxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
+	if opt.SSHType.UsesSSH() {
+		if proxyProps, err = readSSHProxyProps(topo, opt); err != nil {
+			return nil, err
+		}
+	}
+	SetSSHProxies(ctx, topo, opt.SSHProxyHost)
+	proxyConfig := sshProxyConfig(opt, proxyProps, knownHosts, false)
+	if err := proxy.MaybeRouteProxy(spec.SSHProxies(topo), proxyConfig, m.logger); err != nil {
+		return nil, err
+	}
+
+	err = SetClusterSSH(ctx, topo, base.User, opt.SSHTimeout, opt.SSHType, topo.BaseTopo().GlobalOptions.SSHType, proxyConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -633,6 +651,10 @@ func (m *Manager) GetClusterTopology(dopt DisplayOption, opt operator.Options) (
 			}
 		}
 
+		if clusterMeta, ok := metadata.(*spec.ClusterMeta); ok && clusterMeta.InMaintenance(ins) {
+			status += " (maintenance)"
+		}
+
 		// check if the role is patched
 		roleName := ins.Role()
 		// get extended name for TiFlash to distinguish disaggregated mode.
@@ -751,8 +773,29 @@ func SetSSHKeySet(ctx context.Context, privateKeyPath string, publicKeyPath stri
 	return nil
 }
 
-// SetClusterSSH set cluster user ssh executor in context.
-func SetClusterSSH(ctx context.Context, topo spec.Topology, deployUser string, sshTimeout uint64, sshType, defaultSSHType executor.SSHType) error {
+// SetSSHKnownHosts set the known_hosts file to verify the host keys with, the
+// host keys are trusted on first use if the file does not exist yet.
+func SetSSHKnownHosts(ctx context.Context, path string) {
+	ctxt.GetInner(ctx).KnownHostsPath = path
+	ctxt.GetInner(ctx).TrustUnknownHosts = !utils.IsExist(path)
+}
+
+// SetSSHProxies set the jump hosts of the hosts of the topology, all hosts are
+// connected through jumpHost if it's set.
+func SetSSHProxies(ctx context.Context, topo spec.Topology, jumpHost string) {
+	proxies := spec.SSHProxies(topo)
+	if jumpHost != "" {
+		topo.IterInstance(func(inst spec.Instance) {
+			proxies[inst.GetManageHost()] = jumpHost
+		})
+	}
+	ctxt.GetInner(ctx).SSHProxies = proxies
+}
+
+// SetClusterSSH set cluster user ssh executor in context, the hosts with jump
+// hosts in the context are connected through them, which are logged in with
+// the user and credentials of proxy.
+func SetClusterSSH(ctx context.Context, topo spec.Topology, deployUser string, sshTimeout uint64, sshType, defaultSSHType executor.SSHType, proxy executor.SSHConfig) error {
 	if sshType == "" {
 		sshType = defaultSSHType
 	}
@@ -768,6 +811,16 @@ func SetClusterSSH(ctx context.Context, topo spec.Topology, deployUser string, s
 				KeyFile: ctxt.GetInner(ctx).PrivateKeyPath,
 				User:    deployUser,
 				Timeout: time.Second * time.Duration(sshTimeout),
+
+				KnownHosts:      ctxt.GetInner(ctx).KnownHostsPath,
+				TrustOnFirstUse: ctxt.GetInner(ctx).TrustUnknownHosts,
+				Pool:            ctxt.GetInner(ctx).SSHPool,
+			}
+			if jump := ctxt.GetInner(ctx).SSHProxies[in.GetManageHost()]; jump != "" {
+				var err error
+				if cf.Proxy, err = executor.ParseProxyJump(jump, proxy); err != nil {
+					return err
+				}
 			}
 
 			e, err := executor.New(sshType, false, cf)
@@ -830,21 +883,21 @@ func (m *Manager) displayDashboards(ctx context.Context, t *spec.Specification,
 			if tlsCfg != nil {
 				scheme = "https"
 			}
-			if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON && j != nil {
+			if m.logger.GetDisplayMode().Structured() && j != nil {
 				j.ClusterMetaInfo.DashboardURL = fmt.Sprintf("%s://%s/dashboard", scheme, addr)
 			} else {
 				fmt.Printf("Dashboard URL:      %s\n", color.CyanString("%s://%s/dashboard", scheme, addr))
 			}
 		}
 
-		if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON && j != nil {
+		if m.logger.GetDisplayMode().Structured() && j != nil {
 			j.ClusterMetaInfo.DashboardURLS = append(j.ClusterMetaInfo.DashboardURLS, fmt.Sprintf("%s://%s/dashboard", scheme, addr))
 		} else {
 			dashboardAddrs[i] = color.CyanString("%s://%s/dashboard", scheme, addr)
 		}
 	}
 
-	if m.logger.GetDisplayMode() != logprinter.DisplayModeJSON || j == nil {
+	if !m.logger.GetDisplayMode().Structured() || j == nil {
 		fmt.Printf("Dashboard URLs:     %s\n", strings.Join(dashboardAddrs, ","))
 	}
 
@@ -0,0 +1,513 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/meta"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// DriftOptions contains the options for detecting drift of a cluster.
+type DriftOptions struct {
+	Adopt bool // adopt the drifted config items into the topology
+}
+
+const (
+	driftInSync  = "in sync"
+	driftChanged = "drifted"
+	driftMissing = "missing"
+	driftPatched = "patched"
+	driftUnknown = "unknown"
+)
+
+// driftItem is a file or binary deployed on the host of an instance, compared
+// with the one expected by the topology.
+type driftItem struct {
+	instance spec.Instance
+	kind     string // config, script, systemd or binary
+	path     string
+	status   string
+	expected string
+	actual   string
+}
+
+// Drift compares the config files, run scripts, systemd units and binaries on
+// the hosts with the ones generated from the topology, and reports the drifted
+// ones. The drifted config items are adopted into the topology if opt.Adopt is set.
+func (m *Manager) Drift(name string, opt DriftOptions, gOpt operator.Options, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	// the generated files are written to a temporary directory instead of
+	// the cache of the cluster
+	cacheDir, err := os.MkdirTemp("", "tiup-drift-")
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	d := &driftDetector{
+		name:      name,
+		base:      base,
+		cacheDir:  cacheDir,
+		checksums: make(map[string]string),
+	}
+
+	roleFilter := set.NewStringSet(gOpt.Roles...)
+	nodeFilter := set.NewStringSet(gOpt.Nodes...)
+	var (
+		mu    sync.Mutex
+		items []*driftItem
+	)
+	b, err := m.sshTaskBuilder(name, topo, base.User, gOpt)
+	if err != nil {
+		return err
+	}
+	t := b.
+		Func("DetectDrift", func(ctx context.Context) error {
+			topo.IterInstance(func(inst spec.Instance) {
+				if (len(gOpt.Roles) > 0 && !roleFilter.Exist(inst.Role())) ||
+					(len(gOpt.Nodes) > 0 && !nodeFilter.Exist(inst.ID())) {
+					return
+				}
+				found, err := d.detect(checkpoint.NewContext(ctx), inst)
+				if err != nil {
+					m.logger.Warnf("Failed to detect drift of %s: %s", inst.ID(), err)
+					found = []*driftItem{{instance: inst, kind: "config", path: "-", status: driftUnknown}}
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				items = append(items, found...)
+			}, gOpt.Concurrency)
+			return nil
+		}).
+		Build()
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].instance.ID() < items[j].instance.ID()
+	})
+	drifted := printDrift(items)
+	if drifted == 0 {
+		m.logger.Infof("No drift is found in cluster `%s`", name)
+		return nil
+	}
+	m.logger.Warnf("%d items of cluster `%s` are drifted", drifted, name)
+
+	if !opt.Adopt {
+		return nil
+	}
+	return m.adoptDrift(name, items, skipConfirm)
+}
+
+// driftDetector generates the expected files of instances and compares them
+// with the ones on the hosts
+type driftDetector struct {
+	name     string
+	base     *spec.BaseMeta
+	cacheDir string
+
+	mu        sync.Mutex
+	checksums map[string]string // checksums of binaries in the local packages
+}
+
+func (d *driftDetector) detect(ctx context.Context, inst spec.Instance) ([]*driftItem, error) {
+	e := ctxt.GetInner(ctx).Get(inst.GetManageHost())
+	deployDir := spec.Abs(d.base.User, inst.DeployDir())
+	paths := meta.DirPaths{
+		Deploy: deployDir,
+		Data:   spec.MultiDirAbs(d.base.User, inst.DataDir()),
+		Log:    spec.Abs(d.base.User, inst.LogDir()),
+		Cache:  filepath.Join(d.cacheDir, inst.ID()),
+	}
+	if err := utils.MkdirAll(paths.Cache, 0755); err != nil {
+		return nil, err
+	}
+
+	capture := &captureExecutor{Executor: e, files: make(map[string][]byte)}
+	if err := inst.InitConfig(ctx, capture, d.name, d.base.Version, d.base.User, paths); err != nil {
+		return nil, perrs.Annotatef(err, "failed to generate config of %s", inst.ID())
+	}
+
+	dsts := make([]string, 0, len(capture.files))
+	for dst := range capture.files {
+		dsts = append(dsts, dst)
+	}
+	sort.Strings(dsts)
+
+	var items []*driftItem
+	for _, dst := range dsts {
+		var kind string
+		switch {
+		case strings.HasPrefix(dst, path.Join(deployDir, "conf")+"/"):
+			kind = "config"
+		case strings.HasPrefix(dst, path.Join(deployDir, "scripts")+"/"):
+			kind = "script"
+		case strings.HasSuffix(dst, ".service") && !strings.HasPrefix(dst, "/tmp/"):
+			kind = "systemd"
+		default:
+			// the files transferred to temporary directories
+			continue
+		}
+
+		item := &driftItem{
+			instance: inst,
+			kind:     kind,
+			path:     dst,
+			expected: string(capture.files[dst]),
+		}
+		stdout, _, err := e.Execute(ctx, "cat "+dst, false)
+		item.actual = string(stdout)
+		switch {
+		case err != nil:
+			item.status = driftMissing
+		case item.actual == item.expected:
+			item.status = driftInSync
+		default:
+			item.status = driftChanged
+		}
+		items = append(items, item)
+	}
+
+	return append(items, d.detectBinary(ctx, e, inst, deployDir)), nil
+}
+
+// detectBinary compares the checksum of the binary on the host with the one in
+// the local package of the instance version.
+func (d *driftDetector) detectBinary(ctx context.Context, e ctxt.Executor, inst spec.Instance, deployDir string) *driftItem {
+	item := &driftItem{
+		instance: inst,
+		kind:     "binary",
+		path:     path.Join(deployDir, "bin"),
+		status:   driftUnknown,
+	}
+
+	version := inst.CalculateVersion(d.base.Version)
+	if utils.Version(version).IsNightly() {
+		version = utils.NightlyVersionAlias
+	}
+	repo, err := clusterutil.NewRepository(inst.OS(), inst.Arch())
+	if err != nil {
+		return item
+	}
+	entry, err := repo.ComponentBinEntry(inst.ComponentSource(), version)
+	if err != nil {
+		return item
+	}
+	item.path = path.Join(deployDir, "bin", entry)
+
+	stdout, _, err := e.Execute(ctx, "sha256sum "+item.path, false)
+	if err != nil {
+		item.status = driftMissing
+		return item
+	}
+	if fields := strings.Fields(string(stdout)); len(fields) > 0 {
+		item.actual = fields[0]
+	}
+
+	if inst.IsPatched() {
+		item.status = driftPatched
+		return item
+	}
+	expected, err := d.packageChecksum(spec.PackagePath(inst.ComponentSource(), version, inst.OS(), inst.Arch()), entry)
+	if err != nil {
+		// the package is not cached locally
+		return item
+	}
+	item.expected = expected
+	if item.actual == item.expected {
+		item.status = driftInSync
+	} else {
+		item.status = driftChanged
+	}
+	return item
+}
+
+// packageChecksum returns the sha256 checksum of the entry in the package
+func (d *driftDetector) packageChecksum(pkg, entry string) (string, error) {
+	key := pkg + ":" + entry
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if sum, ok := d.checksums[key]; ok {
+		return sum, nil
+	}
+
+	f, err := os.Open(pkg)
+	if err != nil {
+		return "", perrs.AddStack(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", perrs.AddStack(err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return "", perrs.Errorf("%s is not found in %s", entry, pkg)
+		}
+		if err != nil {
+			return "", perrs.AddStack(err)
+		}
+		if path.Clean(hdr.Name) != path.Clean(entry) {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", perrs.AddStack(err)
+		}
+		d.checksums[key] = hex.EncodeToString(h.Sum(nil))
+		return d.checksums[key], nil
+	}
+}
+
+// captureExecutor records the files transferred to the host instead of writing
+// them, and skips the commands, so that the files generated by InitConfig can be
+// compared with the ones on the host.
+type captureExecutor struct {
+	ctxt.Executor
+	files map[string][]byte
+}
+
+// Execute implements the ctxt.Executor interface, the files moved by the command
+// are renamed in the captured files.
+func (e *captureExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) == 3 && fields[0] == "mv" {
+		if data, ok := e.files[fields[1]]; ok {
+			delete(e.files, fields[1])
+			e.files[fields[2]] = data
+		}
+	}
+	return nil, nil, nil
+}
+
+// Transfer implements the ctxt.Executor interface, files are downloaded by the
+// underlying executor but not uploaded.
+func (e *captureExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	if download {
+		return e.Executor.Transfer(ctx, src, dst, download, limit, compress)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	e.files[dst] = data
+	return nil
+}
+
+// printDrift prints the status of the items and the differences of the drifted
+// ones, it returns the number of drifted items.
+func printDrift(items []*driftItem) int {
+	rows := [][]string{{"ID", "Role", "Host", "Type", "Path", "Status"}}
+	drifted := 0
+	for _, item := range items {
+		status := item.status
+		switch item.status {
+		case driftChanged:
+			drifted++
+			status = color.YellowString(status)
+		case driftMissing:
+			drifted++
+			status = color.RedString(status)
+		}
+		rows = append(rows, []string{
+			item.instance.ID(),
+			item.instance.Role(),
+			item.instance.GetManageHost(),
+			item.kind,
+			item.path,
+			status,
+		})
+	}
+	tui.PrintTable(rows, true)
+	// the differences are only shown to human readers
+	if logprinter.GetDisplayMode().Structured() {
+		return drifted
+	}
+
+	for _, item := range items {
+		if item.status != driftChanged {
+			continue
+		}
+		fmt.Printf("\n%s %s:\n", color.CyanString(item.instance.ID()), item.path)
+		if item.kind == "binary" {
+			fmt.Printf("expected sha256 %s, found %s\n", item.expected, item.actual)
+			continue
+		}
+		utils.ShowDiff(item.expected, item.actual, os.Stdout)
+	}
+	return drifted
+}
+
+// adoptDrift saves the drifted items of the instance config files into the
+// instance level config in the topology.
+func (m *Manager) adoptDrift(name string, items []*driftItem, skipConfirm bool) error {
+	adoptions := make(map[string]map[string]any)
+	var ids []string
+	for _, item := range items {
+		if item.status != driftChanged {
+			continue
+		}
+		if item.kind != "config" || path.Base(item.path) != item.instance.ComponentName()+".toml" {
+			m.logger.Warnf("The drift of %s %s can't be adopted", item.instance.ID(), item.path)
+			continue
+		}
+
+		changed, removed, err := diffTomlConfig(item.expected, item.actual)
+		if err != nil {
+			m.logger.Warnf("The drift of %s %s can't be adopted: %s", item.instance.ID(), item.path, err)
+			continue
+		}
+		for _, key := range removed {
+			m.logger.Warnf("The removal of %s in %s %s can't be adopted", key, item.instance.ID(), item.path)
+		}
+		if len(changed) > 0 {
+			adoptions[item.instance.ID()] = changed
+			ids = append(ids, item.instance.ID())
+		}
+	}
+	if len(adoptions) == 0 {
+		m.logger.Infof("No drifted config item can be adopted")
+		return nil
+	}
+
+	fmt.Println("\nThe config items to adopt into the topology:")
+	for _, id := range ids {
+		keys := make([]string, 0, len(adoptions[id]))
+		for key := range adoptions[id] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("  %s: %s = %v\n", id, key, adoptions[id][key])
+		}
+	}
+	if !skipConfirm {
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will save the config items into the topology of cluster %s.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(name)),
+		); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// reload the metadata as the topology is changed by generating configs
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	metadata.GetTopology().IterInstance(func(inst spec.Instance) {
+		items, ok := adoptions[inst.ID()]
+		if !ok {
+			return
+		}
+		if u, ok := inst.(interface{ UpdateConfig(map[string]any) bool }); !ok || !u.UpdateConfig(items) {
+			m.logger.Warnf("The config of %s can't be updated", inst.ID())
+		}
+	})
+	if err := m.specManager.SaveMeta(name, metadata); err != nil {
+		return err
+	}
+	m.logger.Infof("Adopted the drifted config of %d instances into the topology", len(ids))
+	return nil
+}
+
+// diffTomlConfig returns the flattened items which are changed or added in
+// actual, and the ones which are removed from expected.
+func diffTomlConfig(expected, actual string) (map[string]any, []string, error) {
+	var expectedConf, actualConf map[string]any
+	if _, err := toml.Decode(expected, &expectedConf); err != nil {
+		return nil, nil, perrs.AddStack(err)
+	}
+	if _, err := toml.Decode(actual, &actualConf); err != nil {
+		return nil, nil, perrs.AddStack(err)
+	}
+	expectedConf = spec.FlattenMap(expectedConf)
+	actualConf = spec.FlattenMap(actualConf)
+
+	changed := make(map[string]any)
+	for key, value := range actualConf {
+		if ev, ok := expectedConf[key]; !ok || !reflect.DeepEqual(ev, value) {
+			changed[key] = value
+		}
+	}
+	var removed []string
+	for key := range expectedConf {
+		if _, ok := actualConf[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed, nil
+}
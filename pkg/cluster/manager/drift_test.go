@@ -0,0 +1,71 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTomlConfig(t *testing.T) {
+	expected := `
+[log]
+level = "info"
+
+[performance]
+max-procs = 4
+txn-total-size-limit = 1024
+`
+	actual := `
+# hot-fixed by hand
+[log]
+level = "debug"
+
+[performance]
+max-procs = 4
+
+[status]
+record-db-qps = true
+`
+	changed, removed, err := diffTomlConfig(expected, actual)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"log.level":            "debug",
+		"status.record-db-qps": true,
+	}, changed)
+	require.Equal(t, []string{"performance.txn-total-size-limit"}, removed)
+
+	_, _, err = diffTomlConfig(expected, "[log")
+	require.Error(t, err)
+}
+
+func TestCaptureExecutor(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "tidb.service")
+	require.NoError(t, os.WriteFile(src, []byte("[Unit]"), 0644))
+
+	e := &captureExecutor{files: make(map[string][]byte)}
+	ctx := context.Background()
+	require.NoError(t, e.Transfer(ctx, src, "/tmp/tidb_1.service", false, 0, false))
+	_, _, err := e.Execute(ctx, "mv /tmp/tidb_1.service /etc/systemd/system/tidb-4000.service", true)
+	require.NoError(t, err)
+	_, _, err = e.Execute(ctx, "chmod +x /home/tidb/deploy/scripts/run_tidb.sh", false)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		"/etc/systemd/system/tidb-4000.service": []byte("[Unit]"),
+	}, e.files)
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/set"
+)
+
+// dryRun prints the steps of t instead of executing them, it is used when
+// a mutating command is run with --dry-run. The extra lines are appended
+// after the steps, they describe what the closures in the task tree do.
+func (m *Manager) dryRun(name, operation string, t task.Task, extra ...string) error {
+	var buf bytes.Buffer
+	if err := task.Plan(&buf, t); err != nil {
+		return err
+	}
+	for _, line := range extra {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+
+	m.logger.Infof("Dry run of %s on cluster %s, nothing will be changed:",
+		color.HiYellowString(operation), color.HiYellowString(name))
+	m.logger.Infof("%s", strings.TrimRight(buf.String(), "\n"))
+	return nil
+}
+
+// instancePlan lists the instances of the components in the order they are
+// operated, filtered by the roles and nodes of the options.
+func instancePlan(action string, comps []spec.Component, opt operator.Options) []string {
+	var lines []string
+	if action != "" {
+		lines = append(lines, fmt.Sprintf("+ %s", action))
+	}
+	nodes := set.NewStringSet(opt.Nodes...)
+	for _, comp := range operator.FilterComponent(comps, set.NewStringSet(opt.Roles...)) {
+		for _, inst := range operator.FilterInstance(comp.Instances(), nodes) {
+			lines = append(lines, fmt.Sprintf("  * %s: host=%s, instance=%s",
+				comp.Name(), inst.GetManageHost(), inst.ID()))
+		}
+	}
+	return lines
+}
@@ -41,6 +41,12 @@ func (m *Manager) EditConfig(name string, opt EditConfigOptions, skipConfirm boo
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) {
 		return err
@@ -151,7 +157,15 @@ func (m *Manager) editTopo(origTopo spec.Topology, data []byte, opt EditConfigOp
 		return nil, nil
 	}
 
-	utils.ShowDiff(string(origData), string(newData), os.Stdout)
+	if m.logger.GetDisplayMode().Structured() {
+		if err := m.logger.Output(struct {
+			Diff []utils.DiffLine `json:"diff"`
+		}{utils.DiffLines(string(origData), string(newData))}); err != nil {
+			return nil, err
+		}
+	} else {
+		utils.ShowDiff(string(origData), string(newData), os.Stdout)
+	}
 
 	if !skipConfirm {
 		if err := tui.PromptForConfirmOrAbortError(
@@ -97,11 +97,12 @@ func (m *Manager) Exec(name string, opt ExecOptions, gOpt operator.Options) erro
 		Parallel(false, shellTasks...).
 		Build()
 
-	execCtx := ctxt.New(
+	execCtx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(execCtx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
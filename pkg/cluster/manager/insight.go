@@ -0,0 +1,97 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+)
+
+// insightTools builds the tasks to copy the insight collector to a tools
+// directory on the hosts and to remove it afterwards
+type insightTools struct {
+	logger *logprinter.Logger
+	dir    string // the tools directory on the hosts
+	user   string
+	sudo   bool
+	archs  map[string]struct{}
+}
+
+// insightBinary returns the path of the insight collector in the tools directory
+func insightBinary(dir string) string {
+	return filepath.Join(dir, "bin", "insight")
+}
+
+func newInsightTools(logger *logprinter.Logger, dir, user string, sudo bool) *insightTools {
+	return &insightTools{
+		logger: logger,
+		dir:    dir,
+		user:   user,
+		sudo:   sudo,
+		archs:  make(map[string]struct{}),
+	}
+}
+
+// download returns the step downloading the insight collector for the
+// platform of the instance, or nil if it is downloaded by a previous step
+func (t *insightTools) download(inst spec.Instance) *task.StepDisplay {
+	arch := fmt.Sprintf("%s/%s", inst.OS(), inst.Arch())
+	if _, found := t.archs[arch]; found {
+		return nil
+	}
+	t.archs[arch] = struct{}{}
+	return task.NewBuilder(t.logger).
+		Download(spec.ComponentCheckCollector, inst.OS(), inst.Arch(), "").
+		BuildAsStep("  - Downloading insight for " + arch)
+}
+
+// install appends the tasks copying the insight collector to the host of the
+// instance to the builder
+func (t *insightTools) install(b *task.Builder, inst spec.Instance) *task.Builder {
+	host := inst.GetManageHost()
+	return b.
+		Mkdir(t.user, host, t.sudo, filepath.Join(t.dir, "bin")).
+		CopyComponent(spec.ComponentCheckCollector, inst.OS(), inst.Arch(), "", "", host, t.dir)
+}
+
+// clean appends the task removing the tools directory on the host to the builder
+func (t *insightTools) clean(b *task.Builder, host string) *task.Builder {
+	return b.Rmdir(host, t.dir)
+}
+
+// steps returns the steps downloading the insight collector for the platforms
+// of the instances, copying it to their hosts and cleaning it up, the hosts
+// are connected by previous tasks
+func (t *insightTools) steps(insts []spec.Instance) (download, install, clean []*task.StepDisplay) {
+	hosts := make(map[string]struct{})
+	for _, inst := range insts {
+		if step := t.download(inst); step != nil {
+			download = append(download, step)
+		}
+		host := inst.GetManageHost()
+		if _, found := hosts[host]; found {
+			continue
+		}
+		hosts[host] = struct{}{}
+		install = append(install, t.install(task.NewBuilder(t.logger), inst).
+			BuildAsStep("  - Copying insight to "+host))
+		clean = append(clean, t.clean(task.NewBuilder(t.logger), host).
+			BuildAsStep("  - Cleaning up insight on "+host))
+	}
+	return download, install, clean
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsightToolsSteps(t *testing.T) {
+	inst := func(host string, port int, arch string) spec.Instance {
+		return &spec.TiDBInstance{BaseInstance: spec.BaseInstance{
+			InstanceSpec: &spec.TiDBSpec{Host: host, Port: port, Arch: arch, OS: "linux"},
+			Host:         host,
+		}}
+	}
+	tools := newInsightTools(logprinter.NewLogger(""), "/tmp/tiup/top", "tidb", true)
+	download, install, clean := tools.steps([]spec.Instance{
+		inst("172.16.5.1", 4000, "amd64"),
+		inst("172.16.5.1", 4001, "amd64"),
+		inst("172.16.5.2", 4000, "arm64"),
+		inst("172.16.5.3", 4000, "amd64"),
+	})
+	assert.Len(t, download, 2)
+	assert.Len(t, install, 3)
+	assert.Len(t, clean, 3)
+
+	// the platforms downloaded by the previous steps are skipped
+	assert.Nil(t, tools.download(inst("172.16.5.4", 4000, "arm64")))
+	assert.Equal(t, "/tmp/tiup/top/bin/insight", insightBinary("/tmp/tiup/top"))
+}
@@ -14,13 +14,10 @@
 package manager
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
-	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
 	"github.com/pingcap/tiup/pkg/tui"
 )
@@ -41,34 +38,29 @@ func (m *Manager) ListCluster() error {
 		return err
 	}
 
-	switch m.logger.GetDisplayMode() {
-	case logprinter.DisplayModeJSON:
+	if m.logger.GetDisplayMode().Structured() {
 		clusterObj := struct {
 			Clusters []Cluster `json:"clusters"`
 		}{
 			Clusters: clusters,
 		}
-		data, err := json.Marshal(clusterObj)
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-	default:
-		clusterTable := [][]string{
-			// Header
-			{"Name", "User", "Version", "Path", "PrivateKey"},
-		}
-		for _, v := range clusters {
-			clusterTable = append(clusterTable, []string{
-				v.Name,
-				v.User,
-				v.Version,
-				v.Path,
-				v.PrivateKey,
-			})
-		}
-		tui.PrintTable(clusterTable, true)
+		return m.logger.Output(clusterObj)
+	}
+
+	clusterTable := [][]string{
+		// Header
+		{"Name", "User", "Version", "Path", "PrivateKey"},
+	}
+	for _, v := range clusters {
+		clusterTable = append(clusterTable, []string{
+			v.Name,
+			v.User,
+			v.Version,
+			v.Path,
+			v.PrivateKey,
+		})
 	}
+	tui.PrintTable(clusterTable, true)
 	return nil
 }
 
@@ -0,0 +1,124 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/tui"
+)
+
+// lockStatus is the lock of a cluster in the structured display modes
+type lockStatus struct {
+	Cluster   string     `json:"cluster"`
+	Locked    bool       `json:"locked"`
+	User      string     `json:"user,omitempty"`
+	PID       int        `json:"pid,omitempty"`
+	Host      string     `json:"host,omitempty"`
+	Command   string     `json:"command,omitempty"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	Stale     bool       `json:"stale,omitempty"`
+}
+
+// lockCluster acquires the lock of the cluster for an operation, nothing is
+// locked in a dry run as the cluster is not changed.
+func (m *Manager) lockCluster(name string, gOpt operator.Options) (func(), error) {
+	if gOpt.DryRun {
+		return func() {}, nil
+	}
+	return m.specManager.Lock(name)
+}
+
+// LockStatus shows the owner of the lock of the cluster.
+func (m *Manager) LockStatus(name string) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	lock, err := m.specManager.LockStatus(name)
+	if err != nil {
+		return err
+	}
+	if m.logger.GetDisplayMode().Structured() {
+		status := lockStatus{Cluster: name, Locked: lock != nil}
+		if lock != nil {
+			status.User = lock.User
+			status.PID = lock.PID
+			status.Host = lock.Host
+			status.Command = lock.Command
+			status.StartTime = &lock.StartTime
+			status.Stale = lock.Stale()
+		}
+		return m.logger.Output(status)
+	}
+	if lock == nil {
+		fmt.Printf("Cluster `%s` is not locked\n", name)
+		return nil
+	}
+
+	stale := "no"
+	if lock.Stale() {
+		stale = color.YellowString("yes (the owner has exited)")
+	}
+	tui.PrintTable([][]string{
+		{"Cluster", name},
+		{"User", lock.User},
+		{"PID", strconv.Itoa(lock.PID)},
+		{"Host", lock.Host},
+		{"Command", lock.Command},
+		{"Start Time", lock.StartTime.Format(time.RFC3339)},
+		{"Stale", stale},
+	}, false)
+	return nil
+}
+
+// BreakLock removes the lock of the cluster, which is left by an operation that
+// has exited abnormally.
+func (m *Manager) BreakLock(name string, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	lock, err := m.specManager.LockStatus(name)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		m.logger.Infof("Cluster `%s` is not locked", name)
+		return nil
+	}
+
+	if !skipConfirm {
+		if !lock.Stale() {
+			m.logger.Warnf("%s", color.HiRedString("The lock may be held by a running operation, breaking it may corrupt the cluster."))
+		}
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will break the lock of cluster %s held by %s.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(name), lock),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := m.specManager.BreakLock(name); err != nil {
+		return err
+	}
+	m.logger.Infof("Lock of cluster `%s` is broken", name)
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockStatusOutput(t *testing.T) {
+	m, _ := newTestManager(t, "test", `
+tidb_servers:
+  - host: 172.16.5.1
+`)
+	buf := &bytes.Buffer{}
+	m.logger.SetDisplayMode(logprinter.DisplayModeJSON)
+	m.logger.SetStdout(buf)
+
+	require.NoError(t, m.LockStatus("test"))
+	require.Equal(t, `{"cluster":"test","locked":false}`+"\n", buf.String())
+
+	unlock, err := m.specManager.Lock("test")
+	require.NoError(t, err)
+	defer unlock()
+	buf.Reset()
+	require.NoError(t, m.LockStatus("test"))
+	var status lockStatus
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &status))
+	require.True(t, status.Locked)
+	require.Equal(t, os.Getpid(), status.PID)
+	require.False(t, status.Stale)
+	require.NotNil(t, status.StartTime)
+}
+
+func TestMetaHistoryOutput(t *testing.T) {
+	m, meta := newTestManager(t, "test", `
+tidb_servers:
+  - host: 172.16.5.1
+`)
+	buf := &bytes.Buffer{}
+	m.logger.SetDisplayMode(logprinter.DisplayModeJSON)
+	m.logger.SetStdout(buf)
+
+	require.NoError(t, m.specManager.SaveMeta("test", meta))
+	require.NoError(t, m.MetaHistory("test"))
+	var history struct {
+		Cluster  string        `json:"cluster"`
+		Versions []metaVersion `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &history))
+	require.Equal(t, "test", history.Cluster)
+	require.NotEmpty(t, history.Versions)
+}
@@ -0,0 +1,379 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// LogsOptions contains the options for showing the logs of the instances.
+type LogsOptions struct {
+	Follow bool          // keep showing the new lines of the logs
+	Grep   string        // only show the lines matching the regular expression
+	Since  time.Duration // only show the lines logged in the duration
+	Tail   int           // the number of the last lines of each instance, negative for all
+}
+
+const (
+	// the interval to poll the new lines of the logs
+	logsPollInterval = time.Second
+
+	// the number of the last lines of each log searched for the pattern of
+	// --grep, unless all the lines are shown
+	logsGrepLines = 100000
+
+	// the time layout of the unified log format of TiDB, TiKV, PD and TiFlash, e.g.
+	// [2025/01/02 15:04:05.123 +08:00] [INFO] [server.go:123] ["message"] [key=value]
+	unifiedLogTimeLayout = "2006/01/02 15:04:05.000 -07:00"
+)
+
+var (
+	unifiedLogTime = regexp.MustCompile(`^\[(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{3} [+-]\d{2}:\d{2})\]`)
+
+	// the instances are shown in different colors
+	logPrefixColors = []color.Attribute{
+		color.FgCyan, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta,
+		color.FgHiCyan, color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta,
+	}
+)
+
+// logEntry is a line in the log of an instance, the following lines without a
+// timestamp, e.g. the stack traces, belong to the entry
+type logEntry struct {
+	Instance string    `json:"instance"`
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message"`
+}
+
+// instanceLog is the log file of an instance being read
+type instanceLog struct {
+	inst    spec.Instance
+	file    string
+	offset  int64     // the size of the file read
+	partial string    // the last line which is not complete yet
+	last    time.Time // the time of the last entry
+}
+
+// Logs shows the lines of the log files of the instances, the lines of all the
+// instances are merged by their timestamps in the unified log format.
+func (m *Manager) Logs(name string, opt LogsOptions, gOpt operator.Options) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	var pattern *regexp.Regexp
+	if opt.Grep != "" {
+		var err error
+		if pattern, err = regexp.Compile(opt.Grep); err != nil {
+			return perrs.Annotatef(err, "invalid pattern '%s'", opt.Grep)
+		}
+	}
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	roleFilter := set.NewStringSet(gOpt.Roles...)
+	nodeFilter := set.NewStringSet(gOpt.Nodes...)
+	var logs []*instanceLog
+	topo.IterInstance(func(inst spec.Instance) {
+		if len(roleFilter) > 0 && !roleFilter.Exist(inst.Role()) {
+			return
+		}
+		if len(nodeFilter) > 0 && !nodeFilter.Exist(inst.ID()) {
+			return
+		}
+		logs = append(logs, &instanceLog{
+			inst: inst,
+			file: filepath.Join(inst.LogDir(), logFileName(inst.ComponentName())),
+		})
+	})
+	if len(logs) == 0 {
+		return perrs.Errorf("no instance of cluster '%s' matches the role and node filters", name)
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].inst.ID() < logs[j].inst.ID()
+	})
+
+	b, err := m.sshTaskBuilder(name, topo, base.User, gOpt)
+	if err != nil {
+		return err
+	}
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := b.Build().Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+
+	p := newLogPrinter(logs)
+	var since time.Time
+	if opt.Since > 0 {
+		since = time.Now().Add(-opt.Since)
+	}
+	// the lines are matched with the pattern locally, so the lines searched
+	// are read from the hosts
+	lines := opt.Tail
+	if pattern != nil && opt.Tail >= 0 {
+		lines = max(opt.Tail, logsGrepLines)
+	}
+	entries := m.readLogs(ctx, logs, gOpt.Concurrency, func(l *instanceLog) string {
+		return l.tailCommand(since, lines)
+	}, pattern, opt.Tail)
+	if m.logger.GetDisplayMode().Structured() && !opt.Follow {
+		return m.logger.Output(entries)
+	}
+	p.print(m, entries)
+	if !opt.Follow {
+		return nil
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigCtx.Done():
+			return nil
+		case <-ticker.C:
+			p.print(m, m.readLogs(ctx, logs, gOpt.Concurrency, (*instanceLog).followCommand, pattern, -1))
+		}
+	}
+}
+
+// logFileName returns the name of the log file of the component in its log
+// directory
+func logFileName(component string) string {
+	if component == spec.ComponentDashboard {
+		return "tidb_dashboard.log"
+	}
+	return component + ".log"
+}
+
+// readLogs runs the command on the hosts of the instances and returns the new
+// entries of all the instances merged by the time, the lines are filtered by
+// the pattern if it's not nil, and the last tail lines of each instance are
+// kept if tail is not negative
+func (m *Manager) readLogs(ctx context.Context, logs []*instanceLog, concurrency int, cmd func(*instanceLog) string, pattern *regexp.Regexp, tail int) []logEntry {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		entries = make([]logEntry, 0)
+	)
+	limiter := make(chan struct{}, max(concurrency, 1))
+	for _, l := range logs {
+		wg.Add(1)
+		go func(l *instanceLog) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			lines, err := l.read(ctx, cmd(l))
+			if err != nil {
+				m.logger.Warnf("Failed to read the log of %s: %s", l.inst.ID(), err)
+				return
+			}
+			if pattern != nil {
+				lines = filterLines(lines, pattern)
+			}
+			if tail >= 0 && len(lines) > tail {
+				lines = lines[len(lines)-tail:]
+			}
+			var found []logEntry
+			found, l.last = parseLogEntries(l.inst.ID(), lines, l.last)
+			mu.Lock()
+			entries = append(entries, found...)
+			mu.Unlock()
+		}(l)
+	}
+	wg.Wait()
+	return mergeLogEntries(entries)
+}
+
+// read runs the command printing the size of the log file in the first line
+// and the content after it, and returns the complete lines
+func (l *instanceLog) read(ctx context.Context, cmd string) ([]string, error) {
+	e, found := ctxt.GetInner(ctx).GetExecutor(l.inst.GetManageHost())
+	if !found {
+		return nil, perrs.Errorf("no executor for host %s", l.inst.GetManageHost())
+	}
+	stdout, stderr, err := e.Execute(checkpoint.NewContext(ctx), cmd, false)
+	if err != nil {
+		// the size is printed before the content, it's still recorded so that
+		// the content is not read again by the next read
+		if size, _, serr := parseLogOutput(stdout); serr == nil {
+			l.offset, l.partial = size, ""
+		}
+		return nil, perrs.Annotatef(err, "%s", bytes.TrimSpace(stderr))
+	}
+	return l.consume(stdout)
+}
+
+// parseLogOutput splits the output of the commands reading the log file into
+// the size of the file and the content
+func parseLogOutput(stdout []byte) (int64, string, error) {
+	sizeLine, content, _ := strings.Cut(string(stdout), "\n")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeLine), 10, 64)
+	if err != nil {
+		return 0, "", perrs.Errorf("unexpected output: %s", sizeLine)
+	}
+	return size, content, nil
+}
+
+// consume parses the output of the commands reading the log file, and keeps
+// the last line in the partial if it's not complete
+func (l *instanceLog) consume(stdout []byte) ([]string, error) {
+	size, content, err := parseLogOutput(stdout)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to read the log %s", l.file)
+	}
+	if size < l.offset {
+		// the log file is rotated, it's read from the beginning next time
+		l.offset, l.partial = 0, ""
+		return nil, nil
+	}
+	l.offset = size
+
+	content = l.partial + content
+	lines := strings.Split(content, "\n")
+	l.partial = lines[len(lines)-1]
+	return lines[:len(lines)-1], nil
+}
+
+// tailCommand returns the command to print the size of the log file and the
+// last lines since the time, all the lines if tail is negative
+func (l *instanceLog) tailCommand(since time.Time, tail int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "f=%s; s=$(stat -c %%s \"$f\") || exit 1; echo \"$s\"; head -c \"$s\" \"$f\"", utils.ShellQuote(l.file))
+	if !since.IsZero() {
+		b.WriteString(" | " + unifiedLogFilter(since))
+	}
+	if tail >= 0 {
+		fmt.Fprintf(&b, " | tail -n %d", tail)
+	}
+	return b.String()
+}
+
+// followCommand returns the command to print the size of the log file and the
+// content written after the last read
+func (l *instanceLog) followCommand() string {
+	return fmt.Sprintf(
+		"f=%s; s=$(stat -c %%s \"$f\") || exit 1; echo \"$s\"; if [ \"$s\" -gt %[2]d ]; then tail -c +%d \"$f\" | head -c $((s - %[2]d)); fi",
+		utils.ShellQuote(l.file), l.offset, l.offset+1,
+	)
+}
+
+func filterLines(lines []string, pattern *regexp.Regexp) []string {
+	matched := lines[:0]
+	for _, line := range lines {
+		if pattern.MatchString(line) {
+			matched = append(matched, line)
+		}
+	}
+	return matched
+}
+
+// parseLogEntries parses the lines into entries, the lines without a timestamp
+// are appended to the previous entry, or the time of the last entry is used if
+// there is no previous one. It returns the time of the last entry.
+func parseLogEntries(id string, lines []string, last time.Time) ([]logEntry, time.Time) {
+	var entries []logEntry
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if m := unifiedLogTime.FindStringSubmatch(line); m != nil {
+			if t, err := time.Parse(unifiedLogTimeLayout, m[1]); err == nil {
+				last = t
+				entries = append(entries, logEntry{Instance: id, Time: t, Message: line})
+				continue
+			}
+		}
+		if len(entries) > 0 && !entries[len(entries)-1].Time.IsZero() {
+			entries[len(entries)-1].Message += "\n" + line
+			continue
+		}
+		entries = append(entries, logEntry{Instance: id, Time: last, Message: line})
+	}
+	return entries, last
+}
+
+// mergeLogEntries sorts the entries of all the instances by the time, the
+// entries of the same instance keep their order
+func mergeLogEntries(entries []logEntry) []logEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// logPrinter prints the lines of the entries prefixed with the instance IDs
+type logPrinter struct {
+	width  int
+	colors map[string]*color.Color
+}
+
+func newLogPrinter(logs []*instanceLog) *logPrinter {
+	p := &logPrinter{colors: make(map[string]*color.Color)}
+	for i, l := range logs {
+		p.width = max(p.width, len(l.inst.ID()))
+		p.colors[l.inst.ID()] = color.New(logPrefixColors[i%len(logPrefixColors)])
+	}
+	return p
+}
+
+func (p *logPrinter) print(m *Manager, entries []logEntry) {
+	for _, e := range entries {
+		if m.logger.GetDisplayMode().Structured() {
+			_ = m.logger.Output(e)
+			continue
+		}
+		prefix := p.colors[e.Instance].Sprintf("%-*s |", p.width, e.Instance)
+		for _, line := range strings.Split(e.Message, "\n") {
+			fmt.Printf("%s %s\n", prefix, line)
+		}
+	}
+}
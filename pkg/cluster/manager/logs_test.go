@@ -0,0 +1,161 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseLogEntries(t *testing.T) {
+	lines := []string{
+		"goroutine 1 [running]:",
+		"[2025/01/02 15:04:05.123 +08:00] [INFO] [server.go:100] [\"start\"]",
+		"[2025/01/02 15:04:06.000 +08:00] [ERROR] [server.go:200] [\"panic\"]",
+		"goroutine 2 [running]:",
+	}
+	last := time.Date(2025, 1, 2, 7, 4, 0, 0, time.UTC)
+	entries, last := parseLogEntries("tidb-1", lines, last)
+	require.Len(t, entries, 3)
+	require.Equal(t, lines[0], entries[0].Message)
+	require.True(t, entries[0].Time.Equal(time.Date(2025, 1, 2, 7, 4, 0, 0, time.UTC)))
+	require.Equal(t, lines[2]+"\n"+lines[3], entries[2].Message)
+	require.True(t, last.Equal(time.Date(2025, 1, 2, 7, 4, 6, 0, time.UTC)))
+
+	// the entries of the instances in different time zones are merged by time
+	other, _ := parseLogEntries("tikv-1", []string{
+		"[2025/01/02 07:04:05.500 +00:00] [WARN] [store.rs:10] [\"slow\"]",
+	}, time.Time{})
+	merged := mergeLogEntries(append(entries, other...))
+	require.Equal(t, "tikv-1", merged[2].Instance)
+	require.Equal(t, "tidb-1", merged[3].Instance)
+
+	require.Equal(t, []string{"b", "ab"}, filterLines([]string{"a", "b", "ab"}, regexp.MustCompile("b")))
+}
+
+func TestLogsCommands(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	run := func(cmd string) []byte {
+		out, err := exec.Command("bash", "-c", cmd).Output()
+		require.NoError(t, err)
+		return out
+	}
+
+	file := filepath.Join(t.TempDir(), "it's.log")
+	now := time.Now()
+	content := "[" + now.Add(-2*time.Hour).Format(unifiedLogTimeLayout) + "] [INFO] old\n" +
+		"[" + now.Add(-time.Minute).Format(unifiedLogTimeLayout) + "] [INFO] new\n" +
+		"stack of new\n" +
+		"[" + now.Format(unifiedLogTimeLayout) + "] [WARN] newest\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	l := &instanceLog{file: file}
+	lines, err := l.consume(run(l.tailCommand(time.Time{}, 1)))
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], "newest")
+	require.Equal(t, int64(len(content)), l.offset)
+
+	l = &instanceLog{file: file}
+	lines, err = l.consume(run(l.tailCommand(now.Add(-time.Hour), -1)))
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	require.Equal(t, "stack of new", lines[1])
+
+	// the new content is read after the last read, the incomplete line is kept
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("line 1\nline")
+	require.NoError(t, err)
+	lines, err = l.consume(run(l.followCommand()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"line 1"}, lines)
+	_, err = f.WriteString(" 2\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	lines, err = l.consume(run(l.followCommand()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"line 2"}, lines)
+	lines, err = l.consume(run(l.followCommand()))
+	require.NoError(t, err)
+	require.Empty(t, lines)
+
+	// the log file is read from the beginning after it's rotated
+	require.NoError(t, os.WriteFile(file, []byte("rotated\n"), 0644))
+	lines, err = l.consume(run(l.followCommand()))
+	require.NoError(t, err)
+	require.Empty(t, lines)
+	lines, err = l.consume(run(l.followCommand()))
+	require.NoError(t, err)
+	require.Equal(t, []string{"rotated"}, lines)
+}
+
+func TestReadLogsGrep(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	current, err := user.Current()
+	require.NoError(t, err)
+	topo := &spec.Specification{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tidb_servers:
+  - host: 127.0.0.1
+`), topo))
+
+	file := filepath.Join(t.TempDir(), "tidb.log")
+	content := "[2025/01/02 15:04:05.123 +08:00] [INFO] [server.go:100] [\"a\\\\d\"]\n" +
+		"[2025/01/02 15:04:06.000 +08:00] [WARN] [server.go:100] [\"a1\"]\n" +
+		"[2025/01/02 15:04:07.000 +08:00] [WARN] [server.go:100] [\"a2\"]\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	ctx := ctxt.New(context.Background(), 0, logprinter.NewLogger(""))
+	ctxt.GetInner(ctx).SetExecutor("127.0.0.1", &executor.Local{Config: &executor.SSHConfig{Host: "127.0.0.1", User: current.Username}})
+	m := NewManager("tidb", nil, logprinter.NewLogger(""))
+	read := func(pattern string, tail int) []logEntry {
+		var inst spec.Instance
+		topo.IterInstance(func(i spec.Instance) { inst = i })
+		l := &instanceLog{inst: inst, file: file}
+		return m.readLogs(ctx, []*instanceLog{l}, 1, func(l *instanceLog) string {
+			return l.tailCommand(time.Time{}, -1)
+		}, regexp.MustCompile(pattern), tail)
+	}
+
+	// the pattern is matched as a Go regular expression, e.g. \d is a digit
+	entries := read(`a\d`, -1)
+	require.Len(t, entries, 2)
+	require.Contains(t, entries[0].Message, "a1")
+
+	// the last lines matching the pattern are kept
+	entries = read(`a\d`, 1)
+	require.Len(t, entries, 1)
+	require.Contains(t, entries[0].Message, "a2")
+
+	// no matched line is not a failure
+	require.Empty(t, read("ERROR", -1))
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+var (
+	errNSMaintenance = errorx.NewNamespace("maintenance")
+	errInMaintenance = errNSMaintenance.NewType("in_maintenance", utils.ErrTraitPreCheck)
+)
+
+// EnterMaintenance drains and stops the instances on the host, and marks the
+// host as under maintenance in the meta of the cluster. The PD and TiKV leaders
+// are evicted and the TiCDC captures are drained before the instances are stopped,
+// the TiDB and TiProxy servers are stopped first so they are taken out of rotation
+// by their graceful shutdown before the storage is touched.
+func (m *Manager) EnterMaintenance(name, host string, gOpt operator.Options, skipConfirm bool) error {
+	return m.maintenance(name, host, true, gOpt, skipConfirm)
+}
+
+// ExitMaintenance starts the instances on the host under maintenance, removes the
+// evict leader schedulers of the stores, and unmarks the host.
+func (m *Manager) ExitMaintenance(name, host string, gOpt operator.Options, skipConfirm bool) error {
+	return m.maintenance(name, host, false, gOpt, skipConfirm)
+}
+
+func (m *Manager) maintenance(name, host string, enter bool, gOpt operator.Options, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	// check locked
+	if err := m.specManager.ScaleOutLockedErr(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	clusterMeta, ok := metadata.(*spec.ClusterMeta)
+	if !ok {
+		return perrs.Errorf("maintenance is not supported for %s clusters", m.sysName)
+	}
+	topo := clusterMeta.Topology
+
+	inMaintenance := slices.Contains(clusterMeta.MaintenanceHosts, host)
+	switch {
+	case enter && inMaintenance:
+		return perrs.Errorf("host %s of cluster %s is already under maintenance", host, name)
+	case !enter && !inMaintenance:
+		return perrs.Errorf("host %s of cluster %s is not under maintenance", host, name)
+	}
+
+	var nodes []string
+	topo.IterInstance(func(inst spec.Instance) {
+		if inst.GetHost() == host || inst.GetManageHost() == host {
+			nodes = append(nodes, inst.ID())
+		}
+	})
+	if enter && len(nodes) == 0 {
+		return perrs.Errorf("no instance of cluster %s is deployed on host %s", name, host)
+	}
+
+	if !skipConfirm {
+		action := "drain and stop"
+		if !enter {
+			action = "start"
+		}
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will %s the instances %s on host %s of cluster %s.\nDo you want to continue? [y/N]:",
+				action,
+				color.HiYellowString(strings.Join(nodes, ",")),
+				color.HiYellowString(host),
+				color.HiYellowString(name),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
+	if err != nil {
+		return err
+	}
+
+	gOpt.Roles = nil
+	gOpt.Nodes = nodes
+	b, err := m.sshTaskBuilder(name, topo, clusterMeta.User, gOpt)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		if enter {
+			b.Func("EnterMaintenance", func(ctx context.Context) error {
+				return operator.Stop(ctx, topo, gOpt, true, tlsCfg)
+			})
+		} else {
+			b.Func("ExitMaintenance", func(ctx context.Context) error {
+				return operator.Start(ctx, topo, gOpt, true, tlsCfg)
+			})
+		}
+	}
+	t := b.Build()
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+
+	if enter {
+		clusterMeta.MaintenanceHosts = append(clusterMeta.MaintenanceHosts, host)
+	} else {
+		clusterMeta.MaintenanceHosts = slices.DeleteFunc(clusterMeta.MaintenanceHosts, func(h string) bool {
+			return h == host
+		})
+	}
+	if err := m.specManager.SaveMeta(name, clusterMeta); err != nil {
+		return err
+	}
+
+	if enter {
+		m.logger.Infof("Host %s of cluster `%s` is under maintenance", host, name)
+	} else {
+		m.logger.Infof("Host %s of cluster `%s` exits maintenance", host, name)
+	}
+	return nil
+}
+
+// checkMaintenance returns an error if any instance selected by the roles and
+// nodes of options is on a host under maintenance, as the operation would start
+// the drained instance again, unless the operation is forced.
+func checkMaintenance(name string, metadata spec.Metadata, gOpt operator.Options) error {
+	clusterMeta, ok := metadata.(*spec.ClusterMeta)
+	if !ok || len(clusterMeta.MaintenanceHosts) == 0 || gOpt.Force {
+		return nil
+	}
+
+	roleFilter := set.NewStringSet(gOpt.Roles...)
+	nodeFilter := set.NewStringSet(gOpt.Nodes...)
+	var nodes []string
+	clusterMeta.Topology.IterInstance(func(inst spec.Instance) {
+		if len(roleFilter) > 0 && !roleFilter.Exist(inst.Role()) {
+			return
+		}
+		if len(nodeFilter) > 0 && !nodeFilter.Exist(inst.ID()) {
+			return
+		}
+		if clusterMeta.InMaintenance(inst) {
+			nodes = append(nodes, inst.ID())
+		}
+	})
+	if len(nodes) == 0 {
+		return nil
+	}
+	return errInMaintenance.New("Instances %s of cluster `%s` are on the hosts under maintenance", strings.Join(nodes, ","), name).
+		WithProperty(tui.SuggestionFromFormat("Please run `%s maintenance exit %s --host <host>` first, exclude the instances with -R/-N, or use --force to start them anyway.",
+			tui.OsArgs0(), name))
+}
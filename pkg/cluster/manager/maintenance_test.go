@@ -0,0 +1,181 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/localdata"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// the executor type of the fake hosts in the tests
+const sshTypeFake executor.SSHType = "fake"
+
+var systemctlUnit = regexp.MustCompile(`systemctl (start|stop|restart) [\w-]+-(\d+)\.service`)
+
+// fakeHosts simulates the systemd services of the instances on the hosts, the
+// ports of the started services are listened
+type fakeHosts struct {
+	mu        sync.Mutex
+	listening map[string]map[int]bool // by host
+	commands  map[string][]string     // by host
+}
+
+var hosts = &fakeHosts{}
+
+func init() {
+	executor.Register(sshTypeFake, func(sudo bool, c *executor.SSHConfig) (ctxt.Executor, error) {
+		return &fakeExecutor{host: c.Host}, nil
+	}, true)
+}
+
+// reset clears the hosts, the ports of the instances are listened at first
+func (h *fakeHosts) reset(topo spec.Topology) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listening = make(map[string]map[int]bool)
+	h.commands = make(map[string][]string)
+	topo.IterInstance(func(inst spec.Instance) {
+		if h.listening[inst.GetManageHost()] == nil {
+			h.listening[inst.GetManageHost()] = make(map[int]bool)
+		}
+		h.listening[inst.GetManageHost()][inst.GetPort()] = true
+	})
+}
+
+func (h *fakeHosts) listened(host string, port int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.listening[host][port]
+}
+
+type fakeExecutor struct {
+	host string
+}
+
+// Execute implements Executor interface.
+func (e *fakeExecutor) Execute(ctx context.Context, cmd string, sudo bool, timeout ...time.Duration) ([]byte, []byte, error) {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	hosts.commands[e.host] = append(hosts.commands[e.host], cmd)
+	if hosts.listening[e.host] == nil {
+		hosts.listening[e.host] = make(map[int]bool)
+	}
+	for _, m := range systemctlUnit.FindAllStringSubmatch(cmd, -1) {
+		port, _ := strconv.Atoi(m[2])
+		hosts.listening[e.host][port] = m[1] != "stop"
+	}
+	if cmd == "ss -ltn" {
+		out := "State Recv-Q Send-Q Local Address:Port Peer Address:Port\n"
+		for port, ok := range hosts.listening[e.host] {
+			if ok {
+				out += fmt.Sprintf("LISTEN 0 128 0.0.0.0:%d 0.0.0.0:*\n", port)
+			}
+		}
+		return []byte(out), nil, nil
+	}
+	return nil, nil, nil
+}
+
+// Transfer implements Executer interface.
+func (e *fakeExecutor) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	return nil
+}
+
+// newTestManager returns the manager of the cluster saved from the topology in
+// a new profile directory, the hosts of the cluster are faked
+func newTestManager(t *testing.T, name, topology string) (*Manager, *spec.ClusterMeta) {
+	topo := &spec.Specification{}
+	require.NoError(t, yaml.Unmarshal([]byte(topology), topo))
+	meta := &spec.ClusterMeta{User: "tidb", Version: "v8.1.0", Topology: topo}
+
+	t.Setenv(localdata.EnvNameComponentDataDir, t.TempDir())
+	require.NoError(t, spec.Initialize("cluster"))
+	specManager := spec.GetSpecManager()
+	require.NoError(t, specManager.SaveMeta(name, meta))
+	require.NoError(t, os.MkdirAll(specManager.Path(name, "ssh"), 0700))
+	for _, key := range []string{"id_rsa", "id_rsa.pub"} {
+		require.NoError(t, os.WriteFile(specManager.Path(name, "ssh", key), []byte(key), 0600))
+	}
+	hosts.reset(topo)
+	return NewManager("tidb", specManager, logprinter.NewLogger("")), meta
+}
+
+func testOptions() operator.Options {
+	return operator.Options{SSHType: sshTypeFake, Concurrency: 1, OptTimeout: 1, APITimeout: 1}
+}
+
+func TestMaintenance(t *testing.T) {
+	m, _ := newTestManager(t, "test", `
+tidb_servers:
+  - host: 172.16.5.1
+  - host: 172.16.5.2
+grafana_servers:
+  - host: 172.16.5.1
+`)
+	gOpt := testOptions()
+
+	require.Error(t, m.EnterMaintenance("test", "172.16.5.3", gOpt, true))
+	require.Error(t, m.ExitMaintenance("test", "172.16.5.1", gOpt, true))
+
+	// the instances on the host are stopped
+	require.NoError(t, m.EnterMaintenance("test", "172.16.5.1", gOpt, true))
+	require.False(t, hosts.listened("172.16.5.1", 4000))
+	require.False(t, hosts.listened("172.16.5.1", 3000))
+	require.True(t, hosts.listened("172.16.5.2", 4000))
+	metadata, err := m.meta("test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"172.16.5.1"}, metadata.(*spec.ClusterMeta).MaintenanceHosts)
+	require.Error(t, m.EnterMaintenance("test", "172.16.5.1", gOpt, true))
+
+	// the instances under maintenance are not started by other operations
+	err = checkMaintenance("test", metadata, gOpt)
+	require.True(t, errorx.IsOfType(err, errInMaintenance))
+	require.Contains(t, err.Error(), "172.16.5.1:4000,172.16.5.1:3000")
+	nOpt := gOpt
+	nOpt.Nodes = []string{"172.16.5.2:4000"}
+	require.NoError(t, checkMaintenance("test", metadata, nOpt))
+	nOpt = gOpt
+	nOpt.Force = true
+	require.NoError(t, checkMaintenance("test", metadata, nOpt))
+	require.True(t, errorx.IsOfType(m.RestartCluster("test", gOpt, true), errInMaintenance))
+	require.False(t, hosts.listened("172.16.5.1", 4000))
+	require.True(t, errorx.IsOfType(m.StartCluster("test", gOpt, false), errInMaintenance))
+	require.False(t, hosts.listened("172.16.5.1", 4000))
+	require.False(t, hosts.listened("172.16.5.1", 3000))
+
+	// the instances are started after the host exits maintenance
+	require.NoError(t, m.ExitMaintenance("test", "172.16.5.1", gOpt, true))
+	require.True(t, hosts.listened("172.16.5.1", 4000))
+	require.True(t, hosts.listened("172.16.5.1", 3000))
+	metadata, err = m.meta("test")
+	require.NoError(t, err)
+	require.Empty(t, metadata.(*spec.ClusterMeta).MaintenanceHosts)
+	require.NoError(t, checkMaintenance("test", metadata, gOpt))
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/joomcode/errorx"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/cluster/task"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/proxy"
 	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
@@ -149,19 +151,32 @@ You may read the OpenJDK doc for a reference: https://openjdk.java.net/install/
 }
 
 func (m *Manager) sshTaskBuilder(name string, topo spec.Topology, user string, gOpt operator.Options) (*task.Builder, error) {
+	return m.trustedSSHTaskBuilder(name, topo, user, gOpt, false)
+}
+
+// trustedSSHTaskBuilder is the sshTaskBuilder that trusts the host keys of the
+// hosts not recorded in the known_hosts file of the cluster if trust is set
+func (m *Manager) trustedSSHTaskBuilder(name string, topo spec.Topology, user string, gOpt operator.Options, trust bool) (*task.Builder, error) {
 	var p *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
-	if gOpt.SSHType != executor.SSHTypeNone && len(gOpt.SSHProxyHost) != 0 {
+	if gOpt.SSHType.UsesSSH() {
 		var err error
-		if p, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
+		if p, err = readSSHProxyProps(topo, gOpt); err != nil {
 			return nil, err
 		}
 	}
+	// the API requests to the hosts go through their jump hosts as well
+	knownHosts := m.specManager.Path(name, "ssh", "known_hosts")
+	if err := proxy.MaybeRouteProxy(spec.SSHProxies(topo), sshProxyConfig(gOpt, p, knownHosts, trust), m.logger); err != nil {
+		return nil, err
+	}
 
 	return task.NewBuilder(m.logger).
 		SSHKeySet(
 			m.specManager.Path(name, "ssh", "id_rsa"),
 			m.specManager.Path(name, "ssh", "id_rsa.pub"),
 		).
+		SSHKnownHosts(knownHosts, trust).
+		SSHProxies(spec.SSHProxies(topo)).
 		ClusterSSH(
 			topo,
 			user,
@@ -173,12 +188,39 @@ func (m *Manager) sshTaskBuilder(name string, topo spec.Topology, user string, g
 			p.Password,
 			p.IdentityFile,
 			p.IdentityFilePassphrase,
+			p.SSHAgent,
 			gOpt.SSHProxyTimeout,
 			gOpt.SSHType,
 			topo.BaseTopo().GlobalOptions.SSHType,
 		), nil
 }
 
+// readSSHProxyProps reads the identity file or password of the jump hosts if
+// the hosts of the topology are connected through any
+func readSSHProxyProps(topo spec.Topology, gOpt operator.Options) (*tui.SSHConnectionProps, error) {
+	if len(gOpt.SSHProxyHost) == 0 && !topo.BaseTopo().GlobalOptions.HasSSHProxy() {
+		return &tui.SSHConnectionProps{}, nil
+	}
+	return tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword)
+}
+
+// sshProxyConfig returns the config to login to the jump hosts with, the host
+// keys of the jump hosts are verified with the known_hosts file, the unknown
+// ones are trusted if trust is set or the file doesn't exist yet
+func sshProxyConfig(gOpt operator.Options, p *tui.SSHConnectionProps, knownHosts string, trust bool) executor.SSHConfig {
+	return executor.SSHConfig{
+		Port:            gOpt.SSHProxyPort,
+		User:            gOpt.SSHProxyUser,
+		Password:        p.Password,
+		KeyFile:         p.IdentityFile,
+		Passphrase:      p.IdentityFilePassphrase,
+		SSHAgent:        p.SSHAgent,
+		Timeout:         time.Second * time.Duration(gOpt.SSHProxyTimeout),
+		KnownHosts:      knownHosts,
+		TrustOnFirstUse: trust || !utils.IsExist(knownHosts),
+	}
+}
+
 // fillHost full host cpu-arch and kernel-name
 func (m *Manager) fillHost(s, p *tui.SSHConnectionProps, topo spec.Topology, gOpt *operator.Options, user string, sudo bool) error {
 	if err := m.fillHostArchOrOS(s, p, topo, gOpt, user, spec.FullArchType, sudo); err != nil {
@@ -216,6 +258,7 @@ func (m *Manager) fillHostArchOrOS(s, p *tui.SSHConnectionProps, topo spec.Topol
 				s.Password,
 				s.IdentityFile,
 				s.IdentityFilePassphrase,
+				s.SSHAgent,
 				gOpt.SSHTimeout,
 				gOpt.OptTimeout,
 				gOpt.SSHProxyHost,
@@ -224,6 +267,7 @@ func (m *Manager) fillHostArchOrOS(s, p *tui.SSHConnectionProps, topo spec.Topol
 				p.Password,
 				p.IdentityFile,
 				p.IdentityFilePassphrase,
+				p.SSHAgent,
 				gOpt.SSHProxyTimeout,
 				gOpt.SSHType,
 				globalSSHType,
@@ -242,12 +286,14 @@ func (m *Manager) fillHostArchOrOS(s, p *tui.SSHConnectionProps, topo spec.Topol
 		return nil
 	}
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	t := task.NewBuilder(m.logger).
+		SSHProxies(spec.SSHProxies(topo)).
 		ParallelStep(fmt.Sprintf("+ Detect CPU %s Name", string(fullType)), false, detectTasks...).
 		Build()
 
@@ -14,9 +14,13 @@
 package manager
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -122,3 +126,19 @@ func TestDeduplicateCheckResult(t *testing.T) {
 		t.Errorf("Deduplicate Check Result Failed")
 	}
 }
+
+func TestSSHProxyConfig(t *testing.T) {
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	gOpt := operator.Options{SSHProxyPort: 22, SSHProxyUser: "tidb", SSHProxyTimeout: 5}
+	props := &tui.SSHConnectionProps{IdentityFile: "id_rsa"}
+
+	// the host keys of the jump hosts are trusted until they are recorded
+	c := sshProxyConfig(gOpt, props, knownHosts, false)
+	require.Equal(t, knownHosts, c.KnownHosts)
+	require.True(t, c.TrustOnFirstUse)
+	require.Equal(t, "id_rsa", c.KeyFile)
+
+	require.NoError(t, os.WriteFile(knownHosts, nil, 0600))
+	require.False(t, sshProxyConfig(gOpt, props, knownHosts, false).TrustOnFirstUse)
+	require.True(t, sshProxyConfig(gOpt, props, knownHosts, true).TrustOnFirstUse)
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// metaVersion is a version of the meta of a cluster in the structured display
+// modes
+type metaVersion struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	AuditID string    `json:"audit_id,omitempty"`
+	Command string    `json:"command,omitempty"`
+}
+
+// MetaHistory shows the versions of the meta of the cluster and the commands
+// which saved them.
+func (m *Manager) MetaHistory(name string) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	history, err := m.specManager.MetaHistory(name)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]metaVersion, 0, len(history))
+	for _, snapshot := range history {
+		v := metaVersion{Version: snapshot.Version, Time: snapshot.Time, AuditID: snapshot.AuditID}
+		if snapshot.AuditID != "" {
+			if args, err := audit.CommandArgs(filepath.Join(spec.AuditDir(), snapshot.AuditID)); err == nil {
+				v.Command = strings.Join(args, " ")
+			}
+		}
+		versions = append(versions, v)
+	}
+	if m.logger.GetDisplayMode().Structured() {
+		return m.logger.Output(struct {
+			Cluster  string        `json:"cluster"`
+			Versions []metaVersion `json:"versions"`
+		}{name, versions})
+	}
+	if len(versions) == 0 {
+		fmt.Printf("There is no history of the meta of cluster `%s`\n", name)
+		return nil
+	}
+
+	rows := [][]string{{"Version", "Time", "Audit ID", "Command"}}
+	for _, v := range versions {
+		command := v.Command
+		if command == "" {
+			command = "-"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(v.Version),
+			v.Time.Format(time.RFC3339),
+			v.AuditID,
+			command,
+		})
+	}
+	tui.PrintTable(rows, true)
+	return nil
+}
+
+// MetaDiff shows the difference between two versions of the meta of the
+// cluster, the current meta is used if v2 is empty.
+func (m *Manager) MetaDiff(name, v1, v2 string) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	data1, err := m.metaSnapshot(name, v1)
+	if err != nil {
+		return err
+	}
+	data2, err := m.metaSnapshot(name, v2)
+	if err != nil {
+		return err
+	}
+
+	utils.ShowDiff(string(data1), string(data2), os.Stdout)
+	return nil
+}
+
+// RollbackMeta saves a previous version of the meta as the current meta of the
+// cluster, the instances of the cluster are not changed.
+func (m *Manager) RollbackMeta(name, version string, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := m.metaSnapshot(name, "")
+	if err != nil {
+		return err
+	}
+	data, err := m.metaSnapshot(name, version)
+	if err != nil {
+		return err
+	}
+
+	metadata := m.specManager.NewMetadata()
+	if err := yaml.Unmarshal(data, metadata); err != nil {
+		return perrs.Annotatef(err, "invalid version %s of the meta of cluster %s", version, name)
+	}
+
+	if !skipConfirm {
+		utils.ShowDiff(string(current), string(data), os.Stdout)
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will roll back the meta of cluster %s to version %s, the instances of the cluster are not changed.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(name),
+				color.HiYellowString(version),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := m.specManager.SaveMeta(name, metadata); err != nil {
+		return err
+	}
+	m.logger.Infof("Rolled back the meta of cluster `%s` to version %s", name, version)
+	return nil
+}
+
+// metaSnapshot returns the version of the meta of the cluster, or the current
+// meta if version is empty.
+func (m *Manager) metaSnapshot(name, version string) ([]byte, error) {
+	if version == "" {
+		exist, err := m.specManager.Exist(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			return nil, perrs.Errorf("%s cluster `%s` not exists", m.sysName, name)
+		}
+		return m.specManager.RawMetadata(name)
+	}
+
+	ver, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, perrs.Errorf("invalid version %s of the meta of cluster %s", version, name)
+	}
+	return m.specManager.MetaSnapshot(name, ver)
+}
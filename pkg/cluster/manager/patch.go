@@ -41,6 +41,12 @@ func (m *Manager) Patch(name string, packagePath string, opt operator.Options, o
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// check locked
 	if err := m.specManager.ScaleOutLockedErr(name); err != nil {
 		if !offline {
@@ -86,7 +92,7 @@ func (m *Manager) Patch(name string, packagePath string, opt operator.Options, o
 	for _, inst := range insts {
 		deployDir := spec.Abs(base.User, inst.DeployDir())
 		tb := task.NewBuilder(m.logger)
-		tb.BackupComponent(inst.ComponentName(), base.Version, inst.GetManageHost(), deployDir).
+		tb.BackupComponent(inst.ComponentName(), base.Version, "", inst.GetManageHost(), deployDir).
 			InstallPackage(packagePath, inst.GetManageHost(), deployDir)
 		replacePackageTasks = append(replacePackageTasks, tb.Build())
 	}
@@ -109,11 +115,12 @@ func (m *Manager) Patch(name string, packagePath string, opt operator.Options, o
 		}).
 		Build()
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		opt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
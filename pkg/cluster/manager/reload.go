@@ -23,7 +23,6 @@ import (
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 
@@ -41,6 +40,12 @@ func (m *Manager) Reload(name string, gOpt operator.Options, skipRestart, skipCo
 		return err
 	}
 
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	sshTimeout := gOpt.SSHTimeout
 	exeTimeout := gOpt.OptTimeout
 
@@ -48,16 +53,20 @@ func (m *Manager) Reload(name string, gOpt operator.Options, skipRestart, skipCo
 	if err != nil {
 		return err
 	}
+	if !skipRestart {
+		if err := checkMaintenance(name, metadata, gOpt); err != nil {
+			return err
+		}
+	}
 
 	var sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
-	if gOpt.SSHType != executor.SSHTypeNone && len(gOpt.SSHProxyHost) != 0 {
-		var err error
-		if sshProxyProps, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
+	if gOpt.SSHType.UsesSSH() {
+		if sshProxyProps, err = readSSHProxyProps(metadata.GetTopology(), gOpt); err != nil {
 			return err
 		}
 	}
 
-	if !skipConfirm {
+	if !skipConfirm && !gOpt.DryRun {
 		if err := tui.PromptForConfirmOrAbortError(
 			"%s", fmt.Sprintf("Will reload the cluster %s with restart policy is %s, nodes: %s, roles: %s.\nDo you want to continue? [y/N]:",
 				color.HiYellowString(name),
@@ -80,7 +89,7 @@ func (m *Manager) Reload(name string, gOpt operator.Options, skipRestart, skipCo
 	refreshConfigTasks, hasImported := buildInitConfigTasks(m, name, topo, base, gOpt, nil)
 
 	// handle dir scheme changes
-	if hasImported {
+	if hasImported && !gOpt.DryRun {
 		if err := spec.HandleImportPathMigration(name); err != nil {
 			return err
 		}
@@ -137,11 +146,21 @@ func (m *Manager) Reload(name string, gOpt operator.Options, skipRestart, skipCo
 
 	t := b.Build()
 
-	ctx := ctxt.New(
+	if gOpt.DryRun {
+		var extra []string
+		if !skipRestart {
+			extra = instancePlan("Rolling restart instances",
+				topo.ComponentsByUpdateOrder(base.Version), gOpt)
+		}
+		return m.dryRun(name, "reload", t, extra...)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -36,6 +36,12 @@ func (m *Manager) Rename(name string, opt operator.Options, newName string, skip
 			WithProperty(tui.SuggestionFromFormat("Please double check your cluster name"))
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	if err := clusterutil.ValidateClusterNameOrError(newName); err != nil {
 		return err
 	}
@@ -53,7 +59,7 @@ func (m *Manager) Rename(name string, opt operator.Options, newName string, skip
 		}
 	}
 
-	_, err := m.meta(name)
+	_, err = m.meta(name)
 	if err != nil { // refuse renaming if current cluster topology is not valid
 		return err
 	}
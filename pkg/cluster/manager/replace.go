@@ -0,0 +1,333 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// ReplaceOptions contains the options for replacing a node.
+type ReplaceOptions struct {
+	Node        string // the ID of the node to be replaced
+	WaitTimeout uint64 // timeout in seconds when waiting for the regions to be migrated from the node
+}
+
+// Replace migrates a node to a new host: the replacement defined in topoFile is
+// scaled out, the old node is scaled in once the replacement is ready, and it's
+// pruned after its regions are migrated. Each step is skipped if it's already
+// done, so an interrupted replacement is resumed by running it again.
+func (m *Manager) Replace(
+	name string,
+	topoFile string,
+	opt ReplaceOptions,
+	deployOpt DeployOptions,
+	afterDeploy func(b *task.Builder, newPart spec.Topology, gOpt operator.Options),
+	final func(b *task.Builder, name string, meta spec.Metadata, gOpt operator.Options),
+	skipConfirm bool,
+	gOpt operator.Options,
+) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	// check locked
+	if err := m.specManager.ScaleOutLockedErr(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := metadata.(*spec.ClusterMeta); !ok {
+		return perrs.Errorf("replace is not supported for %s clusters", m.sysName)
+	}
+	topo := metadata.GetTopology()
+
+	newPart := topo.NewPart()
+	if err := spec.ParseTopologyYaml(topoFile, newPart, true); err != nil &&
+		!errors.Is(perrs.Cause(err), spec.ErrNoTiSparkMaster) {
+		return err
+	}
+	var replacement spec.Instance
+	count := 0
+	newPart.IterInstance(func(inst spec.Instance) {
+		replacement = inst
+		count++
+	})
+	if count != 1 {
+		return perrs.Errorf("the topology file %s must define exactly one instance to replace %s, got %d", topoFile, opt.Node, count)
+	}
+
+	old := findInstance(topo, opt.Node)
+	current := findInstance(topo, replacement.ID())
+	switch {
+	case old == nil && current != nil:
+		m.logger.Infof("Node %s of cluster `%s` has been replaced by %s", opt.Node, name, replacement.ID())
+		return nil
+	case old == nil:
+		return perrs.Errorf("node %s not found in cluster %s", opt.Node, name)
+	case old.ComponentName() != replacement.ComponentName():
+		return perrs.Errorf("can not replace %s node %s with %s node %s",
+			old.ComponentName(), old.ID(), replacement.ComponentName(), replacement.ID())
+	}
+
+	if !skipConfirm {
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will replace %s node %s of cluster %s with %s, the new node is scaled out before the old one is scaled in.\nDo you want to continue? [y/N]:",
+				old.ComponentName(),
+				color.HiYellowString(old.ID()),
+				color.HiYellowString(name),
+				color.HiYellowString(replacement.ID()),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	if current == nil {
+		m.logger.Infof("Scaling out %s to replace %s", replacement.ID(), old.ID())
+		if err := m.ScaleOut(name, topoFile, afterDeploy, final, deployOpt, true, gOpt); err != nil {
+			return err
+		}
+	} else {
+		m.logger.Infof("Replacement %s is already scaled out", replacement.ID())
+	}
+
+	if metadata, err = m.meta(name); err != nil {
+		return err
+	}
+	topo = metadata.GetTopology()
+	if current = findInstance(topo, replacement.ID()); current == nil {
+		return perrs.Errorf("replacement %s not found in cluster %s after scale-out", replacement.ID(), name)
+	}
+	if old = findInstance(topo, opt.Node); old == nil {
+		return perrs.Errorf("node %s not found in cluster %s", opt.Node, name)
+	}
+
+	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
+	if err != nil {
+		return err
+	}
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+
+	m.logger.Infof("Waiting for replacement %s to be ready", current.ID())
+	if err := operator.WaitInstanceReady(ctx, topo, current, gOpt, tlsCfg); err != nil {
+		return perrs.Annotatef(err, "replacement %s is not ready", current.ID())
+	}
+
+	storeAddr := replacedStoreAddr(old)
+	if !isOffline(old) {
+		m.logger.Infof("Scaling in %s", old.ID())
+		sOpt := gOpt
+		sOpt.Roles = nil
+		sOpt.Nodes = []string{old.ID()}
+		if err := m.ScaleIn(name, true, sOpt, m.scaleInNodes(name, sOpt)); err != nil {
+			return err
+		}
+	}
+	if storeAddr == "" {
+		m.logger.Infof("Replaced node %s of cluster `%s` with %s successfully", opt.Node, name, replacement.ID())
+		return nil
+	}
+
+	m.logger.Infof("Waiting for the regions to be migrated from %s", old.ID())
+	pdClient := api.NewPDClient(ctx, topo.(*spec.Specification).GetPDListWithManageHost(), 10*time.Second, tlsCfg)
+	if err := waitStoreTombstone(ctx, pdClient, storeAddr, time.Duration(opt.WaitTimeout)*time.Second); err != nil {
+		return perrs.Annotatef(err, "regions are not migrated from %s yet, run the same command again to resume", old.ID())
+	}
+
+	if err := m.destroyReplaced(name, old, gOpt, tlsCfg); err != nil {
+		return err
+	}
+	m.logger.Infof("Replaced node %s of cluster `%s` with %s successfully", opt.Node, name, replacement.ID())
+	return nil
+}
+
+// destroyReplaced destroys the replaced TiKV or TiFlash instance whose store is
+// tombstone and removes it from the topology, unlike prune, the other tombstone
+// instances in the cluster are left untouched.
+func (m *Manager) destroyReplaced(name string, inst spec.Instance, gOpt operator.Options, tlsCfg *tls.Config) error {
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	clusterMeta := metadata.(*spec.ClusterMeta)
+	topo := clusterMeta.Topology
+	if inst = findInstance(topo, inst.ID()); inst == nil {
+		return nil
+	}
+	nodes := []string{inst.ID()}
+
+	// the monitor agents are destroyed with the last instance on the host
+	last := true
+	topo.IterInstance(func(other spec.Instance) {
+		if other.ID() != inst.ID() && other.GetManageHost() == inst.GetManageHost() {
+			last = false
+		}
+	})
+
+	b, err := m.sshTaskBuilder(name, topo, clusterMeta.User, gOpt)
+	if err != nil {
+		return err
+	}
+	t := b.
+		Func("DestroyReplaced", func(ctx context.Context) error {
+			m.logger.Infof("Destroying tombstone node %s", inst.ID())
+			return operator.StopAndDestroyInstance(ctx, topo, inst, gOpt, true, last, tlsCfg)
+		}).
+		// the topology in etcd of PD has nothing of the stores to be removed
+		UpdateMeta(name, clusterMeta, nodes).
+		Build()
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+
+	// the stores are only referred by the configs of the monitoring components
+	if metadata, err = m.meta(name); err != nil {
+		return err
+	}
+	skipped := []string{}
+	metadata.GetTopology().IterInstance(func(other spec.Instance) {
+		switch other.ComponentName() {
+		case spec.ComponentPrometheus, spec.ComponentGrafana, spec.ComponentAlertmanager:
+		default:
+			skipped = append(skipped, other.ID())
+		}
+	})
+	gOpt.IgnoreConfigCheck = true
+	b, err = m.sshTaskBuilder(name, metadata.GetTopology(), clusterMeta.User, gOpt)
+	if err != nil {
+		return err
+	}
+	regenConfigTasks, _ := buildInitConfigTasks(m, name, metadata.GetTopology(), metadata.GetBaseMeta(), gOpt, skipped)
+	t = b.
+		ParallelStep("+ Refresh instance configs", true, regenConfigTasks...).
+		ParallelStep("+ Reload prometheus and grafana", true,
+			buildReloadPromAndGrafanaTasks(metadata.GetTopology(), m.logger, gOpt)...).
+		Build()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+	return nil
+}
+
+// findInstance returns the instance of the ID in the topology, or nil
+func findInstance(topo spec.Topology, id string) spec.Instance {
+	var found spec.Instance
+	topo.IterInstance(func(inst spec.Instance) {
+		if inst.ID() == id {
+			found = inst
+		}
+	})
+	return found
+}
+
+// replacedStoreAddr returns the address of the store of the instance, or an
+// empty string if it's not a store which becomes tombstone after scale-in.
+func replacedStoreAddr(inst spec.Instance) string {
+	switch inst.ComponentName() {
+	case spec.ComponentTiKV:
+		return inst.ID()
+	case spec.ComponentTiFlash:
+		return utils.JoinHostPort(inst.GetHost(), inst.(*spec.TiFlashInstance).GetServicePort())
+	}
+	return ""
+}
+
+// isOffline returns true if the store of the instance has been scaled in
+func isOffline(inst spec.Instance) bool {
+	switch s := inst.(type) {
+	case *spec.TiKVInstance:
+		return s.InstanceSpec.(*spec.TiKVSpec).Offline
+	case *spec.TiFlashInstance:
+		return s.InstanceSpec.(*spec.TiFlashSpec).Offline
+	}
+	return false
+}
+
+// waitStoreTombstone waits for the store to be tombstone, the progress of the
+// region migration is logged.
+func waitStoreTombstone(ctx context.Context, pdClient *api.PDClient, addr string, timeout time.Duration) error {
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+	if timeout <= 0 {
+		timeout = 2 * time.Hour
+	}
+
+	var lastErr error
+	err := utils.Retry(func() error {
+		store, err := pdClient.GetCurrentStore(addr)
+		if err != nil {
+			lastErr = err
+			return err
+		}
+		if store.Store.State == metapb.StoreState_Tombstone {
+			return nil
+		}
+		lastErr = perrs.Errorf("store %s is %s with %d regions left", addr, store.Store.StateName, store.Status.RegionCount)
+		logger.Infof("\tStore %s is %s, %d regions left", addr, store.Store.StateName, store.Status.RegionCount)
+		return lastErr
+	}, utils.RetryOption{
+		Delay:   time.Second * 10,
+		Timeout: timeout,
+	})
+	if err != nil && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
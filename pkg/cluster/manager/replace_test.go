@@ -0,0 +1,120 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceInstances(t *testing.T) {
+	topo := &spec.Specification{
+		PDServers: []*spec.PDSpec{{Host: "172.16.5.1", ClientPort: 2379}},
+		TiKVServers: []*spec.TiKVSpec{
+			{Host: "172.16.5.1", Port: 20160},
+			{Host: "172.16.5.2", Port: 20160, Offline: true},
+		},
+		TiFlashServers: []*spec.TiFlashSpec{{Host: "172.16.5.3", TCPPort: 9000, FlashServicePort: 3930}},
+	}
+
+	require.Nil(t, findInstance(topo, "172.16.5.9:20160"))
+
+	pd := findInstance(topo, "172.16.5.1:2379")
+	require.NotNil(t, pd)
+	require.Empty(t, replacedStoreAddr(pd))
+	require.False(t, isOffline(pd))
+
+	tikv := findInstance(topo, "172.16.5.1:20160")
+	require.Equal(t, "172.16.5.1:20160", replacedStoreAddr(tikv))
+	require.False(t, isOffline(tikv))
+	require.True(t, isOffline(findInstance(topo, "172.16.5.2:20160")))
+
+	tiflash := findInstance(topo, "172.16.5.3:9000")
+	require.Equal(t, "172.16.5.3:3930", replacedStoreAddr(tiflash))
+}
+
+func TestReplaceTombstone(t *testing.T) {
+	stores := &api.StoresInfo{}
+	for i, s := range []struct {
+		addr  string
+		state metapb.StoreState
+	}{
+		{"172.16.5.1:20160", metapb.StoreState_Up},
+		{"172.16.5.2:20160", metapb.StoreState_Tombstone},
+		{"172.16.5.3:20160", metapb.StoreState_Tombstone},
+		{"172.16.5.4:20160", metapb.StoreState_Up},
+	} {
+		stores.Stores = append(stores.Stores, &api.StoreInfo{
+			Store: &api.MetaStore{
+				Store:     &metapb.Store{Id: uint64(i + 1), Address: s.addr, State: s.state},
+				StateName: s.state.String(),
+			},
+			Status: &api.StoreStatus{},
+		})
+	}
+	pd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pd/api/v1/stores" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(stores)
+	}))
+	defer pd.Close()
+	pdHost, pdPort := utils.ParseHostPort(strings.TrimPrefix(pd.URL, "http://"))
+
+	// 172.16.5.2 is scaled in and replaced by 172.16.5.4, 172.16.5.3 is scaled in
+	// by another operation and not pruned yet
+	m, _ := newTestManager(t, "test", fmt.Sprintf(`
+pd_servers:
+  - host: %s
+    client_port: %s
+tikv_servers:
+  - host: 172.16.5.1
+  - host: 172.16.5.2
+    offline: true
+  - host: 172.16.5.3
+    offline: true
+  - host: 172.16.5.4
+`, pdHost, pdPort))
+	topoFile := filepath.Join(t.TempDir(), "replace.yaml")
+	require.NoError(t, os.WriteFile(topoFile, []byte("tikv_servers:\n  - host: 172.16.5.4\n"), 0644))
+
+	gOpt := testOptions()
+	require.NoError(t, m.Replace("test", topoFile, ReplaceOptions{Node: "172.16.5.2:20160", WaitTimeout: 1}, DeployOptions{}, nil, nil, true, gOpt))
+
+	metadata, err := m.meta("test")
+	require.NoError(t, err)
+	topo := metadata.GetTopology()
+	require.Nil(t, findInstance(topo, "172.16.5.2:20160"))
+	require.NotNil(t, findInstance(topo, "172.16.5.3:20160"))
+	require.False(t, hosts.listened("172.16.5.2", 20160))
+	require.True(t, hosts.listened("172.16.5.3", 20160))
+	require.Contains(t, strings.Join(hosts.commands["172.16.5.2"], "\n"), "rm -rf")
+	require.NotContains(t, strings.Join(hosts.commands["172.16.5.3"], "\n"), "rm -rf")
+
+	// the replacement is done
+	require.NoError(t, m.Replace("test", topoFile, ReplaceOptions{Node: "172.16.5.2:20160"}, DeployOptions{}, nil, nil, true, gOpt))
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/tui"
+)
+
+// Rollback restores the cluster to the version before its latest upgrade, the
+// backed up binaries and configs are restored and the upgraded instances are
+// restarted in the reverse order of upgrade. The subcommand returns the name of
+// the subcommand of the recorded arguments of a command.
+func (m *Manager) Rollback(name string, subcommand func(args []string) string, gOpt operator.Options, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	// check locked
+	if err := m.specManager.ScaleOutLockedErr(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	base := metadata.GetBaseMeta()
+
+	a, err := latestUpgradeAudit(name, subcommand)
+	if err != nil {
+		return err
+	}
+	var fromVer, toVer, backupID string
+	if a == nil ||
+		!a.recordField(upgradeStartedMsg, "from", &fromVer) ||
+		!a.recordField(upgradeStartedMsg, "to", &toVer) {
+		return perrs.Errorf("no upgrade of cluster %s is found to roll back", name)
+	}
+	// the backups are not stamped if the upgrade didn't record the ID
+	a.recordField(upgradeStartedMsg, "backup", &backupID)
+	if base.Version != fromVer && base.Version != toVer {
+		return perrs.Errorf("cluster %s is %s now, which is neither the version before (%s) nor after (%s) the latest upgrade",
+			name, base.Version, fromVer, toVer)
+	}
+
+	nodes, err := a.upgradedInstances()
+	if err != nil {
+		return err
+	}
+
+	if !skipConfirm && !gOpt.DryRun {
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will roll back the cluster %s from %s to %s, restarting nodes: %s.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(name),
+				color.HiYellowString(toVer),
+				color.HiYellowString(fromVer),
+				color.HiRedString(strings.Join(nodes, ",")),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := m.rollbackUpgrade(name, metadata, fromVer, toVer, backupID, nodes, gOpt); err != nil {
+		return err
+	}
+	if gOpt.DryRun {
+		return nil
+	}
+
+	metadata.SetVersion(fromVer)
+	var versions upgradeVersions
+	if a.recordField(upgradeStartedMsg, "versions", &versions) {
+		versions.restore(metadata.GetTopology())
+	}
+	if err := m.specManager.SaveMeta(name, metadata); err != nil {
+		return err
+	}
+
+	m.logger.Infof("Rolled back cluster `%s` to %s successfully", name, fromVer)
+	return nil
+}
+
+// rollbackUpgrade restores the files of version fromVer backed up with the ID on
+// all instances of the cluster and restarts the instances in nodes.
+func (m *Manager) rollbackUpgrade(name string, metadata spec.Metadata, fromVer, toVer, backupID string, nodes []string, gOpt operator.Options) error {
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
+	if err != nil {
+		return err
+	}
+
+	var restoreTasks []*task.StepDisplay
+	for _, comp := range topo.ComponentsByUpdateOrder(fromVer) {
+		for _, inst := range comp.Instances() {
+			deployDir := spec.Abs(base.User, inst.DeployDir())
+			t := task.NewBuilder(m.logger).
+				RestoreComponent(inst.ComponentSource(), fromVer, backupID, inst.GetManageHost(), deployDir).
+				BuildAsStep(fmt.Sprintf("  - Restore %s -> %s", inst.ComponentName(), inst.ID()))
+			restoreTasks = append(restoreTasks, t)
+		}
+	}
+
+	b, err := m.sshTaskBuilder(name, topo, base.User, gOpt)
+	if err != nil {
+		return err
+	}
+	t := b.
+		ParallelStep("+ Restore files of the previous version", gOpt.Force, restoreTasks...).
+		Func("RollbackCluster", func(ctx context.Context) error {
+			nopt := gOpt
+			nopt.Nodes = nodes
+			return operator.Rollback(ctx, topo, nopt, tlsCfg, toVer, fromVer)
+		}).
+		Build()
+
+	if gOpt.DryRun {
+		var extra []string
+		if len(nodes) > 0 {
+			comps := topo.ComponentsByUpdateOrder(fromVer)
+			slices.Reverse(comps)
+			extra = instancePlan("Restart instances", comps, operator.Options{Nodes: nodes})
+		}
+		return m.dryRun(name, "rollback", t, extra...)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+	return nil
+}
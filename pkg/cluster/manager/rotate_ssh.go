@@ -31,6 +31,12 @@ import (
 
 // RotateSSH rotate public keys of target nodes
 func (m *Manager) RotateSSH(name string, gOpt operator.Options, skipConfirm bool) error {
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) &&
 		!errors.Is(perrs.Cause(err), spec.ErrNoTiSparkMaster) {
@@ -82,11 +88,12 @@ func (m *Manager) RotateSSH(name string, gOpt operator.Options, skipConfirm bool
 				Build(),
 			m.logger)
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := builder.Build().Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
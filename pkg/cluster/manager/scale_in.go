@@ -49,6 +49,12 @@ func (m *Manager) ScaleIn(
 		return err
 	}
 
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	var (
 		force bool     = gOpt.Force
 		nodes []string = gOpt.Nodes
@@ -68,7 +74,7 @@ func (m *Manager) ScaleIn(
 	topo := metadata.GetTopology()
 	base := metadata.GetBaseMeta()
 
-	if !skipConfirm {
+	if !skipConfirm && !gOpt.DryRun {
 		if force {
 			m.logger.Warnf("%s", color.HiRedString(tui.ASCIIArtWarning))
 			if err := tui.PromptForAnswerOrAbortError(
@@ -111,11 +117,24 @@ func (m *Manager) ScaleIn(
 		return err
 	}
 	scale(b, metadata, tlsCfg)
-	ctx := ctxt.New(
+
+	if gOpt.DryRun {
+		// the configs are refreshed with the topology which has the nodes removed
+		regenConfigTasks, _ := buildInitConfigTasks(m, name, topo, base, gOpt, nodes)
+		t := b.
+			ParallelStep("+ Refresh instance configs", force, regenConfigTasks...).
+			ParallelStep("+ Reload prometheus and grafana", gOpt.Force,
+				buildReloadPromAndGrafanaTasks(topo, m.logger, gOpt, nodes...)...).
+			Build()
+		return m.dryRun(name, "scale-in", t)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 
 	if err := b.Build().Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
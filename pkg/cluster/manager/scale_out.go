@@ -25,7 +25,6 @@ import (
 	"github.com/pingcap/tiup/pkg/cluster/api"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/cluster/task"
@@ -56,6 +55,12 @@ func (m *Manager) ScaleOut(
 		return err
 	}
 
+	unlock, err := m.lockCluster(name, gOpt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	// allow specific validation errors so that user can recover a broken
 	// cluster if it is somehow in a bad state.
@@ -109,36 +114,49 @@ func (m *Manager) ScaleOut(
 		sshConnProps  *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 		sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 	)
-	if gOpt.SSHType != executor.SSHTypeNone {
+	// no host is connected in dry run, so the credentials are not needed
+	if gOpt.SSHType.UsesSSH() && !gOpt.DryRun {
 		var err error
-		if sshConnProps, err = tui.ReadIdentityFileOrPassword(opt.IdentityFile, opt.UsePassword); err != nil {
+		useAgent := opt.UseSSHAgent || topo.BaseTopo().GlobalOptions.SSHAgent
+		if sshConnProps, err = readSSHConnProps(opt.IdentityFile, opt.UsePassword, useAgent); err != nil {
 			return err
 		}
-		if len(gOpt.SSHProxyHost) != 0 {
-			if sshProxyProps, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
-				return err
-			}
+		if sshProxyProps, err = readSSHProxyProps(topo, gOpt); err != nil {
+			return err
+		}
+	}
+
+	if opt.NoSudo {
+		if err := checkSudoless(topo, opt); err != nil {
+			return err
 		}
 	}
 
 	var sudo bool
-	if topo.BaseTopo().GlobalOptions.SystemdMode == spec.UserMode {
+	if topo.BaseTopo().GlobalOptions.SystemdMode == spec.UserMode && opt.NoSudo {
+		// lingering is enabled by the scripts preparing the hosts
+		sudo = false
+	} else if topo.BaseTopo().GlobalOptions.SystemdMode == spec.UserMode {
 		sudo = false
 		hint := fmt.Sprintf("loginctl enable-linger %s", opt.User)
 		msg := "The value of systemd_mode is set to `user` in the topology, please note that you'll need to manually execute the following command using root or sudo on the host(s) to enable lingering for the systemd user instance.\n"
 		msg += color.GreenString(hint)
 		msg += "\nYou can read the systemd documentation for reference: https://wiki.archlinux.org/title/Systemd/User#Automatic_start-up_of_systemd_user_instances."
 		m.logger.Warnf("%s", msg)
-		err = tui.PromptForConfirmOrAbortError("Do you want to continue? [y/N]: ")
-		if err != nil {
-			return err
+		if !gOpt.DryRun {
+			if err := tui.PromptForConfirmOrAbortError("Do you want to continue? [y/N]: "); err != nil {
+				return err
+			}
 		}
 	} else {
 		sudo = opt.User != "root"
 	}
 
-	if err := m.fillHost(sshConnProps, sshProxyProps, newPart, &gOpt, opt.User, sudo); err != nil {
-		return err
+	// the arch and OS of hosts are detected via SSH, which is not allowed in dry run
+	if !gOpt.DryRun {
+		if err := m.fillHost(sshConnProps, sshProxyProps, newPart, &gOpt, opt.User, sudo); err != nil {
+			return err
+		}
 	}
 
 	var mergedTopo spec.Topology
@@ -154,8 +172,8 @@ func (m *Manager) ScaleOut(
 		spec.ExpandRelativeDir(mergedTopo)
 
 		if topo, ok := mergedTopo.(*spec.Specification); ok {
-			// Check if TiKV's label set correctly
-			if !opt.NoLabels {
+			// Check if TiKV's label set correctly, the labels are read from PD
+			if !opt.NoLabels && !gOpt.DryRun {
 				pdList := topo.BaseTopo().MasterList
 				tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
 				if err != nil {
@@ -191,7 +209,7 @@ func (m *Manager) ScaleOut(
 		}
 	})
 
-	if !skipConfirm {
+	if !skipConfirm && !gOpt.DryRun {
 		// patchedComponents are components that have been patched and overwrited
 		if err := m.confirmTopology(name, base.Version, newPart, patchedComponents); err != nil {
 			return err
@@ -206,12 +224,19 @@ func (m *Manager) ScaleOut(
 		return err
 	}
 
-	ctx := ctxt.New(
+	if gOpt.DryRun {
+		return m.dryRun(name, "scale-out", t)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	ctx = context.WithValue(ctx, ctxt.CtxBaseTopo, topo)
+	ctx, cleanupPeers := task.WithPeerDistributor(ctx, gOpt.PeerFanout, mergedTopo)
+	defer cleanupPeers()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -19,6 +19,7 @@ import (
 
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
 	"gopkg.in/yaml.v3"
 )
@@ -41,6 +42,15 @@ func (m *Manager) ShowConfig(name string) error {
 		return perrs.AddStack(err)
 	}
 
+	// the topology is in YAML already, it is converted in the JSON mode
+	if m.logger.GetDisplayMode() == logprinter.DisplayModeJSON {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return perrs.AddStack(err)
+		}
+		return m.logger.Output(v)
+	}
+
 	fmt.Print(string(data))
 	return nil
 }
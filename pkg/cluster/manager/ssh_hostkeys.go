@@ -0,0 +1,202 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/meta"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"golang.org/x/crypto/ssh"
+)
+
+// ListHostKeys prints the SSH host keys recorded for the cluster
+func (m *Manager) ListHostKeys(name string) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+	if _, err := m.meta(name); err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) &&
+		!errors.Is(perrs.Cause(err), spec.ErrNoTiSparkMaster) {
+		return err
+	}
+
+	file := m.specManager.Path(name, "ssh", "known_hosts")
+	keys, err := executor.ListKnownHosts(file)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		m.logger.Infof("No host key is recorded for cluster `%s` yet", name)
+		return nil
+	}
+
+	m.logger.Infof("Host keys of cluster `%s` in %s:", name, file)
+	rows := [][]string{{"Host", "Type", "Fingerprint"}}
+	for _, k := range keys {
+		rows = append(rows, []string{
+			strings.Join(k.Hosts, ","),
+			k.Key.Type(),
+			ssh.FingerprintSHA256(k.Key),
+		})
+	}
+	tui.PrintTable(rows, true)
+	return nil
+}
+
+// RemoveHostKeys removes the recorded SSH host keys of the hosts of the cluster,
+// their host keys are trusted on next connection if it's in a deploy or scale-out,
+// other operations fail on the hosts until their keys are refreshed.
+func (m *Manager) RemoveHostKeys(name string, hosts []string, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return perrs.New("no host specified, use --host to specify the hosts")
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !skipConfirm {
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will remove the host keys of %s from cluster %s.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(strings.Join(hosts, ",")),
+				color.HiYellowString(name),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	removed, err := executor.RemoveKnownHosts(m.specManager.Path(name, "ssh", "known_hosts"), hosts...)
+	if err != nil {
+		return err
+	}
+	m.logger.Infof("Removed %d host key(s) of cluster `%s`", removed, name)
+	return nil
+}
+
+// RefreshHostKeys drops the recorded SSH host keys of the hosts and trusts the
+// ones they present now, all hosts of the cluster are refreshed if hosts is empty.
+// It's for the hosts reinstalled or with their keys regenerated on purpose.
+func (m *Manager) RefreshHostKeys(name string, hosts []string, gOpt operator.Options, skipConfirm bool) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	metadata, err := m.meta(name)
+	if err != nil && !errors.Is(perrs.Cause(err), meta.ErrValidate) &&
+		!errors.Is(perrs.Cause(err), spec.ErrNoTiSparkMaster) {
+		return err
+	}
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	if len(hosts) == 0 {
+		uniqueHosts, _ := getMonitorHosts(topo)
+		for host := range uniqueHosts {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		hosts = append(hosts, jumpHosts(topo, gOpt)...)
+	}
+
+	if !skipConfirm {
+		if err := tui.PromptForConfirmOrAbortError(
+			"%s", fmt.Sprintf("Will trust the host keys presented by %s of cluster %s now, make sure the hosts are not compromised.\nDo you want to continue? [y/N]:",
+				color.HiYellowString(strings.Join(hosts, ",")),
+				color.HiYellowString(name),
+			),
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := executor.RemoveKnownHosts(m.specManager.Path(name, "ssh", "known_hosts"), hosts...); err != nil {
+		return err
+	}
+
+	b, err := m.trustedSSHTaskBuilder(name, topo, base.User, gOpt, true)
+	if err != nil {
+		return err
+	}
+	// the host keys are recorded once the hosts are connected
+	uniqueHosts, _ := getMonitorHosts(topo)
+	for host := range uniqueHosts {
+		b.Shell(host, "true", "", false)
+	}
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	if err := b.Build().Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+
+	m.logger.Infof("Refreshed the host keys of cluster `%s`", name)
+	return nil
+}
+
+// jumpHosts returns the jump hosts the hosts of the topology are connected through
+func jumpHosts(topo spec.Topology, gOpt operator.Options) []string {
+	var jumps []string
+	for _, jump := range spec.SSHProxies(topo) {
+		jumps = append(jumps, jump)
+	}
+	if gOpt.SSHProxyHost != "" {
+		jumps = append(jumps, gOpt.SSHProxyHost)
+	}
+
+	hosts := set.NewStringSet()
+	for _, jump := range jumps {
+		proxy, err := executor.ParseProxyJump(jump, executor.SSHConfig{})
+		if err != nil {
+			continue
+		}
+		for ; proxy != nil; proxy = proxy.Proxy {
+			hosts.Insert(proxy.Host)
+		}
+	}
+	result := hosts.Slice()
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	perrs "github.com/pingcap/errors"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/cluster/template/scripts"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// prepareScriptDir is the directory of the scripts preparing the hosts as root
+// for the sudo-less deployment, under the directory of the cluster
+const prepareScriptDir = "prepare"
+
+// checkSudoless checks the topology can be deployed by the user without sudo
+func checkSudoless(topo spec.Topology, opt DeployOptions) error {
+	globalOptions := topo.BaseTopo().GlobalOptions
+	if opt.User != globalOptions.User {
+		return perrs.Errorf("the user to login must be the deploy user '%s' to deploy without sudo, but it's '%s'", globalOptions.User, opt.User)
+	}
+	if globalOptions.SystemdMode != spec.UserMode {
+		return perrs.Errorf("systemd_mode must be '%s' to deploy without sudo, but it's '%s'", spec.UserMode, globalOptions.SystemdMode)
+	}
+	return nil
+}
+
+// prepareDirs returns the directories to be created by root for the deploy
+// user on each host, i.e. the deploy, data and log directories of the
+// instances and the monitoring agents
+func prepareDirs(topo spec.Topology) map[string][]string {
+	globalOptions := topo.BaseTopo().GlobalOptions
+	monitoredOptions := topo.GetMonitoredOptions()
+	user := globalOptions.User
+
+	dirs := make(map[string]set.StringSet)
+	add := func(host string, paths ...string) {
+		if dirs[host] == nil {
+			dirs[host] = set.NewStringSet()
+		}
+		for _, path := range paths {
+			if path != "" {
+				dirs[host].Insert(path)
+			}
+		}
+	}
+	_, noAgentHosts := getMonitorHosts(topo)
+	topo.IterInstance(func(inst spec.Instance) {
+		host := inst.GetManageHost()
+		add(host, spec.Abs(user, inst.DeployDir()), spec.Abs(user, inst.LogDir()))
+		add(host, spec.MultiDirAbs(user, inst.DataDir())...)
+
+		if monitoredOptions == nil || noAgentHosts.Exist(host) {
+			return
+		}
+		deployDir := spec.Abs(user, monitoredOptions.DeployDir)
+		dataDir := monitoredOptions.DataDir
+		if dataDir != "" && !strings.HasPrefix(dataDir, "/") {
+			dataDir = filepath.Join(deployDir, dataDir)
+		}
+		add(host, deployDir, dataDir, spec.Abs(user, monitoredOptions.LogDir))
+	})
+
+	result := make(map[string][]string)
+	for host, paths := range dirs {
+		result[host] = paths.Slice()
+		sort.Strings(result[host])
+	}
+	return result
+}
+
+// buildPrepareCheckTasks generates the scripts preparing the hosts of the topology
+// as root for the sudo-less deployment, and returns the tasks checking the hosts
+// are prepared, which fail with the scripts to run as root if not
+func buildPrepareCheckTasks(
+	m *Manager,
+	name string,
+	topo spec.Topology,
+	opt DeployOptions,
+	gOpt operator.Options,
+	s, p *tui.SSHConnectionProps,
+) ([]*task.StepDisplay, error) {
+	globalOptions := topo.BaseTopo().GlobalOptions
+	dir := m.specManager.Path(name, prepareScriptDir)
+	if err := utils.MkdirAll(dir, 0755); err != nil {
+		return nil, perrs.Annotatef(err, "create directory %s", dir)
+	}
+
+	var tasks []*task.StepDisplay
+	uniqueHosts, _ := getMonitorHosts(topo)
+	for host, dirs := range prepareDirs(topo) {
+		script := filepath.Join(dir, fmt.Sprintf("prepare_%s.sh", host))
+		prepare := &scripts.PrepareScript{
+			ClusterName: name,
+			Host:        host,
+			User:        globalOptions.User,
+			Group:       globalOptions.Group,
+			Dirs:        dirs,
+		}
+		if err := prepare.ConfigToFile(script); err != nil {
+			return nil, err
+		}
+
+		t := task.NewBuilder(m.logger).
+			RootSSH(
+				host,
+				uniqueHosts[host].ssh,
+				opt.User,
+				s.Password,
+				s.IdentityFile,
+				s.IdentityFilePassphrase,
+				s.SSHAgent,
+				gOpt.SSHTimeout,
+				gOpt.OptTimeout,
+				gOpt.SSHProxyHost,
+				gOpt.SSHProxyPort,
+				gOpt.SSHProxyUser,
+				p.Password,
+				p.IdentityFile,
+				p.IdentityFilePassphrase,
+				p.SSHAgent,
+				gOpt.SSHProxyTimeout,
+				gOpt.SSHType,
+				globalOptions.SSHType,
+				false,
+			).
+			PrepareCheck(host, globalOptions.User, script, dirs...).
+			BuildAsStep(fmt.Sprintf("  - Check the preparation of %s", host))
+		tasks = append(tasks, t)
+	}
+	m.logger.Infof("The scripts to prepare the hosts as root are generated in %s", color.CyanString(dir))
+	return tasks, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"errors"
+	"testing"
+
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPrepareDirs(t *testing.T) {
+	topo := &spec.Specification{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+global:
+  user: "tidb"
+  systemd_mode: "user"
+  deploy_dir: "/tidb-deploy"
+  data_dir: "/tidb-data"
+monitored:
+  deploy_dir: "/tidb-deploy/monitor"
+  data_dir: "data"
+  log_dir: "log"
+pd_servers:
+  - host: 172.16.5.1
+tikv_servers:
+  - host: 172.16.5.1
+  - host: 172.16.5.2
+    data_dir: "/data1/tikv"
+    ignore_exporter: true
+`), topo))
+	spec.ExpandRelativeDir(topo)
+
+	dirs := prepareDirs(topo)
+	require.Equal(t, []string{
+		"/tidb-data/pd-2379",
+		"/tidb-data/tikv-20160",
+		"/tidb-deploy/monitor",
+		"/tidb-deploy/monitor/data",
+		"/tidb-deploy/monitor/log",
+		"/tidb-deploy/pd-2379",
+		"/tidb-deploy/pd-2379/log",
+		"/tidb-deploy/tikv-20160",
+		"/tidb-deploy/tikv-20160/log",
+	}, dirs["172.16.5.1"])
+	require.Equal(t, []string{
+		"/data1/tikv",
+		"/tidb-deploy/tikv-20160",
+		"/tidb-deploy/tikv-20160/log",
+	}, dirs["172.16.5.2"])
+
+	require.NoError(t, checkSudoless(topo, DeployOptions{User: "tidb"}))
+	require.Error(t, checkSudoless(topo, DeployOptions{User: "root"}))
+	topo.GlobalOptions.SystemdMode = spec.SystemMode
+	require.Error(t, checkSudoless(topo, DeployOptions{User: "tidb"}))
+}
+
+func TestRootFixCommand(t *testing.T) {
+	for _, c := range []struct {
+		res *operator.CheckResult
+		cmd string
+	}{
+		{
+			&operator.CheckResult{Name: operator.CheckNameSysctl, Msg: "fs.file-max = 1000000"},
+			"echo 'fs.file-max=1000000' >> /etc/sysctl.d/99-sysctl.conf && sysctl -w fs.file-max=1000000",
+		},
+		{
+			&operator.CheckResult{Name: operator.CheckNameLimits, Msg: "tidb    soft    nofile    1000000"},
+			"echo 'tidb    soft    nofile    1000000' >> /etc/security/limits.conf",
+		},
+		{
+			&operator.CheckResult{Name: operator.CheckNameSysService, Msg: "start irqbalance"},
+			"systemctl start irqbalance",
+		},
+		{
+			&operator.CheckResult{Name: operator.CheckNameSysService, Msg: "service irqbalance not found"},
+			"",
+		},
+		{
+			&operator.CheckResult{Name: operator.CheckNameSwap},
+			"swapoff -a",
+		},
+		{
+			&operator.CheckResult{Name: operator.CheckNameCPUThreads},
+			"",
+		},
+	} {
+		require.Equal(t, c.cmd, rootFixCommand(c.res))
+	}
+
+	// the fixes needing root are reported in user mode
+	res := &operator.CheckResult{Name: operator.CheckNameSwap, Err: errors.New("swap is enabled")}
+	msg, err := fixFailedChecks("172.16.5.1", res, nil, string(spec.UserMode))
+	require.NoError(t, err)
+	require.Contains(t, msg, "swap is enabled, root is needed to fix it")
+	require.Contains(t, msg, "swapoff -a")
+}
@@ -23,7 +23,6 @@ import (
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 
@@ -42,6 +41,12 @@ func (m *Manager) TLS(name string, gOpt operator.Options, enable, cleanCertifica
 		return err
 	}
 
+	unlock, err := m.specManager.Lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil {
 		return err
@@ -70,12 +75,10 @@ func (m *Manager) TLS(name string, gOpt operator.Options, enable, cleanCertifica
 	var (
 		sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
 	)
-	if gOpt.SSHType != executor.SSHTypeNone {
+	if gOpt.SSHType.UsesSSH() {
 		var err error
-		if len(gOpt.SSHProxyHost) != 0 {
-			if sshProxyProps, err = tui.ReadIdentityFileOrPassword(gOpt.SSHProxyIdentity, gOpt.SSHProxyUsePassword); err != nil {
-				return err
-			}
+		if sshProxyProps, err = readSSHProxyProps(topo, gOpt); err != nil {
+			return err
 		}
 	}
 
@@ -92,11 +95,12 @@ func (m *Manager) TLS(name string, gOpt operator.Options, enable, cleanCertifica
 		return err
 	}
 
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		gOpt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
 	if err := t.Execute(ctx); err != nil {
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
@@ -0,0 +1,467 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/joomcode/errorx"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	operator "github.com/pingcap/tiup/pkg/cluster/operation"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/cluster/task"
+	"github.com/pingcap/tiup/pkg/insight"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/tui"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// TopOptions represents the options of the top command
+type TopOptions struct {
+	Interval time.Duration // the refresh interval
+	SortBy   string        // the column the instances are sorted by
+	Reverse  bool          // sort the instances in descending order
+	Count    int           // exit after the number of refreshes, 0 for no limit
+}
+
+var (
+	topHeader = []string{"ID", "Role", "Host", "Status", "Since", "CPU", "Memory", "Leaders", "Regions"}
+
+	// topToolsDir is where the insight collector is copied to on the hosts
+	topToolsDir = filepath.Join(task.CheckToolsPathDir, "top")
+)
+
+// procSample is the CPU time of a process at a time, the CPU usage is the
+// difference of two samples
+type procSample struct {
+	pid  int32
+	cpu  float64 // the user and system CPU time in seconds
+	time time.Time
+}
+
+// topCollector collects the stats of the instances shown by the top command
+type topCollector struct {
+	logger      *logprinter.Logger
+	instances   []spec.Instance
+	masterList  []string
+	tlsCfg      *tls.Config
+	timeout     time.Duration
+	systemdMode string
+	concurrency int
+
+	mu      sync.Mutex
+	samples map[string]procSample // instance ID -> the last sample
+}
+
+// Top shows the status, uptime, CPU and memory usage of the instances and the
+// leader and region counts of the stores, refreshed periodically.
+func (m *Manager) Top(name string, opt TopOptions, gOpt operator.Options) error {
+	if err := clusterutil.ValidateClusterNameOrError(name); err != nil {
+		return err
+	}
+
+	metadata, err := m.meta(name)
+	if err != nil {
+		return err
+	}
+	topo := metadata.GetTopology()
+	base := metadata.GetBaseMeta()
+
+	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
+	if err != nil {
+		return err
+	}
+
+	roleFilter := set.NewStringSet(gOpt.Roles...)
+	nodeFilter := set.NewStringSet(gOpt.Nodes...)
+	c := &topCollector{
+		logger:      m.logger,
+		masterList:  topo.BaseTopo().MasterList,
+		tlsCfg:      tlsCfg,
+		timeout:     time.Duration(gOpt.APITimeout) * time.Second,
+		systemdMode: string(topo.BaseTopo().GlobalOptions.SystemdMode),
+		concurrency: gOpt.Concurrency,
+		samples:     make(map[string]procSample),
+	}
+	topo.IterInstance(func(inst spec.Instance) {
+		if len(roleFilter) > 0 && !roleFilter.Exist(inst.Role()) {
+			return
+		}
+		if len(nodeFilter) > 0 && !nodeFilter.Exist(inst.ID()) {
+			return
+		}
+		c.instances = append(c.instances, inst)
+	})
+	if len(c.instances) == 0 {
+		return perrs.Errorf("no instance of cluster '%s' matches the role and node filters", name)
+	}
+
+	ctx, closeSSH := ctxt.NewWithSSHPool(
+		context.Background(),
+		gOpt.Concurrency,
+		m.logger,
+	)
+	defer closeSSH()
+	dashboard := tui.NewDashboard(
+		fmt.Sprintf("%s %s (%s)", m.sysName, name, base.Version),
+		topHeader,
+		func() ([][]string, error) { return c.collect(ctx) },
+	).SetInterval(opt.Interval)
+	if err := dashboard.SetSort(opt.SortBy, opt.Reverse); err != nil {
+		return err
+	}
+	_ = dashboard.SetFilter("Role", "")
+
+	// the insight collector reports the CPU and memory usage of the processes
+	b, err := m.sshTaskBuilder(name, topo, base.User, gOpt)
+	if err != nil {
+		return err
+	}
+	tools := newInsightTools(m.logger, topToolsDir, base.User, topo.BaseTopo().GlobalOptions.SystemdMode != spec.UserMode)
+	downloadTasks, copyTasks, cleanTasks := tools.steps(c.instances)
+
+	t := b.
+		ParallelStep("+ Download the insight collector", false, downloadTasks...).
+		ParallelStep("+ Copy the insight collector to the hosts", false, copyTasks...).
+		Build()
+	if err := t.Execute(ctx); err != nil {
+		if errorx.Cast(err) != nil {
+			// FIXME: Map possible task errors and give suggestions.
+			return err
+		}
+		return perrs.Trace(err)
+	}
+	defer func() {
+		t := task.NewBuilder(m.logger).
+			ParallelStep("+ Clean up the insight collector", true, cleanTasks...).
+			Build()
+		if err := t.Execute(ctx); err != nil {
+			m.logger.Warnf("Failed to clean up the insight collector in %s: %s", topToolsDir, err)
+		}
+	}()
+
+	// stop on signals to clean up the insight collector
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mode := m.logger.GetDisplayMode()
+	if !mode.Structured() && opt.Count == 0 && tui.IsTerminal() {
+		return dashboard.Run(sigCtx)
+	}
+
+	// the snapshots are printed one after another if the output is not for a
+	// terminal, e.g. in the structured display modes or redirected to a file
+	for i := 0; opt.Count == 0 || i < opt.Count; i++ {
+		if i > 0 {
+			select {
+			case <-sigCtx.Done():
+				return nil
+			case <-time.After(opt.Interval):
+			}
+		}
+		if err := dashboard.Update(); err != nil {
+			return err
+		}
+		tui.PrintTable(append([][]string{topHeader}, dashboard.Rows()...), true)
+	}
+	return nil
+}
+
+// collect returns the rows of the instances
+func (c *topCollector) collect(ctx context.Context) ([][]string, error) {
+	// the status of the PD instances goes first, the others are queried from
+	// the PD instances which are up
+	var mu sync.Mutex
+	status := make(map[string]string)
+	pdList := make([]string, 0)
+	c.parallel(func(inst spec.Instance) {
+		if inst.ComponentName() != spec.ComponentPD {
+			return
+		}
+		s := inst.Status(ctx, c.timeout, c.tlsCfg, c.masterList...)
+		mu.Lock()
+		defer mu.Unlock()
+		status[inst.ID()] = s
+		if strings.HasPrefix(s, "Up") {
+			pdList = append(pdList, utils.JoinHostPort(inst.GetManageHost(), inst.GetPort()))
+		}
+	})
+	if len(pdList) == 0 {
+		pdList = c.masterList
+	}
+
+	stores := c.stores(ctx, pdList)
+	owner := c.cdcOwner(ctx)
+	procs := c.processes(ctx)
+
+	rows := make([][]string, 0, len(c.instances))
+	c.parallel(func(inst spec.Instance) {
+		s, found := status[inst.ID()]
+		if !found {
+			s = inst.Status(ctx, c.timeout, c.tlsCfg, pdList...)
+		}
+		if inst.ComponentName() == spec.ComponentCDC && owner != "" &&
+			owner == utils.JoinHostPort(inst.GetHost(), inst.GetPort()) {
+			s += "|Owner"
+		}
+		since := formatInstanceSince(inst.Uptime(ctx, c.timeout, c.tlsCfg))
+
+		leaders, regions := "-", "-"
+		if store, ok := stores[storeAddress(inst)]; ok && store.Status != nil {
+			leaders = strconv.Itoa(store.Status.LeaderCount)
+			regions = strconv.Itoa(store.Status.RegionCount)
+		}
+
+		cpu, memory := "-", "-"
+		if stat, ok := procs[inst.ID()]; ok {
+			cpu, memory = c.usage(inst.ID(), stat)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		rows = append(rows, []string{
+			inst.ID(),
+			inst.Role(),
+			inst.GetHost(),
+			formatInstanceStatus(s),
+			since,
+			cpu,
+			memory,
+			leaders,
+			regions,
+		})
+	})
+
+	// the rows are sorted by ID to keep the order of the equal rows when the
+	// dashboard sorts them by the other columns
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return rows, nil
+}
+
+func (c *topCollector) parallel(fn func(inst spec.Instance)) {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, max(c.concurrency, 1))
+	for _, inst := range c.instances {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(inst spec.Instance) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			fn(inst)
+		}(inst)
+	}
+	wg.Wait()
+}
+
+// storeAddress returns the address the store of the instance is registered
+// with in PD, empty if the instance is not a store
+func storeAddress(inst spec.Instance) string {
+	switch inst := inst.(type) {
+	case *spec.TiKVInstance:
+		return utils.JoinHostPort(inst.GetHost(), inst.GetPort())
+	case *spec.TiFlashInstance:
+		return utils.JoinHostPort(inst.GetHost(), inst.GetServicePort())
+	}
+	return ""
+}
+
+// stores returns the stores keyed by the address
+func (c *topCollector) stores(ctx context.Context, pdList []string) map[string]*api.StoreInfo {
+	stores := make(map[string]*api.StoreInfo)
+	if len(pdList) == 0 {
+		return stores
+	}
+	pdClient := api.NewPDClient(
+		context.WithValue(ctx, logprinter.ContextKeyLogger, c.logger),
+		pdList,
+		c.timeout,
+		c.tlsCfg,
+	)
+	info, err := pdClient.GetStores()
+	if err != nil {
+		c.logger.Debugf("Failed to get the stores from PD: %s", err)
+		return stores
+	}
+	for _, store := range info.Stores {
+		if store.Store != nil && store.Store.Store != nil {
+			stores[store.Store.Address] = store
+		}
+	}
+	return stores
+}
+
+// cdcOwner returns the address of the CDC owner, empty if there is none
+func (c *topCollector) cdcOwner(ctx context.Context) string {
+	addrs := make([]string, 0)
+	for _, inst := range c.instances {
+		if inst.ComponentName() == spec.ComponentCDC {
+			addrs = append(addrs, utils.JoinHostPort(inst.GetManageHost(), inst.GetPort()))
+		}
+	}
+	if len(addrs) == 0 {
+		return ""
+	}
+	captures, err := api.NewCDCOpenAPIClient(ctx, addrs, c.timeout, c.tlsCfg).GetAllCaptures()
+	if err != nil {
+		c.logger.Debugf("Failed to get the CDC captures: %s", err)
+		return ""
+	}
+	for _, capture := range captures {
+		if capture.IsOwner {
+			return capture.AdvertiseAddr
+		}
+	}
+	return ""
+}
+
+// processes returns the stats of the processes of the instances keyed by the
+// instance ID, the process stats of a host are collected by insight at once
+func (c *topCollector) processes(ctx context.Context) map[string]*topProcess {
+	hosts := make(map[string][]spec.Instance)
+	for _, inst := range c.instances {
+		hosts[inst.GetManageHost()] = append(hosts[inst.GetManageHost()], inst)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	procs := make(map[string]*topProcess)
+	for host, insts := range hosts {
+		wg.Add(1)
+		go func(host string, insts []spec.Instance) {
+			defer wg.Done()
+			stats, err := c.hostProcesses(ctx, host, insts)
+			if err != nil {
+				c.logger.Debugf("Failed to get the process stats on %s: %s", host, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for id, stat := range stats {
+				procs[id] = stat
+			}
+		}(host, insts)
+	}
+	wg.Wait()
+	return procs
+}
+
+// topProcess is the process stat of an instance reported by insight
+type topProcess struct {
+	insight.ProcessStat
+	uptime    float64 // the uptime of the host in seconds
+	timestamp time.Time
+}
+
+func (c *topCollector) hostProcesses(ctx context.Context, host string, insts []spec.Instance) (map[string]*topProcess, error) {
+	e, found := ctxt.GetInner(ctx).GetExecutor(host)
+	if !found {
+		return nil, perrs.Errorf("no executor for host %s", host)
+	}
+
+	services := make([]string, 0, len(insts))
+	for _, inst := range insts {
+		services = append(services, inst.ServiceName())
+	}
+	nctx := checkpoint.NewContext(ctx)
+	pids, err := operator.GetServicePIDs(nctx, e, services, c.systemdMode, c.systemdMode)
+	if err != nil {
+		return nil, err
+	}
+	if len(pids) == 0 {
+		return nil, nil
+	}
+
+	pidList := make([]string, 0, len(pids))
+	for _, pid := range pids {
+		pidList = append(pidList, strconv.Itoa(pid))
+	}
+	sort.Strings(pidList)
+	cmd := fmt.Sprintf("%s --proc --pid=%s", insightBinary(topToolsDir), strings.Join(pidList, ","))
+	stdout, stderr, err := e.Execute(nctx, cmd, false)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "failed to run insight: %s", stderr)
+	}
+	var info insight.Info
+	if err := json.Unmarshal(stdout, &info); err != nil {
+		return nil, perrs.Annotatef(err, "failed to parse the output of insight")
+	}
+
+	stats := make(map[string]*topProcess)
+	for _, inst := range insts {
+		pid, ok := pids[inst.ServiceName()]
+		if !ok {
+			continue
+		}
+		for _, stat := range info.ProcStats {
+			if int(stat.Pid) == pid {
+				stats[inst.ID()] = &topProcess{stat, info.Meta.UPTime, info.Meta.Timestamp}
+			}
+		}
+	}
+	return stats, nil
+}
+
+// usage returns the CPU and memory usage of the process, the CPU usage is the
+// average since the last sample, or since the process started if there is no
+// sample of the process yet
+func (c *topCollector) usage(id string, proc *topProcess) (cpu, memory string) {
+	cpu, memory = "-", "-"
+	if proc.Memory != nil {
+		memory = units.BytesSize(float64(proc.Memory.RSS))
+	}
+	if proc.CPUTimes == nil {
+		return cpu, memory
+	}
+
+	cur := procSample{
+		pid:  proc.Pid,
+		cpu:  proc.CPUTimes.User + proc.CPUTimes.System,
+		time: proc.timestamp,
+	}
+	c.mu.Lock()
+	last, found := c.samples[id]
+	c.samples[id] = cur
+	c.mu.Unlock()
+
+	var used, elapsed float64
+	if found && last.pid == cur.pid && cur.time.After(last.time) {
+		used, elapsed = cur.cpu-last.cpu, cur.time.Sub(last.time).Seconds()
+	} else {
+		used, elapsed = cur.cpu, proc.uptime-proc.StartTime
+	}
+	if elapsed > 0 && used >= 0 {
+		cpu = fmt.Sprintf("%.1f%%", used/elapsed*100)
+	}
+	return cpu, memory
+}
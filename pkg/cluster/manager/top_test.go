@@ -0,0 +1,60 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/insight"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/process"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopUsage(t *testing.T) {
+	c := &topCollector{samples: make(map[string]procSample)}
+	now := time.Now()
+	proc := func(pid int32, cpuTime float64, at time.Time) *topProcess {
+		return &topProcess{
+			ProcessStat: insight.ProcessStat{
+				Pid:       pid,
+				StartTime: 100,
+				CPUTimes:  &cpu.TimesStat{User: cpuTime * 3 / 4, System: cpuTime / 4},
+				Memory:    &process.MemoryInfoStat{RSS: 3 << 29},
+			},
+			uptime:    300,
+			timestamp: at,
+		}
+	}
+
+	// the average since the process started without a sample
+	usage, memory := c.usage("tikv-1", proc(10, 100, now))
+	require.Equal(t, "50.0%", usage)
+	require.Equal(t, "1.5GiB", memory)
+
+	// the average since the last sample
+	usage, _ = c.usage("tikv-1", proc(10, 104, now.Add(2*time.Second)))
+	require.Equal(t, "200.0%", usage)
+
+	// the process is restarted
+	usage, _ = c.usage("tikv-1", proc(11, 20, now.Add(4*time.Second)))
+	require.Equal(t, "10.0%", usage)
+
+	// no cpu times reported
+	p := proc(11, 0, now)
+	p.CPUTimes = nil
+	usage, _ = c.usage("tikv-1", p)
+	require.Equal(t, "-", usage)
+}
@@ -15,6 +15,7 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -25,21 +26,22 @@ import (
 	perrs "github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/clusterutil"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
-	"github.com/pingcap/tiup/pkg/cluster/executor"
 	operator "github.com/pingcap/tiup/pkg/cluster/operation"
 	"github.com/pingcap/tiup/pkg/cluster/spec"
 	"github.com/pingcap/tiup/pkg/cluster/task"
 	"github.com/pingcap/tiup/pkg/environment"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/meta"
 	"github.com/pingcap/tiup/pkg/repository"
 	"github.com/pingcap/tiup/pkg/set"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
+	"go.uber.org/zap"
 	"golang.org/x/mod/semver"
 )
 
 func (m *Manager) upgradePrecheck(name string, componentVersions map[string]string, opt operator.Options, skipConfirm bool) error {
-	if !skipConfirm && strings.ToLower(opt.DisplayMode) != "json" {
+	if !skipConfirm && !opt.DryRun && !logprinter.ParseDisplayMode(opt.DisplayMode).Structured() {
 		for _, v := range componentVersions {
 			if v != "" {
 				m.logger.Warnf("%s", color.YellowString("tiup-cluster does not provide compatibility guarantees or version checks for different component versions. Please be aware of the risks or use it with the assistance of PingCAP support."))
@@ -66,13 +68,29 @@ func (m *Manager) Upgrade(name string, clusterVersion string, componentVersions
 		return err
 	}
 
+	unlock, err := m.lockCluster(name, opt)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	metadata, err := m.meta(name)
 	if err != nil {
 		return err
 	}
+	if !offline {
+		if err := checkMaintenance(name, metadata, opt); err != nil {
+			return err
+		}
+	}
 
 	topo := metadata.GetTopology()
 	base := metadata.GetBaseMeta()
+	versions := metaVersions(topo)
+	backupID, err := upgradeBackupID(name, base.Version)
+	if err != nil {
+		return err
+	}
 
 	// Adjust topo by new version
 	if clusterTopo, ok := topo.(*spec.Specification); ok {
@@ -134,7 +152,7 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 		opt.Concurrency,
 		color.HiYellowString(clusterVersion),
 		compVersionMsg)
-	if !skipConfirm {
+	if !skipConfirm && !opt.DryRun {
 		if err := tui.PromptForConfirmOrAbortError(`Do you want to continue? [y/N]:`); err != nil {
 			return err
 		}
@@ -186,7 +204,7 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 			}
 
 			// backup files of the old version
-			tb = tb.BackupComponent(inst.ComponentSource(), base.Version, inst.GetManageHost(), deployDir)
+			tb = tb.BackupComponent(inst.ComponentSource(), base.Version, backupID, inst.GetManageHost(), deployDir)
 
 			// this interface is not used
 			if deployerInstance, ok := inst.(DeployerInstance); ok {
@@ -236,12 +254,10 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 	}
 
 	var sshProxyProps *tui.SSHConnectionProps = &tui.SSHConnectionProps{}
-	if opt.SSHType != executor.SSHTypeNone {
+	if opt.SSHType.UsesSSH() {
 		var err error
-		if len(opt.SSHProxyHost) != 0 {
-			if sshProxyProps, err = tui.ReadIdentityFileOrPassword(opt.SSHProxyIdentity, opt.SSHProxyUsePassword); err != nil {
-				return err
-			}
+		if sshProxyProps, err = readSSHProxyProps(topo, opt); err != nil {
+			return err
 		}
 	}
 
@@ -275,23 +291,26 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 	)
 
 	// handle dir scheme changes
-	if hasImported {
+	if hasImported && !opt.DryRun {
 		if err := spec.HandleImportPathMigration(name); err != nil {
 			return err
 		}
 	}
-	ctx := ctxt.New(
+	ctx, closeSSH := ctxt.NewWithSSHPool(
 		context.Background(),
 		opt.Concurrency,
 		m.logger,
 	)
+	defer closeSSH()
+	ctx, cleanupPeers := task.WithPeerDistributor(ctx, opt.PeerFanout, topo)
+	defer cleanupPeers()
 	tlsCfg, err := topo.TLSConfig(m.specManager.Path(name, spec.TLSCertKeyDir))
 	if err != nil {
 		return err
 	}
 
 	// make sure the cluster is stopped
-	if offline && !opt.Force {
+	if offline && !opt.Force && !opt.DryRun {
 		running := false
 		topo.IterInstance(func(ins spec.Instance) {
 			if !running {
@@ -311,6 +330,7 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 	if err != nil {
 		return err
 	}
+	recorder := &operator.UpgradeRecorder{}
 	t := b.
 		Parallel(false, downloadCompTasks...).
 		ParallelStep("download monitored", false, dlTasks...).
@@ -339,11 +359,39 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 					}
 				}
 			}
-			return operator.Upgrade(ctx, topo, nopt, tlsCfg, base.Version, clusterVersion, waitFunc)
+			return operator.Upgrade(operator.WithUpgradeRecorder(ctx, recorder), topo, nopt, tlsCfg, base.Version, clusterVersion, waitFunc)
 		}).
 		Build()
 
+	if opt.DryRun {
+		var extra []string
+		if !offline {
+			extra = instancePlan("Rolling upgrade instances", components, operator.Options{Nodes: opt.Nodes})
+		}
+		return m.dryRun(name, "upgrade", t, extra...)
+	}
+
+	zap.L().Info(upgradeStartedMsg, zap.String("cluster", name), zap.String("from", base.Version), zap.String("to", clusterVersion),
+		zap.String("backup", backupID), zap.Any("versions", versions))
 	if err := t.Execute(ctx); err != nil {
+		if errors.Is(perrs.Cause(err), operator.ErrUpgradePaused) {
+			zap.L().Info(upgradePausedMsg, zap.String("cluster", name), zap.String("version", clusterVersion))
+			m.logger.Infof("Upgrade of cluster `%s` is paused, run `%s` to continue the upgrade",
+				name, color.YellowString("%s %s %s", tui.OsArgs0(), resumeUpgradeCommand, name))
+			return nil
+		}
+		if opt.RollbackOnFailure && !offline {
+			m.logger.Warnf("Upgrade of cluster `%s` failed, rolling back to %s", name, base.Version)
+			// the topology has been adjusted by the new version, reload it
+			original, merr := m.meta(name)
+			if merr != nil {
+				return perrs.Annotatef(err, "rollback failed: %s", merr)
+			}
+			if rerr := m.rollbackUpgrade(name, original, base.Version, clusterVersion, backupID, recorder.Instances(), opt); rerr != nil {
+				return perrs.Annotatef(err, "rollback failed: %s", rerr)
+			}
+			m.logger.Infof("Rolled back cluster `%s` to %s", name, base.Version)
+		}
 		if errorx.Cast(err) != nil {
 			// FIXME: Map possible task errors and give suggestions.
 			return err
@@ -366,6 +414,7 @@ This operation will upgrade %s %s cluster %s (with a concurrency of %d) to %s:%s
 	if err := m.specManager.SaveMeta(name, metadata); err != nil {
 		return err
 	}
+	zap.L().Info(upgradeFinishedMsg, zap.String("cluster", name), zap.String("version", clusterVersion))
 
 	m.logger.Infof("Upgraded cluster `%s` successfully", name)
 
@@ -0,0 +1,272 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"go.uber.org/zap"
+)
+
+const (
+	// upgradeStartedMsg is logged to the audit log when an upgrade is started,
+	// it records the versions before and after the upgrade
+	upgradeStartedMsg = "UpgradeStarted"
+	// upgradePausedMsg is logged to the audit log when an upgrade is paused
+	upgradePausedMsg = "UpgradePaused"
+	// upgradeResumedMsg is logged to the audit log when an upgrade is resumed,
+	// it records the arguments of the original upgrade command
+	upgradeResumedMsg = "UpgradeResumed"
+	// upgradeFinishedMsg is logged to the audit log when an upgrade is finished
+	upgradeFinishedMsg = "UpgradeFinished"
+
+	// the commands that upgrade a cluster
+	upgradeCommand       = "upgrade"
+	resumeUpgradeCommand = "resume-upgrade"
+)
+
+// upgradeAudit is the audit log of the latest upgrade of a cluster
+type upgradeAudit struct {
+	id      string
+	file    string
+	args    []string // the arguments of the upgrade command
+	records map[string]map[string]json.RawMessage
+}
+
+// latestUpgradeAudit finds the audit log of the latest upgrade of the cluster,
+// the upgrade may be run by the upgrade or the resume-upgrade command, which
+// is found in the recorded arguments by subcommand. It returns nil if there is
+// no upgrade of the cluster in the audit logs.
+func latestUpgradeAudit(name string, subcommand func(args []string) string) (*upgradeAudit, error) {
+	dir := spec.AuditDir()
+	auditList, err := audit.GetAuditList(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, perrs.AddStack(err)
+	}
+
+	for i := len(auditList) - 1; i >= 0; i-- {
+		file := filepath.Join(dir, auditList[i].ID)
+		args, err := audit.CommandArgs(file)
+		if err != nil || len(args) < 3 || !slices.Contains(args[1:], name) {
+			continue
+		}
+		cmd := subcommand(args)
+		if cmd != upgradeCommand && cmd != resumeUpgradeCommand {
+			continue
+		}
+
+		records, err := readAuditRecords(file, upgradeStartedMsg, upgradePausedMsg, upgradeResumedMsg)
+		if err != nil {
+			return nil, err
+		}
+		if cmd == resumeUpgradeCommand {
+			resumed, ok := records[upgradeResumedMsg]
+			if !ok {
+				// the resume is aborted before the upgrade is run
+				continue
+			}
+			args = nil
+			if err := json.Unmarshal(resumed["args"], &args); err != nil {
+				return nil, perrs.Annotatef(err, "invalid audit log %s", auditList[i].ID)
+			}
+		}
+
+		return &upgradeAudit{
+			id:      auditList[i].ID,
+			file:    file,
+			args:    args,
+			records: records,
+		}, nil
+	}
+	return nil, nil
+}
+
+// upgradeVersions are the versions in the meta which may be changed by an
+// upgrade, they're recorded before the upgrade to be restored by rollback
+type upgradeVersions struct {
+	Components       spec.ComponentVersions `json:"components"`
+	NodeExporter     string                 `json:"node_exporter"`
+	BlackboxExporter string                 `json:"blackbox_exporter"`
+}
+
+// metaVersions returns the versions of the topology which may be changed by
+// an upgrade
+func metaVersions(topo spec.Topology) upgradeVersions {
+	var v upgradeVersions
+	if cluster, ok := topo.(*spec.Specification); ok {
+		v.Components = cluster.ComponentVersions
+	}
+	if monitored := topo.GetMonitoredOptions(); monitored != nil {
+		v.NodeExporter = monitored.NodeExporterVersion
+		v.BlackboxExporter = monitored.BlackboxExporterVersion
+	}
+	return v
+}
+
+// restore sets the versions of the topology back
+func (v upgradeVersions) restore(topo spec.Topology) {
+	if cluster, ok := topo.(*spec.Specification); ok {
+		cluster.ComponentVersions = v.Components
+	}
+	if monitored := topo.GetMonitoredOptions(); monitored != nil {
+		monitored.NodeExporterVersion = v.NodeExporter
+		monitored.BlackboxExporterVersion = v.BlackboxExporter
+	}
+}
+
+// upgradeBackupID returns the ID stamped on the backups of the files replaced
+// by the upgrade. The ID of the latest upgrade of the cluster is reused if it
+// started from the same version and isn't finished, e.g. it's failed or paused,
+// as the files of the new version may have been copied to the hosts by it.
+func upgradeBackupID(name, fromVer string) (string, error) {
+	dir := spec.AuditDir()
+	auditList, err := audit.GetAuditList(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", perrs.AddStack(err)
+	}
+
+	for i := len(auditList) - 1; i >= 0; i-- {
+		file := filepath.Join(dir, auditList[i].ID)
+		if args, err := audit.CommandArgs(file); err != nil || !slices.Contains(args, name) {
+			continue
+		}
+		records, err := readAuditRecords(file, upgradeStartedMsg, upgradeFinishedMsg)
+		if err != nil {
+			return "", err
+		}
+		a := &upgradeAudit{records: records}
+		var cluster, from, backup string
+		if !a.recordField(upgradeStartedMsg, "cluster", &cluster) || cluster != name {
+			continue
+		}
+		if _, finished := records[upgradeFinishedMsg]; !finished &&
+			a.recordField(upgradeStartedMsg, "from", &from) && from == fromVer &&
+			a.recordField(upgradeStartedMsg, "backup", &backup) && backup != "" {
+			return backup, nil
+		}
+		break
+	}
+	return audit.ReserveAuditID(), nil
+}
+
+// recordField decodes the field of the record with the message into v, it
+// returns false if the record or the field doesn't exist.
+func (a *upgradeAudit) recordField(msg, field string, v any) bool {
+	record, ok := a.records[msg]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(record[field], v) == nil
+}
+
+// upgradedInstances returns the instances which have been restarted by the
+// upgrade, including the failed one and the ones restarted before it's resumed.
+func (a *upgradeAudit) upgradedInstances() ([]string, error) {
+	var instances []string
+	err := scanAuditLog(a.file, func(_, msg string, fields map[string]json.RawMessage) {
+		var fn, instance string
+		if msg != "CheckPoint" ||
+			json.Unmarshal(fields["__func__"], &fn) != nil ||
+			json.Unmarshal(fields["instance"], &instance) != nil {
+			return
+		}
+		if strings.HasSuffix(fn, ".upgradeInstance") && !slices.Contains(instances, instance) {
+			instances = append(instances, instance)
+		}
+	})
+	return instances, err
+}
+
+// PausedUpgrade finds the latest upgrade of the cluster in the audit logs, it
+// returns the audit ID and the arguments of the upgrade command if the upgrade
+// is paused. The subcommand returns the name of the subcommand of the recorded
+// arguments of a command.
+func (m *Manager) PausedUpgrade(name string, subcommand func(args []string) string) (string, []string, error) {
+	a, err := latestUpgradeAudit(name, subcommand)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var cluster string
+	if a == nil || !a.recordField(upgradePausedMsg, "cluster", &cluster) || cluster != name {
+		return "", nil, perrs.Errorf("the latest upgrade of cluster %s is not paused", name)
+	}
+	return a.id, a.args, nil
+}
+
+// ResumeUpgrade loads the checkpoints of the paused upgrade so that the
+// upgraded instances are skipped when the upgrade command is run again.
+func (m *Manager) ResumeUpgrade(auditID string, args []string) error {
+	if !checkpoint.HasCheckPoint() {
+		if err := checkpoint.SetCheckPoint(filepath.Join(spec.AuditDir(), auditID)); err != nil {
+			return perrs.Annotate(err, "set checkpoint failed")
+		}
+	}
+	zap.L().Info(upgradeResumedMsg, zap.String("audit", auditID), zap.Strings("args", args))
+	return nil
+}
+
+// readAuditRecords reads the fields of the audit log records with the given
+// messages, the last record wins if a message is logged more than once.
+func readAuditRecords(file string, msgs ...string) (map[string]map[string]json.RawMessage, error) {
+	records := make(map[string]map[string]json.RawMessage)
+	err := scanAuditLog(file, func(level, msg string, fields map[string]json.RawMessage) {
+		if slices.Contains(msgs, msg) {
+			records[msg] = fields
+		}
+	})
+	return records, err
+}
+
+// scanAuditLog calls fn with the level, message and fields of every structured
+// record in the audit log.
+func scanAuditLog(file string, fn func(level, msg string, fields map[string]json.RawMessage)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// the format of audit log records:
+		//	2021-01-13T14:11:02.987+0800    INFO    UpgradePaused      {k:v...}
+		ss := strings.Fields(line)
+		pos := strings.Index(line, "{")
+		if len(ss) < 4 || pos == -1 {
+			continue
+		}
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal([]byte(line[pos:]), &fields); err != nil {
+			// not a structured record, e.g. the output of a command
+			continue
+		}
+		fn(ss[1], ss[2], fields)
+	}
+	return perrs.AddStack(scanner.Err())
+}
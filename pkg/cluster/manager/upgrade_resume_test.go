@@ -0,0 +1,208 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/base52"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/localdata"
+	"github.com/stretchr/testify/require"
+)
+
+// subcommand returns the first argument which is not a flag or the value of
+// --ssh as the subcommand
+func subcommand(args []string) string {
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--ssh":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+	return ""
+}
+
+func writeUpgradeAudit(t *testing.T, ts time.Time, lines ...string) string {
+	id := base52.Encode(ts.UnixNano())
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(spec.AuditDir(), id), []byte(content), 0644))
+	return id
+}
+
+func TestPausedUpgrade(t *testing.T) {
+	t.Setenv(localdata.EnvNameComponentDataDir, t.TempDir())
+	require.NoError(t, spec.Initialize("cluster"))
+	require.NoError(t, os.MkdirAll(spec.AuditDir(), 0755))
+	m := NewManager("tidb", spec.GetSpecManager(), nil)
+
+	_, _, err := m.PausedUpgrade("test", subcommand)
+	require.Error(t, err)
+
+	now := time.Now()
+	upgradeArgs := []string{"tiup-cluster", "--ssh", "system", "upgrade", "test", "v8.1.0", "--pause-after-canary"}
+	paused := writeUpgradeAudit(t, now.Add(-3*time.Hour),
+		"tiup-cluster --ssh system upgrade test v8.1.0 --pause-after-canary",
+		"2025-01-01T00:00:00.000+0800\tINFO\tCheckPoint\t{\"canary\": \"pd\"}",
+		"2025-01-01T00:00:00.000+0800\tINFO\tUpgradePaused\t{\"cluster\": \"test\", \"version\": \"v8.1.0\"}",
+	)
+	// upgrades of other clusters are ignored
+	writeUpgradeAudit(t, now.Add(-2*time.Hour),
+		"tiup-cluster upgrade other v8.1.0",
+	)
+	// so are the other commands with the cluster name in the arguments
+	writeUpgradeAudit(t, now.Add(-2*time.Hour+time.Minute),
+		"tiup-cluster --ssh system display test",
+	)
+
+	id, args, err := m.PausedUpgrade("test", subcommand)
+	require.NoError(t, err)
+	require.Equal(t, paused, id)
+	require.Equal(t, upgradeArgs, args)
+
+	// the resumed upgrade is paused again
+	pausedAgain := writeUpgradeAudit(t, now.Add(-time.Hour),
+		"tiup-cluster resume-upgrade test",
+		"2025-01-01T01:00:00.000+0800\tINFO\tUpgradeResumed\t{\"audit\": \""+paused+"\", \"args\": [\"tiup-cluster\", \"--ssh\", \"system\", \"upgrade\", \"test\", \"v8.1.0\", \"--pause-after-canary\"]}",
+		"2025-01-01T01:00:00.000+0800\tINFO\tUpgradePaused\t{\"cluster\": \"test\", \"version\": \"v8.1.0\"}",
+	)
+	id, args, err = m.PausedUpgrade("test", subcommand)
+	require.NoError(t, err)
+	require.Equal(t, pausedAgain, id)
+	require.Equal(t, upgradeArgs, args)
+
+	// the resumed upgrade is finished
+	writeUpgradeAudit(t, now,
+		"tiup-cluster resume-upgrade test",
+		"2025-01-01T02:00:00.000+0800\tINFO\tUpgradeResumed\t{\"audit\": \""+pausedAgain+"\", \"args\": [\"tiup-cluster\", \"--ssh\", \"system\", \"upgrade\", \"test\", \"v8.1.0\", \"--pause-after-canary\"]}",
+	)
+	_, _, err = m.PausedUpgrade("test", subcommand)
+	require.Error(t, err)
+}
+
+func TestLatestUpgradeAudit(t *testing.T) {
+	t.Setenv(localdata.EnvNameComponentDataDir, t.TempDir())
+	require.NoError(t, spec.Initialize("cluster"))
+	require.NoError(t, os.MkdirAll(spec.AuditDir(), 0755))
+
+	a, err := latestUpgradeAudit("test", subcommand)
+	require.NoError(t, err)
+	require.Nil(t, a)
+
+	now := time.Now()
+	failed := writeUpgradeAudit(t, now.Add(-time.Hour),
+		"tiup-cluster upgrade test v8.1.0",
+		"2025-01-01T00:00:00.000+0800\tINFO\tUpgradeStarted\t{\"cluster\": \"test\", \"from\": \"v7.5.0\", \"to\": \"v8.1.0\"}",
+		"2025-01-01T00:00:00.000+0800\tINFO\tCheckPoint\t{\"instance\": \"pd1:2379\", \"__func__\": \"github.com/pingcap/tiup/pkg/cluster/operation.upgradeInstance\", \"hit\": false}",
+		"2025-01-01T00:00:00.000+0800\tINFO\tCheckPoint\t{\"host\": \"pd1\", \"cmd\": \"ls\", \"__func__\": \"github.com/pingcap/tiup/pkg/cluster/executor.(*CheckPointExecutor).Execute\", \"hit\": false}",
+		"2025-01-01T00:00:00.000+0800\tERROR\tCheckPoint\t{\"instance\": \"tikv1:20160\", \"error\": \"timeout\", \"__func__\": \"github.com/pingcap/tiup/pkg/cluster/operation.upgradeInstance\", \"hit\": false}",
+		"2025-01-01T00:00:00.000+0800\tINFO\tCheckPoint\t{\"instance\": \"pd1:2379\", \"__func__\": \"github.com/pingcap/tiup/pkg/cluster/operation.upgradeInstance\", \"hit\": true}",
+	)
+	writeUpgradeAudit(t, now,
+		"tiup-cluster display test",
+	)
+
+	a, err = latestUpgradeAudit("test", subcommand)
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	require.Equal(t, failed, a.id)
+
+	var from, to string
+	require.True(t, a.recordField(upgradeStartedMsg, "from", &from))
+	require.True(t, a.recordField(upgradeStartedMsg, "to", &to))
+	require.Equal(t, "v7.5.0", from)
+	require.Equal(t, "v8.1.0", to)
+	require.False(t, a.recordField(upgradePausedMsg, "cluster", &from))
+
+	instances, err := a.upgradedInstances()
+	require.NoError(t, err)
+	require.Equal(t, []string{"pd1:2379", "tikv1:20160"}, instances)
+}
+
+func TestUpgradeBackupID(t *testing.T) {
+	t.Setenv(localdata.EnvNameComponentDataDir, t.TempDir())
+	require.NoError(t, spec.Initialize("cluster"))
+	require.NoError(t, os.MkdirAll(spec.AuditDir(), 0755))
+
+	// a new ID is used without an unfinished upgrade
+	id, err := upgradeBackupID("test", "v7.5.0")
+	require.NoError(t, err)
+	require.Equal(t, audit.ReserveAuditID(), id)
+
+	now := time.Now()
+	writeUpgradeAudit(t, now.Add(-3*time.Hour),
+		"tiup-cluster upgrade test v8.1.0",
+		"2025-01-01T00:00:00.000+0800\tINFO\tUpgradeStarted\t{\"cluster\": \"test\", \"from\": \"v7.5.0\", \"to\": \"v8.1.0\", \"backup\": \"first\"}",
+	)
+	writeUpgradeAudit(t, now.Add(-2*time.Hour),
+		"tiup-cluster upgrade other v8.1.0",
+		"2025-01-01T00:00:00.000+0800\tINFO\tUpgradeStarted\t{\"cluster\": \"other\", \"from\": \"v7.5.0\", \"to\": \"v8.1.0\", \"backup\": \"other\"}",
+	)
+
+	// the failed upgrade is retried with its backups
+	id, err = upgradeBackupID("test", "v7.5.0")
+	require.NoError(t, err)
+	require.Equal(t, "first", id)
+	id, err = upgradeBackupID("test", "v7.1.0")
+	require.NoError(t, err)
+	require.Equal(t, audit.ReserveAuditID(), id)
+
+	// the backups of a finished upgrade are not reused by the next upgrade
+	writeUpgradeAudit(t, now.Add(-time.Hour),
+		"tiup-cluster upgrade test v8.1.0",
+		"2025-01-01T01:00:00.000+0800\tINFO\tUpgradeStarted\t{\"cluster\": \"test\", \"from\": \"v7.5.0\", \"to\": \"v8.1.0\", \"backup\": \"first\"}",
+		"2025-01-01T01:00:00.000+0800\tINFO\tUpgradeFinished\t{\"cluster\": \"test\", \"version\": \"v8.1.0\"}",
+	)
+	id, err = upgradeBackupID("test", "v7.5.0")
+	require.NoError(t, err)
+	require.Equal(t, audit.ReserveAuditID(), id)
+}
+
+func TestUpgradeVersions(t *testing.T) {
+	topo := &spec.Specification{}
+	topo.ComponentVersions.TiKV = "v7.5.1"
+	topo.MonitoredOptions.NodeExporterVersion = "v1.5.0"
+	versions, err := json.Marshal(metaVersions(topo))
+	require.NoError(t, err)
+
+	// the versions changed by the upgrade are restored from the audit log
+	topo.ComponentVersions.TiKV = "v8.1.1"
+	topo.ComponentVersions.TiDB = "v8.1.1"
+	topo.MonitoredOptions.NodeExporterVersion = "v1.8.0"
+	t.Setenv(localdata.EnvNameComponentDataDir, t.TempDir())
+	require.NoError(t, spec.Initialize("cluster"))
+	require.NoError(t, os.MkdirAll(spec.AuditDir(), 0755))
+	writeUpgradeAudit(t, time.Now(),
+		"tiup-cluster upgrade test v8.1.0 --tikv-version v8.1.1",
+		"2025-01-01T00:00:00.000+0800\tINFO\tUpgradeStarted\t{\"cluster\": \"test\", \"from\": \"v7.5.0\", \"to\": \"v8.1.0\", \"versions\": "+string(versions)+"}",
+	)
+	a, err := latestUpgradeAudit("test", subcommand)
+	require.NoError(t, err)
+	var recorded upgradeVersions
+	require.True(t, a.recordField(upgradeStartedMsg, "versions", &recorded))
+	recorded.restore(topo)
+	require.Equal(t, spec.ComponentVersions{TiKV: "v7.5.1"}, topo.ComponentVersions)
+	require.Equal(t, "v1.5.0", topo.MonitoredOptions.NodeExporterVersion)
+}
@@ -38,7 +38,7 @@ type Options struct {
 	APITimeout          uint64           // timeout in seconds for API operations that support it, like transferring store leader
 	IgnoreConfigCheck   bool             // should we ignore the config check result after init config
 	NativeSSH           bool             // should use native ssh client or builtin easy ssh (deprecated, should use SSHType)
-	SSHType             executor.SSHType // the ssh type: 'builtin', 'system', 'none'
+	SSHType             executor.SSHType // the ssh type: 'builtin', 'system', 'none', 'docker', 'podman', 'kubectl'
 	Concurrency         int              // max number of parallel tasks to run
 	SSHProxyHost        string           // the ssh proxy host
 	SSHProxyPort        int              // the ssh proxy port
@@ -57,8 +57,20 @@ type Options struct {
 	RetainDataRoles []string
 	RetainDataNodes []string
 
+	// Strategy of rolling upgrade
+	UpgradeCanary     bool // upgrade one canary instance of each component and check the cluster health before the others
+	UpgradeBatchSize  int  // max number of instances of a component upgraded at the same time
+	PauseAfterCanary  bool // pause the upgrade after the canary instance of each component is upgraded
+	UpgradeCheck      bool // check the cluster health after each step of the upgrade
+	RollbackOnFailure bool // roll back the upgraded instances to the previous version if the upgrade fails
+
+	RollingRestart bool // restart the instances one by one and wait for each of them to be ready
+
+	PeerFanout int // distribute the packages among the hosts, each of them and the control machine serves at most this many hosts at a time
+
 	DisplayMode string // the output format
 	Operation   Operation
+	DryRun      bool // only print the planned steps, don't execute them
 }
 
 // SSHCustomScripts represents the custom ssh script set to be executed during cluster operations
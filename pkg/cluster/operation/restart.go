@@ -0,0 +1,167 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// RollingRestart restarts the instances of the cluster one by one in the order
+// of upgrade. The leaders are evicted from an instance before it's restarted,
+// and the next instance is not restarted until the instance is ready to serve.
+func RollingRestart(
+	ctx context.Context,
+	topo spec.Topology,
+	options Options,
+	tlsCfg *tls.Config,
+	version string,
+) error {
+	roleFilter := set.NewStringSet(options.Roles...)
+	nodeFilter := set.NewStringSet(options.Nodes...)
+	components := topo.ComponentsByUpdateOrder(version)
+	components = FilterComponent(components, roleFilter)
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+	systemdMode := string(topo.BaseTopo().GlobalOptions.SystemdMode)
+
+	noAgentHosts := set.NewStringSet()
+	uniqueHosts := set.NewStringSet()
+	updcfg := &spec.UpdateConfig{
+		CurrentVersion: version,
+		TargetVersion:  version,
+	}
+
+	instances, err := restartOrder(components, nodeFilter, func(instance spec.Instance) (bool, error) {
+		return checkAndDeferPDLeader(ctx, topo, int(options.APITimeout), tlsCfg, instance)
+	})
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		uniqueHosts.Insert(instance.GetManageHost())
+		if instance.IgnoreMonitorAgent() {
+			noAgentHosts.Insert(instance.GetManageHost())
+		}
+	}
+
+	for i, instance := range instances {
+		if i == 0 || instances[i-1].ComponentName() != instance.ComponentName() {
+			logger.Infof("Restarting component %s", instance.ComponentName())
+		}
+		logger.Infof("\tRestarting instance %s", instance.ID())
+		if err := rollInstance(ctx, topo, instance, options, tlsCfg, updcfg); err != nil {
+			return err
+		}
+		if err := WaitInstanceReady(ctx, topo, instance, options, tlsCfg); err != nil {
+			return perrs.Annotatef(err, "instance %s is not ready after restart", instance.ID())
+		}
+	}
+
+	if topo.GetMonitoredOptions() == nil {
+		return nil
+	}
+
+	return RestartMonitored(ctx, uniqueHosts.Slice(), noAgentHosts, topo.GetMonitoredOptions(), options.OptTimeout, systemdMode)
+}
+
+// restartOrder returns the instances of the components to be restarted in
+// order, the PD related leaders/primaries of a component are restarted after
+// the others of it.
+func restartOrder(components []spec.Component, nodeFilter set.StringSet, isLeader func(spec.Instance) (bool, error)) ([]spec.Instance, error) {
+	var ordered []spec.Instance
+	for _, component := range components {
+		deferInstances := make([]spec.Instance, 0)
+		for _, instance := range FilterInstance(component.Instances(), nodeFilter) {
+			switch component.Name() {
+			case spec.ComponentPD, spec.ComponentTSO, spec.ComponentScheduling:
+				leader, err := isLeader(instance)
+				if err != nil {
+					return nil, err
+				}
+				if leader {
+					deferInstances = append(deferInstances, instance)
+					continue
+				}
+			}
+			ordered = append(ordered, instance)
+		}
+		ordered = append(ordered, deferInstances...)
+	}
+	return ordered, nil
+}
+
+// WaitInstanceReady waits for the (re)started instance to serve. The stores of
+// TiKV and TiFlash are checked to be Up in PD, the TiCDC capture to be alive,
+// and the status of PD and TiDB to be Up. Other components are ready once their
+// ports are listened, which is checked by the restart.
+func WaitInstanceReady(ctx context.Context, topo spec.Topology, instance spec.Instance, options Options, tlsCfg *tls.Config) error {
+	cluster, ok := topo.(*spec.Specification)
+	if !ok {
+		return nil
+	}
+
+	var check func() error
+	switch instance.ComponentName() {
+	case spec.ComponentTiKV, spec.ComponentTiFlash, spec.ComponentPD, spec.ComponentTiDB:
+		pdList := cluster.GetPDListWithManageHost()
+		check = func() error {
+			if status := instance.Status(ctx, 5*time.Second, tlsCfg, pdList...); !strings.HasPrefix(status, "Up") {
+				return perrs.Errorf("%s %s is %s", instance.ComponentName(), instance.ID(), status)
+			}
+			return nil
+		}
+	case spec.ComponentCDC:
+		client := api.NewCDCOpenAPIClient(ctx, []string{utils.JoinHostPort(instance.GetManageHost(), instance.GetPort())}, 5*time.Second, tlsCfg)
+		check = client.IsCaptureAlive
+	default:
+		return nil
+	}
+
+	return waitReady(check, options)
+}
+
+// readyCheckInterval is the interval between the checks of waitReady
+var readyCheckInterval = 2 * time.Second
+
+// waitReady retries the check until it passes or the API timeout of options is
+// reached, which is one minute if not set. The last error of the check is
+// returned on timeout.
+func waitReady(check func() error, options Options) error {
+	timeout := time.Duration(options.APITimeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	var lastErr error
+	err := utils.Retry(func() error {
+		lastErr = check()
+		return lastErr
+	}, utils.RetryOption{
+		Delay:   readyCheckInterval,
+		Timeout: timeout,
+	})
+	if err != nil && lastErr != nil {
+		return lastErr
+	}
+	return err
+}
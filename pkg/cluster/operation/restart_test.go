@@ -0,0 +1,103 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/set"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartOrder(t *testing.T) {
+	topo := &spec.Specification{
+		TiDBServers: []*spec.TiDBSpec{
+			{Host: "172.16.5.1", Port: 4000},
+		},
+		TiKVServers: []*spec.TiKVSpec{
+			{Host: "172.16.5.1", Port: 20160},
+			{Host: "172.16.5.2", Port: 20160},
+		},
+		PDServers: []*spec.PDSpec{
+			{Host: "172.16.5.1", ClientPort: 2379},
+			{Host: "172.16.5.2", ClientPort: 2379},
+			{Host: "172.16.5.3", ClientPort: 2379},
+		},
+	}
+	components := topo.ComponentsByUpdateOrder("v8.1.0")
+	isLeader := func(inst spec.Instance) (bool, error) {
+		return inst.ID() == "172.16.5.1:2379", nil
+	}
+
+	ids := func(instances []spec.Instance) []string {
+		var ids []string
+		for _, inst := range instances {
+			ids = append(ids, inst.ID())
+		}
+		return ids
+	}
+
+	// the PD leader is restarted after the other PD servers
+	instances, err := restartOrder(components, set.NewStringSet(), isLeader)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"172.16.5.2:2379", "172.16.5.3:2379", "172.16.5.1:2379",
+		"172.16.5.1:20160", "172.16.5.2:20160",
+		"172.16.5.1:4000",
+	}, ids(instances))
+
+	instances, err = restartOrder(components, set.NewStringSet("172.16.5.2:20160", "172.16.5.1:2379"), isLeader)
+	require.NoError(t, err)
+	require.Equal(t, []string{"172.16.5.1:2379", "172.16.5.2:20160"}, ids(instances))
+
+	_, err = restartOrder(components, set.NewStringSet(), func(spec.Instance) (bool, error) {
+		return false, errors.New("no leader")
+	})
+	require.Error(t, err)
+}
+
+func TestWaitReady(t *testing.T) {
+	interval := readyCheckInterval
+	readyCheckInterval = 10 * time.Millisecond
+	defer func() { readyCheckInterval = interval }()
+
+	// ready after some checks
+	count := 0
+	require.NoError(t, waitReady(func() error {
+		if count++; count < 3 {
+			return errors.New("not ready")
+		}
+		return nil
+	}, Options{APITimeout: 1}))
+	require.Equal(t, 3, count)
+
+	// the last error of the check is returned on timeout
+	start := time.Now()
+	err := waitReady(func() error {
+		return errors.New("store is Down")
+	}, Options{APITimeout: 1})
+	require.EqualError(t, err, "store is Down")
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// the instances not checked are ready at once
+	topo := &spec.Specification{
+		Grafanas: []*spec.GrafanaSpec{{Host: "172.16.5.1", Port: 3000}},
+	}
+	grafana := (&spec.GrafanaComponent{Topology: topo}).Instances()[0]
+	require.NoError(t, WaitInstanceReady(context.Background(), topo, grafana, Options{APITimeout: 1}, nil))
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"slices"
+	"sync"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/set"
+)
+
+type upgradeRecorderKey struct{}
+
+// UpgradeRecorder records the instances restarted by Upgrade, including the
+// one which failed to restart.
+type UpgradeRecorder struct {
+	mu        sync.Mutex
+	instances []string
+}
+
+// WithUpgradeRecorder returns a context which records the restarted instances
+// of Upgrade into r.
+func WithUpgradeRecorder(ctx context.Context, r *UpgradeRecorder) context.Context {
+	return context.WithValue(ctx, upgradeRecorderKey{}, r)
+}
+
+// Instances returns the IDs of the recorded instances.
+func (r *UpgradeRecorder) Instances() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.instances)
+}
+
+func recordUpgrade(ctx context.Context, instance spec.Instance) {
+	r, ok := ctx.Value(upgradeRecorderKey{}).(*UpgradeRecorder)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances = append(r.instances, instance.ID())
+}
+
+// Rollback restarts the instances in options.Nodes after their files have been
+// restored to the previous version, in the reverse order of upgrade. The
+// checkpoints of upgrade are not used, as all of the instances must be restarted.
+func Rollback(
+	ctx context.Context,
+	topo spec.Topology,
+	options Options,
+	tlsCfg *tls.Config,
+	currentVersion string,
+	previousVersion string,
+) error {
+	if len(options.Nodes) == 0 {
+		return nil
+	}
+
+	nodeFilter := set.NewStringSet(options.Nodes...)
+	components := topo.ComponentsByUpdateOrder(previousVersion)
+	slices.Reverse(components)
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+
+	updcfg := &spec.UpdateConfig{
+		CurrentVersion: currentVersion,
+		TargetVersion:  previousVersion,
+	}
+
+	for _, component := range components {
+		instances := FilterInstance(component.Instances(), nodeFilter)
+		if len(instances) < 1 {
+			continue
+		}
+		logger.Infof("Rolling back component %s", component.Name())
+
+		for _, instance := range instances {
+			if err := rollInstance(ctx, topo, instance, options, tlsCfg, updcfg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
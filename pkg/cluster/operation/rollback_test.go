@@ -0,0 +1,51 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeRecorder(t *testing.T) {
+	topo := &spec.Specification{
+		PDServers: []*spec.PDSpec{
+			{Host: "172.16.5.1", ClientPort: 2379},
+		},
+		TiKVServers: []*spec.TiKVSpec{
+			{Host: "172.16.5.1", Port: 20160},
+			{Host: "172.16.5.2", Port: 20160},
+		},
+	}
+	pd := (&spec.PDComponent{Topology: topo}).Instances()
+	tikv := (&spec.TiKVComponent{Topology: topo}).Instances()
+
+	// the instances are not recorded without a recorder
+	recordUpgrade(context.Background(), pd[0])
+
+	r := &UpgradeRecorder{}
+	ctx := WithUpgradeRecorder(context.Background(), r)
+	recordUpgrade(ctx, pd[0])
+	recordUpgrade(ctx, tikv[1])
+	instances := r.Instances()
+	require.Equal(t, []string{"172.16.5.1:2379", "172.16.5.2:20160"}, instances)
+
+	// the returned instances are not changed by later records
+	recordUpgrade(ctx, tikv[0])
+	require.Len(t, instances, 2)
+	require.Equal(t, []string{"172.16.5.1:2379", "172.16.5.2:20160", "172.16.5.1:20160"}, r.Instances())
+}
@@ -15,6 +15,7 @@ package operator
 
 import (
 	"context"
+	"strconv"
 	"strings"
 	"time"
 
@@ -96,3 +97,50 @@ func parseSystemctlSince(str string) (dur time.Duration) {
 
 	return time.Since(tm)
 }
+
+// GetServicePIDs returns the main PIDs of the services, the services not running
+// are omitted.
+func GetServicePIDs(ctx context.Context, e ctxt.Executor, names []string, scope string, systemdMode string) (map[string]int, error) {
+	c := module.SystemdModuleConfig{
+		Unit:        strings.Join(names, " "),
+		Action:      "show --property=Id,MainPID",
+		Scope:       scope,
+		SystemdMode: systemdMode,
+	}
+	stdout, stderr, err := module.NewSystemdModule(c).Execute(ctx, e)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to get the PIDs of services: %s", stderr)
+	}
+	return parseServicePIDs(string(stdout)), nil
+}
+
+// `systemctl show --property=Id,MainPID a.service b.service` returns as below,
+// the properties of the services are separated by blank lines
+// Id=a.service
+// MainPID=1234
+//
+// Id=b.service
+// MainPID=0
+func parseServicePIDs(str string) map[string]int {
+	pids := make(map[string]int)
+	for block := range strings.SplitSeq(str, "\n\n") {
+		var id string
+		var pid int
+		for line := range strings.SplitSeq(block, "\n") {
+			k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "Id":
+				id = v
+			case "MainPID":
+				pid, _ = strconv.Atoi(v)
+			}
+		}
+		if id != "" && pid > 0 {
+			pids[id] = pid
+		}
+	}
+	return pids
+}
@@ -133,6 +133,8 @@ func Upgrade(
 
 		// some instances are upgraded after others
 		deferInstances := make([]spec.Instance, 0)
+		// instances are rolled with the upgrade strategy
+		rollInstances := make([]spec.Instance, 0)
 
 		for _, instance := range instances {
 			// monitors
@@ -195,21 +197,11 @@ func Upgrade(
 				// do nothing, kept for future usage with other components
 			}
 
-			if err := upgradeInstance(ctx, topo, instance, options, tlsCfg, updcfg); err != nil {
-				return err
-			}
-
-			if waitFunc != nil {
-				waitFunc()
-			}
+			rollInstances = append(rollInstances, instance)
 		}
 
-		// process deferred instances
-		for _, instance := range deferInstances {
-			logger.Debugf("Upgrading deferred instance %s...", instance.ID())
-			if err := upgradeInstance(ctx, topo, instance, options, tlsCfg, updcfg); err != nil {
-				return err
-			}
+		if err := rollingUpgrade(ctx, topo, component.Name(), rollInstances, deferInstances, options, tlsCfg, updcfg, waitFunc); err != nil {
+			return err
 		}
 
 		switch component.Name() {
@@ -263,10 +255,24 @@ func upgradeInstance(
 		point.Release(err, zap.String("instance", instance.ID()))
 	}()
 
+	recordUpgrade(ctx, instance)
 	if point.Hit() != nil {
 		return nil
 	}
 
+	return rollInstance(ctx, topo, instance, options, tlsCfg, updcfg)
+}
+
+// rollInstance restarts an instance with the pre/post-upgrade commands and the
+// rolling update hooks of the instance
+func rollInstance(
+	ctx context.Context,
+	topo spec.Topology,
+	instance spec.Instance,
+	options Options,
+	tlsCfg *tls.Config,
+	updcfg *spec.UpdateConfig,
+) error {
 	var rollingInstance spec.RollingUpdateInstance
 	var isRollingInstance bool
 
@@ -274,7 +280,7 @@ func upgradeInstance(
 		rollingInstance, isRollingInstance = instance.(spec.RollingUpdateInstance)
 	}
 
-	err = executeSSHCommand(ctx, "Executing pre-upgrade command", instance.GetManageHost(),
+	err := executeSSHCommand(ctx, "Executing pre-upgrade command", instance.GetManageHost(),
 		fmt.Sprintf(`export NODE="%s";export ROLE="%s";%s`,
 			instance.ID(),
 			instance.Role(),
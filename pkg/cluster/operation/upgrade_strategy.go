@@ -0,0 +1,358 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tiup/pkg/checkpoint"
+	"github.com/pingcap/tiup/pkg/cluster/api"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/utils"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrUpgradePaused is returned when the upgrade is paused after the canary
+// instance of a component is upgraded.
+var ErrUpgradePaused = perrs.New("upgrade paused after canary")
+
+// register checkpoint for the pause after canary, so that the resumed upgrade
+// doesn't pause at the same component again
+var pausePoint = checkpoint.Register(checkpoint.Field("canary", reflect.DeepEqual))
+
+// rollingUpgrade upgrades the instances of a component with the upgrade strategy
+// of options. The first instance is upgraded as the canary if it's enabled, the
+// rest are upgraded in batches, the deferred instances are upgraded one by one
+// at last. The health of the cluster is checked after each step if it's
+// enabled by upgradeChecked.
+func rollingUpgrade(
+	ctx context.Context,
+	topo spec.Topology,
+	component string,
+	instances []spec.Instance,
+	deferInstances []spec.Instance,
+	options Options,
+	tlsCfg *tls.Config,
+	updcfg *spec.UpdateConfig,
+	waitFunc UpgradeWaitFunc,
+) error {
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+
+	if options.UpgradeCanary && len(instances)+len(deferInstances) > 0 {
+		var canary spec.Instance
+		if len(instances) > 0 {
+			canary, instances = instances[0], instances[1:]
+		} else {
+			canary, deferInstances = deferInstances[0], deferInstances[1:]
+		}
+
+		logger.Infof("Upgrading canary instance %s", canary.ID())
+		if err := upgradeInstance(ctx, topo, canary, options, tlsCfg, updcfg); err != nil {
+			return err
+		}
+		if upgradeChecked(options) {
+			if err := checkUpgradeHealth(ctx, topo, options, tlsCfg); err != nil {
+				return perrs.Annotatef(err, "cluster is unhealthy after upgrading canary instance %s", canary.ID())
+			}
+		}
+		if options.PauseAfterCanary {
+			if err := pauseAfterCanary(ctx, component, canary); err != nil {
+				return err
+			}
+		}
+		if waitFunc != nil {
+			waitFunc()
+		}
+	}
+
+	for _, batch := range upgradeBatches(ctx, topo, component, instances, options, tlsCfg) {
+		if err := upgradeBatch(ctx, topo, batch, options, tlsCfg, updcfg); err != nil {
+			return err
+		}
+		if upgradeChecked(options) {
+			if err := checkUpgradeHealth(ctx, topo, options, tlsCfg); err != nil {
+				return perrs.Annotatef(err, "cluster is unhealthy after upgrading %s", instanceIDs(batch))
+			}
+		}
+		if waitFunc != nil {
+			waitFunc()
+		}
+	}
+
+	// process deferred instances
+	for _, instance := range deferInstances {
+		logger.Debugf("Upgrading deferred instance %s...", instance.ID())
+		if err := upgradeInstance(ctx, topo, instance, options, tlsCfg, updcfg); err != nil {
+			return err
+		}
+		if upgradeChecked(options) {
+			if err := checkUpgradeHealth(ctx, topo, options, tlsCfg); err != nil {
+				return perrs.Annotatef(err, "cluster is unhealthy after upgrading %s", instance.ID())
+			}
+		}
+	}
+
+	return nil
+}
+
+// upgradeChecked returns whether the health of the whole cluster is checked
+// after each step of the upgrade. It's only checked for the canary, the batches
+// or if it's asked for, the default upgrade one by one relies on the readiness
+// of each upgraded instance.
+func upgradeChecked(options Options) bool {
+	if options.Force {
+		return false
+	}
+	return options.UpgradeCheck || options.UpgradeCanary || options.UpgradeBatchSize > 1
+}
+
+// upgradeBatches splits the instances of a component into the batches upgraded
+// at the same time. The stateless components are batched by the batch size of
+// options, the PD servers are always upgraded one by one. The stores of TiKV
+// and TiFlash are batched only if they can be restarted together without
+// losing the majority of the replicas of any region, see storeBatches.
+func upgradeBatches(
+	ctx context.Context,
+	topo spec.Topology,
+	component string,
+	instances []spec.Instance,
+	options Options,
+	tlsCfg *tls.Config,
+) [][]spec.Instance {
+	batchSize := max(options.UpgradeBatchSize, 1)
+	switch component {
+	case spec.ComponentTiDB,
+		spec.ComponentTiProxy,
+		spec.ComponentCDC,
+		spec.ComponentPrometheus,
+		spec.ComponentGrafana,
+		spec.ComponentAlertmanager:
+	case spec.ComponentTiKV, spec.ComponentTiFlash:
+		if batchSize > 1 {
+			domains, maxReplicas, err := storeDomains(ctx, topo, tlsCfg)
+			if err == nil {
+				return storeBatches(instances, batchSize, domains, maxReplicas)
+			}
+			logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+			logger.Warnf("Failed to get the failure domains of the stores, upgrading %s one by one: %s", component, err)
+		}
+		batchSize = 1
+	default:
+		batchSize = 1
+	}
+
+	var batches [][]spec.Instance
+	for start := 0; start < len(instances); start += batchSize {
+		batches = append(batches, instances[start:min(start+batchSize, len(instances))])
+	}
+	return batches
+}
+
+// storeBatches batches the stores in the same failure domain, at most
+// max-replicas - 1 of them in a batch. PD places the replicas of a region in
+// different failure domains, so the stores of a batch hold at most one replica
+// of each region if there are enough failure domains for the replicas. The
+// stores without a failure domain are upgraded one by one.
+func storeBatches(instances []spec.Instance, batchSize int, domains map[string]string, maxReplicas int) [][]spec.Instance {
+	count := make(map[string]int)
+	for _, domain := range domains {
+		count[domain]++
+	}
+	if len(count) < maxReplicas {
+		batchSize = 1
+	}
+	batchSize = max(min(batchSize, maxReplicas-1), 1)
+
+	var (
+		batches [][]spec.Instance
+		open    = make(map[string]int) // the index of the last batch of a domain
+	)
+	for _, inst := range instances {
+		domain, found := domains[storeAddr(inst)]
+		if idx, ok := open[domain]; found && ok && len(batches[idx]) < batchSize {
+			batches[idx] = append(batches[idx], inst)
+			continue
+		}
+		batches = append(batches, []spec.Instance{inst})
+		if found {
+			open[domain] = len(batches) - 1
+		}
+	}
+	return batches
+}
+
+// storeDomains returns the failure domains of the stores by their addresses
+// and the max replicas of the regions. The failure domain of a store is the
+// value of its label of the isolation level, or of the first location label if
+// the isolation level is not set.
+func storeDomains(ctx context.Context, topo spec.Topology, tlsCfg *tls.Config) (map[string]string, int, error) {
+	cluster, ok := topo.(*spec.Specification)
+	if !ok {
+		return nil, 0, perrs.New("not a TiDB cluster")
+	}
+	pdClient := api.NewPDClient(ctx, cluster.GetPDListWithManageHost(), 10*time.Second, tlsCfg)
+	data, err := pdClient.GetReplicateConfig()
+	if err != nil {
+		return nil, 0, err
+	}
+	rc := api.PDReplicationConfig{}
+	if err := json.Unmarshal(data, &rc); err != nil {
+		return nil, 0, perrs.Annotatef(err, "unmarshal replication config: %s", string(data))
+	}
+	level := rc.IsolationLevel
+	if level == "" && len(rc.LocationLabels) > 0 {
+		level = rc.LocationLabels[0]
+	}
+	if level == "" {
+		return nil, 0, perrs.New("replication.location-labels is not set")
+	}
+
+	stores, err := pdClient.GetStores()
+	if err != nil {
+		return nil, 0, err
+	}
+	domains := make(map[string]string)
+	for _, store := range stores.Stores {
+		if store.Store == nil || store.Store.Store == nil {
+			continue
+		}
+		for _, label := range store.Store.Labels {
+			if label.GetKey() == level {
+				domains[store.Store.Address] = label.GetValue()
+			}
+		}
+	}
+	return domains, int(rc.MaxReplicas), nil
+}
+
+// storeAddr returns the address of the store of a TiKV or TiFlash instance
+func storeAddr(inst spec.Instance) string {
+	if inst.ComponentName() == spec.ComponentTiFlash {
+		return utils.JoinHostPort(inst.GetHost(), inst.(*spec.TiFlashInstance).GetServicePort())
+	}
+	return utils.JoinHostPort(inst.GetHost(), inst.GetPort())
+}
+
+// upgradeBatch upgrades the instances at the same time
+func upgradeBatch(
+	ctx context.Context,
+	topo spec.Topology,
+	batch []spec.Instance,
+	options Options,
+	tlsCfg *tls.Config,
+	updcfg *spec.UpdateConfig,
+) error {
+	if len(batch) == 1 {
+		return upgradeInstance(ctx, topo, batch[0], options, tlsCfg, updcfg)
+	}
+
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+	logger.Infof("Upgrading instances %s", instanceIDs(batch))
+
+	errg, _ := errgroup.WithContext(ctx)
+	for _, instance := range batch {
+		// checkpoint must be in a new context
+		nctx := checkpoint.NewContext(ctx)
+		errg.Go(func() error {
+			return upgradeInstance(nctx, topo, instance, options, tlsCfg, updcfg)
+		})
+	}
+	return errg.Wait()
+}
+
+// pauseAfterCanary stops the upgrade after the canary instance of the component
+// is upgraded, unless it has been paused there before the upgrade is resumed.
+func pauseAfterCanary(ctx context.Context, component string, canary spec.Instance) error {
+	point := checkpoint.Acquire(ctx, pausePoint, map[string]any{"canary": component})
+	defer func() {
+		point.Release(nil, zap.String("canary", component))
+	}()
+
+	if point.Hit() != nil {
+		return nil
+	}
+
+	logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+	logger.Warnf("Canary instance %s of %s is upgraded, pausing the upgrade", canary.ID(), component)
+	return ErrUpgradePaused
+}
+
+// checkUpgradeHealth waits for the PD servers, the stores and the TiDB servers
+// to be healthy, the PD servers and stores are checked via the PD API and the
+// TiDB servers are checked via their status port.
+func checkUpgradeHealth(ctx context.Context, topo spec.Topology, options Options, tlsCfg *tls.Config) error {
+	cluster, ok := topo.(*spec.Specification)
+	if !ok {
+		return nil
+	}
+
+	return waitReady(func() error {
+		return clusterHealth(ctx, cluster, tlsCfg)
+	}, options)
+}
+
+func clusterHealth(ctx context.Context, cluster *spec.Specification, tlsCfg *tls.Config) error {
+	pdList := cluster.GetPDListWithManageHost()
+	if len(pdList) > 0 {
+		for _, addr := range pdList {
+			if err := api.NewPDClient(ctx, []string{addr}, 5*time.Second, tlsCfg).CheckHealth(); err != nil {
+				return perrs.Annotatef(err, "PD %s is unhealthy", addr)
+			}
+		}
+
+		stores, err := api.NewPDClient(ctx, pdList, 10*time.Second, tlsCfg).GetStores()
+		if err != nil {
+			return err
+		}
+		var unhealthy []string
+		for _, store := range stores.Stores {
+			switch {
+			case store.Store == nil || store.Store.Store == nil,
+				store.Store.State == metapb.StoreState_Tombstone,
+				store.Store.StateName == metapb.StoreState_Up.String(),
+				store.Store.StateName == metapb.StoreState_Offline.String():
+				continue
+			}
+			unhealthy = append(unhealthy, fmt.Sprintf("%s(%s)", store.Store.Address, store.Store.StateName))
+		}
+		if len(unhealthy) > 0 {
+			return perrs.Errorf("stores are not up: %s", strings.Join(unhealthy, ","))
+		}
+	}
+
+	for _, inst := range (&spec.TiDBComponent{Topology: cluster}).Instances() {
+		if status := inst.Status(ctx, 5*time.Second, tlsCfg); !strings.HasPrefix(status, "Up") {
+			return perrs.Errorf("TiDB %s is %s", inst.ID(), status)
+		}
+	}
+	return nil
+}
+
+func instanceIDs(instances []spec.Instance) string {
+	ids := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		ids = append(ids, inst.ID())
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func batchIDs(batches [][]spec.Instance) [][]string {
+	ids := make([][]string, 0, len(batches))
+	for _, batch := range batches {
+		var b []string
+		for _, inst := range batch {
+			b = append(b, inst.ID())
+		}
+		ids = append(ids, b)
+	}
+	return ids
+}
+
+func TestUpgradeBatches(t *testing.T) {
+	topo := &spec.Specification{
+		TiDBServers: []*spec.TiDBSpec{
+			{Host: "172.16.5.1", Port: 4000},
+			{Host: "172.16.5.2", Port: 4000},
+			{Host: "172.16.5.3", Port: 4000},
+		},
+		PDServers: []*spec.PDSpec{
+			{Host: "172.16.5.1", ClientPort: 2379},
+			{Host: "172.16.5.2", ClientPort: 2379},
+		},
+	}
+	ctx := context.WithValue(context.Background(), logprinter.ContextKeyLogger, logprinter.NewLogger(""))
+
+	tidb := (&spec.TiDBComponent{Topology: topo}).Instances()
+	require.Equal(t, [][]string{
+		{"172.16.5.1:4000", "172.16.5.2:4000"},
+		{"172.16.5.3:4000"},
+	}, batchIDs(upgradeBatches(ctx, topo, spec.ComponentTiDB, tidb, Options{UpgradeBatchSize: 2}, nil)))
+
+	pd := (&spec.PDComponent{Topology: topo}).Instances()
+	require.Equal(t, [][]string{
+		{"172.16.5.1:2379"},
+		{"172.16.5.2:2379"},
+	}, batchIDs(upgradeBatches(ctx, topo, spec.ComponentPD, pd, Options{UpgradeBatchSize: 2}, nil)))
+}
+
+func TestStoreBatches(t *testing.T) {
+	topo := &spec.Specification{
+		TiKVServers: []*spec.TiKVSpec{
+			{Host: "172.16.5.1", Port: 20160},
+			{Host: "172.16.5.2", Port: 20160},
+			{Host: "172.16.5.3", Port: 20160},
+			{Host: "172.16.5.4", Port: 20160},
+			{Host: "172.16.5.5", Port: 20160},
+			{Host: "172.16.5.6", Port: 20160},
+		},
+	}
+	tikv := (&spec.TiKVComponent{Topology: topo}).Instances()
+	domains := map[string]string{
+		"172.16.5.1:20160": "z1",
+		"172.16.5.2:20160": "z2",
+		"172.16.5.3:20160": "z3",
+		"172.16.5.4:20160": "z1",
+		"172.16.5.5:20160": "z1",
+	}
+
+	// the stores of a batch are in the same zone, at most 2 of them with 3 replicas
+	require.Equal(t, [][]string{
+		{"172.16.5.1:20160", "172.16.5.4:20160"},
+		{"172.16.5.2:20160"},
+		{"172.16.5.3:20160"},
+		{"172.16.5.5:20160"},
+		{"172.16.5.6:20160"},
+	}, batchIDs(storeBatches(tikv, 3, domains, 3)))
+
+	// not enough zones for the replicas
+	require.Len(t, storeBatches(tikv, 3, domains, 5), len(tikv))
+	require.Len(t, storeBatches(tikv, 3, domains, 1), len(tikv))
+}
+
+func TestUpgradeChecked(t *testing.T) {
+	require.False(t, upgradeChecked(Options{UpgradeBatchSize: 1}))
+	require.True(t, upgradeChecked(Options{UpgradeBatchSize: 1, UpgradeCheck: true}))
+	require.True(t, upgradeChecked(Options{UpgradeCanary: true}))
+	require.True(t, upgradeChecked(Options{UpgradeBatchSize: 3}))
+	require.False(t, upgradeChecked(Options{UpgradeBatchSize: 3, UpgradeCanary: true, Force: true}))
+}
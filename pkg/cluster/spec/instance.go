@@ -499,6 +499,22 @@ func (i *BaseInstance) SetPatched(p bool) {
 	v.SetBool(p)
 }
 
+// UpdateConfig merges the items into the instance level config, the config is
+// flattened so that the items override the nested ones. It returns false if the
+// instance doesn't have instance level config.
+func (i *BaseInstance) UpdateConfig(items map[string]any) bool {
+	v := reflect.Indirect(reflect.ValueOf(i.InstanceSpec)).FieldByName("Config")
+	if !v.CanSet() || v.Type() != reflect.TypeOf(map[string]any{}) {
+		return false
+	}
+	config := FlattenMap(v.Interface().(map[string]any))
+	for key, value := range items {
+		config[key] = value
+	}
+	v.Set(reflect.ValueOf(config))
+	return true
+}
+
 // CalculateVersion implements the Instance interface
 func (i *BaseInstance) CalculateVersion(globalVersion string) string {
 	return i.Component.CalculateVersion(globalVersion)
@@ -0,0 +1,205 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/tui"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ClusterLockName is the file lock held by the operations that change a
+	// cluster, it records the owner of the lock.
+	ClusterLockName = ".lock"
+)
+
+var (
+	// ErrClusterLocked is the error when the cluster is locked by another operation
+	ErrClusterLocked = errNS.NewType("cluster_locked")
+
+	// the locks held by the current process, an operation may call another
+	// one on the same cluster, e.g. apply calls upgrade
+	heldLocksMu sync.Mutex
+	heldLocks   = map[string]int{}
+)
+
+// ClusterLock is the owner of the lock of a cluster
+type ClusterLock struct {
+	User      string    `yaml:"user"`
+	PID       int       `yaml:"pid"`
+	Host      string    `yaml:"host"`
+	Command   string    `yaml:"command"`
+	StartTime time.Time `yaml:"start_time"`
+}
+
+// String implements the fmt.Stringer interface
+func (l *ClusterLock) String() string {
+	return fmt.Sprintf("`%s` by %s (pid %d) on %s since %s",
+		l.Command, l.User, l.PID, l.Host, l.StartTime.Format(time.RFC3339))
+}
+
+// Stale returns true if the owner of the lock runs on this host and has exited.
+func (l *ClusterLock) Stale() bool {
+	hostname, err := os.Hostname()
+	if err != nil || hostname != l.Host {
+		return false
+	}
+	return processExited(l.PID)
+}
+
+// owned returns true if the lock is held by the current process
+func (l *ClusterLock) owned() bool {
+	hostname, _ := os.Hostname()
+	return l.Host == hostname && l.PID == os.Getpid()
+}
+
+func newClusterLock() *ClusterLock {
+	lock := &ClusterLock{
+		PID:       os.Getpid(),
+		Command:   strings.Join(os.Args, " "),
+		StartTime: time.Now(),
+	}
+	lock.Host, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		lock.User = u.Username
+	}
+	return lock
+}
+
+// Lock acquires the lock of the cluster for the current process, it fails if
+// the lock is held by another process, unless that process is on this host and
+// has exited. The returned function releases the lock.
+func (s *SpecManager) Lock(clusterName string) (func(), error) {
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+
+	release := func() {
+		heldLocksMu.Lock()
+		defer heldLocksMu.Unlock()
+		s.release(clusterName)
+	}
+	if heldLocks[s.Path(clusterName)] > 0 {
+		heldLocks[s.Path(clusterName)]++
+		return release, nil
+	}
+
+	if err := s.ensureDir(clusterName); err != nil {
+		return nil, err
+	}
+
+	fname := s.Path(clusterName, ClusterLockName)
+	data, err := yaml.Marshal(newClusterLock())
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+
+	for retry := true; ; retry = false {
+		f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(fname)
+				return nil, perrs.AddStack(err)
+			}
+			heldLocks[s.Path(clusterName)] = 1
+			return release, nil
+		}
+		if !os.IsExist(err) {
+			return nil, perrs.AddStack(err)
+		}
+
+		owner, err := s.LockStatus(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case owner == nil && retry:
+			// released just now
+			continue
+		case owner != nil && owner.owned():
+			// the cluster is renamed from a locked one by the current process
+			heldLocks[s.Path(clusterName)] = 1
+			return release, nil
+		case owner != nil && owner.Stale() && retry:
+			if err := os.Remove(fname); err != nil && !os.IsNotExist(err) {
+				return nil, perrs.AddStack(err)
+			}
+			continue
+		case owner == nil:
+			return nil, ErrClusterLocked.New("Cluster `%s` is locked by another operation", clusterName)
+		}
+		return nil, ErrClusterLocked.New("Cluster `%s` is locked by %s", clusterName, owner).
+			WithProperty(tui.SuggestionFromFormat("Please wait for the operation to finish, or run `%s lock break %s` if it has exited.",
+				tui.OsArgs0(), clusterName))
+	}
+}
+
+// release decreases the count of the lock held by the current process and
+// removes the lock file once the count is zero, heldLocksMu must be held.
+func (s *SpecManager) release(clusterName string) {
+	path := s.Path(clusterName)
+	if heldLocks[path] == 0 {
+		return
+	}
+	heldLocks[path]--
+	if heldLocks[path] > 0 {
+		return
+	}
+	delete(heldLocks, path)
+
+	// the cluster may be destroyed or renamed
+	_ = os.Remove(s.Path(clusterName, ClusterLockName))
+	// remove the directory created by the lock if the cluster is not deployed
+	if exist, err := s.Exist(clusterName); err == nil && !exist {
+		_ = os.Remove(path)
+	}
+}
+
+// LockStatus returns the owner of the lock of the cluster, or nil if the cluster
+// is not locked.
+func (s *SpecManager) LockStatus(clusterName string) (*ClusterLock, error) {
+	data, err := os.ReadFile(s.Path(clusterName, ClusterLockName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, perrs.AddStack(err)
+	}
+
+	lock := &ClusterLock{}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, perrs.Annotatef(err, "invalid lock file of cluster %s", clusterName)
+	}
+	return lock, nil
+}
+
+// BreakLock removes the lock of the cluster regardless of its owner.
+func (s *SpecManager) BreakLock(clusterName string) error {
+	err := os.Remove(s.Path(clusterName, ClusterLockName))
+	if err != nil && !os.IsNotExist(err) {
+		return perrs.AddStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/joomcode/errorx"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeClusterLock(t *testing.T, s *SpecManager, name string, lock *ClusterLock) {
+	data, err := yaml.Marshal(lock)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(s.Path(name), 0755))
+	require.NoError(t, os.WriteFile(s.Path(name, ClusterLockName), data, 0644))
+}
+
+func TestClusterLock(t *testing.T) {
+	s := NewSpec(t.TempDir(), func() Metadata {
+		return new(TestMetadata)
+	})
+
+	lock, err := s.LockStatus("test")
+	require.NoError(t, err)
+	require.Nil(t, lock)
+
+	unlock, err := s.Lock("test")
+	require.NoError(t, err)
+	lock, err = s.LockStatus("test")
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), lock.PID)
+	require.False(t, lock.Stale())
+
+	// reentrant in the same process
+	unlock2, err := s.Lock("test")
+	require.NoError(t, err)
+	unlock2()
+	lock, err = s.LockStatus("test")
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	// the directory created by the lock is removed as the cluster is not deployed
+	unlock()
+	lock, err = s.LockStatus("test")
+	require.NoError(t, err)
+	require.Nil(t, lock)
+	require.NoDirExists(t, s.Path("test"))
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	// locked by a running process
+	writeClusterLock(t, s, "test", &ClusterLock{PID: os.Getppid(), Host: hostname, Command: "tiup-cluster reload test", StartTime: time.Now()})
+	_, err = s.Lock("test")
+	require.True(t, errorx.IsOfType(err, ErrClusterLocked))
+
+	// locked by a process on another host
+	writeClusterLock(t, s, "test", &ClusterLock{PID: os.Getppid(), Host: hostname + "-other"})
+	_, err = s.Lock("test")
+	require.True(t, errorx.IsOfType(err, ErrClusterLocked))
+
+	require.NoError(t, s.BreakLock("test"))
+	unlock, err = s.Lock("test")
+	require.NoError(t, err)
+	unlock()
+
+	// the stale lock of an exited process is taken over
+	writeClusterLock(t, s, "test", &ClusterLock{PID: 1 << 30, Host: hostname})
+	lock, err = s.LockStatus("test")
+	require.NoError(t, err)
+	require.True(t, lock.Stale())
+	unlock, err = s.Lock("test")
+	require.NoError(t, err)
+	lock, err = s.LockStatus("test")
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), lock.PID)
+	unlock()
+}
@@ -0,0 +1,28 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package spec
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processExited returns true if there is no process of the pid on this host
+func processExited(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err != nil && !errors.Is(err, syscall.EPERM)
+}
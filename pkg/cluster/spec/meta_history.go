@@ -0,0 +1,173 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/audit"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+const (
+	// HistoryDirName is the directory to save the versioned snapshots of meta file,
+	// the snapshots are named as {version}-{audit id}.yaml
+	HistoryDirName = "history"
+
+	// MaxMetaHistory is the number of the latest snapshots of meta file kept in
+	// the history, the older ones are removed when a new snapshot is saved
+	MaxMetaHistory = 100
+)
+
+// MetaSnapshot is a version of the meta file of a cluster
+type MetaSnapshot struct {
+	Version int
+	// AuditID is the ID of the audit log of the operation which saved the
+	// snapshot, it's empty for the meta file saved before the history exists
+	AuditID string
+	Time    time.Time
+	path    string
+}
+
+// MetaHistory returns the snapshots of the meta file of the cluster, the
+// oldest one comes first.
+func (s *SpecManager) MetaHistory(clusterName string) ([]*MetaSnapshot, error) {
+	entries, err := os.ReadDir(s.Path(clusterName, HistoryDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, perrs.AddStack(err)
+	}
+
+	var history []*MetaSnapshot
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		verStr, auditID, _ := strings.Cut(name, "-")
+		ver, err := strconv.Atoi(verStr)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, perrs.AddStack(err)
+		}
+		history = append(history, &MetaSnapshot{
+			Version: ver,
+			AuditID: auditID,
+			Time:    info.ModTime(),
+			path:    s.Path(clusterName, HistoryDirName, entry.Name()),
+		})
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Version < history[j].Version
+	})
+	return history, nil
+}
+
+// MetaSnapshot returns the content of the version of the meta file.
+func (s *SpecManager) MetaSnapshot(clusterName string, version int) ([]byte, error) {
+	history, err := s.MetaHistory(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range history {
+		if snapshot.Version == version {
+			data, err := os.ReadFile(snapshot.path)
+			return data, perrs.AddStack(err)
+		}
+	}
+	return nil, perrs.Errorf("version %d of the meta of cluster %s does not exist", version, clusterName)
+}
+
+// RawMetadata returns the content of the current meta file of the cluster.
+func (s *SpecManager) RawMetadata(clusterName string) ([]byte, error) {
+	data, err := os.ReadFile(s.Path(clusterName, metaFileName))
+	return data, perrs.AddStack(err)
+}
+
+// saveMetaSnapshot saves data as the next version of the meta file, nothing is
+// saved if it's the same as the latest version. The existing meta file is saved
+// as the first version if there is no history yet. Only the latest
+// MaxMetaHistory versions are kept.
+func (s *SpecManager) saveMetaSnapshot(clusterName string, data []byte) error {
+	history, err := s.MetaHistory(clusterName)
+	if err != nil {
+		return err
+	}
+	if err := utils.MkdirAll(s.Path(clusterName, HistoryDirName), 0755); err != nil {
+		return err
+	}
+
+	var latest []byte
+	if len(history) > 0 {
+		if latest, err = os.ReadFile(history[len(history)-1].path); err != nil {
+			return perrs.AddStack(err)
+		}
+	} else if latest, err = os.ReadFile(s.Path(clusterName, metaFileName)); err == nil {
+		if err := s.writeMetaSnapshot(clusterName, 1, "", latest); err != nil {
+			return err
+		}
+		history = append(history, &MetaSnapshot{Version: 1})
+	} else if !os.IsNotExist(err) {
+		return perrs.AddStack(err)
+	}
+
+	if bytes.Equal(latest, data) {
+		return nil
+	}
+	next := 1
+	if len(history) > 0 {
+		next = history[len(history)-1].Version + 1
+	}
+	if err := s.writeMetaSnapshot(clusterName, next, audit.ReserveAuditID(), data); err != nil {
+		return err
+	}
+	return s.pruneMetaHistory(clusterName, MaxMetaHistory)
+}
+
+// pruneMetaHistory removes the oldest snapshots of the meta file but the latest
+// keep ones.
+func (s *SpecManager) pruneMetaHistory(clusterName string, keep int) error {
+	history, err := s.MetaHistory(clusterName)
+	if err != nil {
+		return err
+	}
+	for len(history) > keep {
+		if err := os.Remove(history[0].path); err != nil && !os.IsNotExist(err) {
+			return perrs.AddStack(err)
+		}
+		history = history[1:]
+	}
+	return nil
+}
+
+func (s *SpecManager) writeMetaSnapshot(clusterName string, version int, auditID string, data []byte) error {
+	name := strconv.Itoa(version)
+	if auditID != "" {
+		name = fmt.Sprintf("%d-%s", version, auditID)
+	}
+	return utils.WriteFile(s.Path(clusterName, HistoryDirName, name+".yaml"), data, 0644)
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMetaHistory(t *testing.T) {
+	s := NewSpec(t.TempDir(), func() Metadata {
+		return new(TestMetadata)
+	})
+
+	history, err := s.MetaHistory("test")
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	// the meta saved before the history exists becomes the first version
+	meta1 := &TestMetadata{BaseMeta: BaseMeta{Version: "v1.0.0"}, Topo: &TestTopology{}}
+	data1, err := yaml.Marshal(meta1)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(s.Path("test"), 0755))
+	require.NoError(t, os.WriteFile(s.Path("test", metaFileName), data1, 0644))
+
+	meta2 := &TestMetadata{BaseMeta: BaseMeta{Version: "v2.0.0"}, Topo: &TestTopology{}}
+	require.NoError(t, s.SaveMeta("test", meta2))
+	// saving the same meta again doesn't make a new version
+	require.NoError(t, s.SaveMeta("test", meta2))
+
+	history, err = s.MetaHistory("test")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, 1, history[0].Version)
+	require.Empty(t, history[0].AuditID)
+	require.Equal(t, 2, history[1].Version)
+	require.NotEmpty(t, history[1].AuditID)
+
+	data, err := s.MetaSnapshot("test", 1)
+	require.NoError(t, err)
+	require.Equal(t, data1, data)
+
+	data, err = s.MetaSnapshot("test", 2)
+	require.NoError(t, err)
+	getMeta := new(TestMetadata)
+	require.NoError(t, yaml.Unmarshal(data, getMeta))
+	require.Equal(t, meta2, getMeta)
+
+	_, err = s.MetaSnapshot("test", 3)
+	require.Error(t, err)
+
+	// only the latest versions are kept
+	meta3 := &TestMetadata{BaseMeta: BaseMeta{Version: "v3.0.0"}, Topo: &TestTopology{}}
+	require.NoError(t, s.SaveMeta("test", meta3))
+	require.NoError(t, s.pruneMetaHistory("test", 2))
+	history, err = s.MetaHistory("test")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, 2, history[0].Version)
+	require.Equal(t, 3, history[1].Version)
+}
@@ -91,6 +91,9 @@ type (
 		Group           string               `yaml:"group,omitempty"`
 		SSHPort         int                  `yaml:"ssh_port,omitempty" default:"22" validate:"ssh_port:editable"`
 		SSHType         executor.SSHType     `yaml:"ssh_type,omitempty" default:"builtin"`
+		SSHAgent        bool                 `yaml:"ssh_agent,omitempty" validate:"ssh_agent:editable"`
+		SSHProxy        string               `yaml:"ssh_proxy,omitempty" validate:"ssh_proxy:editable"`
+		SSHProxyHosts   map[string]string    `yaml:"ssh_proxy_hosts,omitempty" validate:"ssh_proxy_hosts:ignore"`
 		TLSEnabled      bool                 `yaml:"enable_tls,omitempty"`
 		ListenHost      string               `yaml:"listen_host,omitempty" validate:"listen_host:editable"`
 		DeployDir       string               `yaml:"deploy_dir,omitempty" default:"deploy"`
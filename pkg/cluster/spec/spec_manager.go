@@ -112,6 +112,10 @@ func (s *SpecManager) SaveMeta(clusterName string, meta Metadata) error {
 		*opsVer = version.NewTiUPVersion().String()
 	}
 
+	if err := s.saveMetaSnapshot(clusterName, data); err != nil {
+		return wrapError(err)
+	}
+
 	err = utils.SaveFileWithBackup(metaFile, data, backupDir)
 	if err != nil {
 		return wrapError(err)
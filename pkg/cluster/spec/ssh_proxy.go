@@ -0,0 +1,105 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+)
+
+// SSHProxyNone in ssh_proxy_hosts connects to the host directly
+const SSHProxyNone = "none"
+
+// SSHProxyOf returns the jump hosts to connect to the host through via SSH, in
+// the ProxyJump format of OpenSSH. The host is looked up in ssh_proxy_hosts by
+// its address first, then by the CIDR blocks containing it, the narrowest block
+// wins, and the global ssh_proxy is used if none matches. An empty string is
+// returned if the host is connected directly.
+func (g *GlobalOptions) SSHProxyOf(host string) string {
+	jump, ok := g.SSHProxyHosts[host]
+	if !ok {
+		jump, ok = g.sshProxyOfCIDR(host)
+	}
+	if !ok {
+		jump = g.SSHProxy
+	}
+	if jump == SSHProxyNone {
+		return ""
+	}
+	return jump
+}
+
+func (g *GlobalOptions) sshProxyOfCIDR(host string) (string, bool) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", false
+	}
+	jump, prefix := "", -1
+	for block, j := range g.SSHProxyHosts {
+		_, ipNet, err := net.ParseCIDR(block)
+		if err != nil || !ipNet.Contains(ip) {
+			continue
+		}
+		if ones, _ := ipNet.Mask.Size(); ones > prefix {
+			jump, prefix = j, ones
+		}
+	}
+	return jump, prefix >= 0
+}
+
+// ValidateSSHProxy checks the jump hosts set in ssh_proxy and ssh_proxy_hosts
+func (g *GlobalOptions) ValidateSSHProxy() error {
+	if g.SSHProxy != "" {
+		if _, err := executor.ParseProxyJump(g.SSHProxy, executor.SSHConfig{}); err != nil {
+			return errors.Annotate(err, "`global` of ssh_proxy is invalid")
+		}
+	}
+	for host, jump := range g.SSHProxyHosts {
+		if jump == SSHProxyNone {
+			continue
+		}
+		if _, err := executor.ParseProxyJump(jump, executor.SSHConfig{}); err != nil {
+			return errors.Annotatef(err, "`global` of ssh_proxy_hosts for '%s' is invalid", host)
+		}
+	}
+	return nil
+}
+
+// HasSSHProxy returns if any host of the topology is connected through jump hosts
+func (g *GlobalOptions) HasSSHProxy() bool {
+	if g.SSHProxy != "" && g.SSHProxy != SSHProxyNone {
+		return true
+	}
+	for _, jump := range g.SSHProxyHosts {
+		if jump != SSHProxyNone {
+			return true
+		}
+	}
+	return false
+}
+
+// SSHProxies returns the jump hosts of the hosts of the topology connected
+// through jump hosts, by the hosts
+func SSHProxies(topo Topology) map[string]string {
+	g := topo.BaseTopo().GlobalOptions
+	proxies := make(map[string]string)
+	topo.IterInstance(func(inst Instance) {
+		if jump := g.SSHProxyOf(inst.GetManageHost()); jump != "" {
+			proxies[inst.GetManageHost()] = jump
+		}
+	})
+	return proxies
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSSHProxies(t *testing.T) {
+	topo := Specification{}
+	err := yaml.Unmarshal([]byte(`
+global:
+  user: "tidb"
+  ssh_proxy: "ops@bastion-a:2222,bastion-b"
+  ssh_proxy_hosts:
+    10.1.0.0/16: "bastion-c"
+    10.1.2.0/24: "ops@bastion-d"
+    10.1.2.5: "none"
+    tikv-x: "bastion-e"
+pd_servers:
+  - host: 10.0.1.1
+tidb_servers:
+  - host: 10.1.2.1
+  - host: 10.1.3.1
+tikv_servers:
+  - host: 10.1.2.5
+  - host: tikv-x
+`), &topo)
+	require.NoError(t, err)
+	require.NoError(t, topo.Validate())
+	require.True(t, topo.GlobalOptions.HasSSHProxy())
+
+	require.Equal(t, map[string]string{
+		"10.0.1.1": "ops@bastion-a:2222,bastion-b",
+		"10.1.2.1": "ops@bastion-d",
+		"10.1.3.1": "bastion-c",
+		"tikv-x":   "bastion-e",
+	}, SSHProxies(&topo))
+
+	topo.GlobalOptions.SSHProxyHosts["10.1.3.0/24"] = "bastion-f:port"
+	require.Error(t, topo.Validate())
+
+	g := GlobalOptions{SSHProxy: SSHProxyNone}
+	require.False(t, g.HasSSHProxy())
+	require.Equal(t, "", g.SSHProxyOf("10.0.1.1"))
+}
@@ -45,6 +45,8 @@ type ClusterMeta struct {
 	Version string `yaml:"tidb_version"` // the version of TiDB cluster
 	// EnableFirewall bool   `yaml:"firewall"`
 	OpsVer string `yaml:"last_ops_ver,omitempty"` // the version of ourself that updated the meta last time
+	// the hosts under maintenance, the instances on them are stopped
+	MaintenanceHosts []string `yaml:"maintenance_hosts,omitempty"`
 
 	Topology *Specification `yaml:"topology"`
 }
@@ -85,6 +87,16 @@ func (m *ClusterMeta) GetBaseMeta() *BaseMeta {
 	}
 }
 
+// InMaintenance returns true if the host of the instance is under maintenance.
+func (m *ClusterMeta) InMaintenance(inst Instance) bool {
+	for _, host := range m.MaintenanceHosts {
+		if inst.GetHost() == host || inst.GetManageHost() == host {
+			return true
+		}
+	}
+	return false
+}
+
 // AuditDir return the directory for saving audit log.
 func AuditDir() string {
 	return filepath.Join(profileDir, TiUPAuditDir)
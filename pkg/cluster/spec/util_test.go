@@ -59,3 +59,14 @@ func TestMultiDirAbs(t *testing.T) {
 	require.Equal(t, "/home/tidb/a", paths[0])
 	require.Equal(t, "/tmp/b", paths[1])
 }
+
+func TestInMaintenance(t *testing.T) {
+	meta := &ClusterMeta{MaintenanceHosts: []string{"172.16.5.1", "10.0.1.2"}}
+
+	inst := &TiKVInstance{BaseInstance: BaseInstance{Host: "172.16.5.1"}}
+	require.True(t, meta.InMaintenance(inst))
+	inst = &TiKVInstance{BaseInstance: BaseInstance{Host: "tikv-2", ManageHost: "10.0.1.2"}}
+	require.True(t, meta.InMaintenance(inst))
+	inst = &TiKVInstance{BaseInstance: BaseInstance{Host: "172.16.5.3"}}
+	require.False(t, meta.InMaintenance(inst))
+}
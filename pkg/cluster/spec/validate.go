@@ -1100,6 +1100,7 @@ func (s *Specification) Validate() error {
 		s.validateTiSparkSpec,
 		s.validateTiFlashConfigs,
 		s.validateMonitorAgent,
+		s.GlobalOptions.ValidateSSHProxy,
 	}
 
 	for _, v := range validators {
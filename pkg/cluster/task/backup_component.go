@@ -23,11 +23,25 @@ import (
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
 )
 
+// backupDirs are the directories in the deploy dir which are backed up before upgrade
+var backupDirs = []string{"bin", "conf", "scripts"}
+
+// backupPath returns the path of the backup of dir for the version, the backup
+// is stamped with the ID if it's not empty
+func backupPath(deployDir, dir, version, id string) string {
+	path := filepath.Join(deployDir, dir) + ".old." + version
+	if id != "" {
+		path += "." + id
+	}
+	return path
+}
+
 // BackupComponent is used to copy all files related the specific version a component
 // to the target directory of path
 type BackupComponent struct {
 	component string
 	fromVer   string
+	backupID  string // stamped on the backup, the backup of an earlier attempt with the same ID is kept
 	host      string
 	deployDir string
 }
@@ -40,22 +54,23 @@ func (c *BackupComponent) Execute(ctx context.Context) error {
 		return ErrNoExecutor
 	}
 
-	binDir := filepath.Join(c.deployDir, "bin")
-
-	// Make upgrade idempotent
-	// The old version has been backup if upgrade abort
-	cmd := fmt.Sprintf(`test -d %[2]s || cp -r %[1]s %[2]s`, binDir, binDir+".old."+c.fromVer)
-	_, stderr, err := exec.Execute(ctx, cmd, false)
-	if err != nil {
-		// ignore error if the source path does not exist, this is possible when
-		// there are multiple instances share the same deploy_dir, typical case
-		// is imported cluster
-		// NOTE: by changing the behaviour to cp instead of mv in line 45, we don't
-		// need to check "no such file" anymore, but I'm keeping it here in case
-		// we got a better way handling the backups later
-		if !(bytes.Contains(stderr, []byte("No such file or directory")) ||
-			bytes.Contains(stderr, []byte("File exists"))) {
-			return errors.Annotate(err, cmd)
+	for _, dir := range backupDirs {
+		// Make upgrade idempotent
+		// The old version has been backup if upgrade abort
+		cmd := fmt.Sprintf(`test -d %[2]s || cp -r %[1]s %[2]s`,
+			filepath.Join(c.deployDir, dir), backupPath(c.deployDir, dir, c.fromVer, c.backupID))
+		_, stderr, err := exec.Execute(ctx, cmd, false)
+		if err != nil {
+			// ignore error if the source path does not exist, this is possible when
+			// there are multiple instances share the same deploy_dir, typical case
+			// is imported cluster
+			// NOTE: by changing the behaviour to cp instead of mv in line 45, we don't
+			// need to check "no such file" anymore, but I'm keeping it here in case
+			// we got a better way handling the backups later
+			if !(bytes.Contains(stderr, []byte("No such file or directory")) ||
+				bytes.Contains(stderr, []byte("File exists"))) {
+				return errors.Annotate(err, cmd)
+			}
 		}
 	}
 	return nil
@@ -68,6 +83,45 @@ func (c *BackupComponent) Rollback(ctx context.Context) error {
 
 // String implements the fmt.Stringer interface
 func (c *BackupComponent) String() string {
-	return fmt.Sprintf("BackupComponent: component=%s, currentVersion=%s, remote=%s:%s",
-		c.component, c.fromVer, c.host, c.deployDir)
+	return fmt.Sprintf("BackupComponent: component=%s, currentVersion=%s, backup=%s, remote=%s:%s",
+		c.component, c.fromVer, c.backupID, c.host, c.deployDir)
+}
+
+// RestoreComponent is used to restore the files of a component backed up by
+// BackupComponent, the directories without backup are left untouched. The
+// backups are moved back, so they're not reused by a later upgrade.
+type RestoreComponent struct {
+	component string
+	toVer     string
+	backupID  string
+	host      string
+	deployDir string
+}
+
+// Execute implements the Task interface
+func (c *RestoreComponent) Execute(ctx context.Context) error {
+	exec, found := ctxt.GetInner(ctx).GetExecutor(c.host)
+	if !found {
+		return ErrNoExecutor
+	}
+
+	for _, dir := range backupDirs {
+		cmd := fmt.Sprintf(`if [ -d %[2]s ]; then rm -rf %[1]s && mv %[2]s %[1]s; fi`,
+			filepath.Join(c.deployDir, dir), backupPath(c.deployDir, dir, c.toVer, c.backupID))
+		if _, _, err := exec.Execute(ctx, cmd, false); err != nil {
+			return errors.Annotate(err, cmd)
+		}
+	}
+	return nil
+}
+
+// Rollback implements the Task interface
+func (c *RestoreComponent) Rollback(ctx context.Context) error {
+	return ErrUnsupportedRollback
+}
+
+// String implements the fmt.Stringer interface
+func (c *RestoreComponent) String() string {
+	return fmt.Sprintf("RestoreComponent: component=%s, restoreVersion=%s, backup=%s, remote=%s:%s",
+		c.component, c.toVer, c.backupID, c.host, c.deployDir)
 }
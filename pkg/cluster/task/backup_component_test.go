@@ -0,0 +1,79 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupPath(t *testing.T) {
+	require.Equal(t, "/deploy/bin.old.v7.5.0", backupPath("/deploy", "bin", "v7.5.0", ""))
+	require.Equal(t, "/deploy/conf.old.v7.5.0.fTs8sVvqfZ", backupPath("/deploy", "conf", "v7.5.0", "fTs8sVvqfZ"))
+}
+
+func TestBackupAndRestoreComponent(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+	ctx := ctxt.New(context.Background(), 0, logprinter.NewLogger(""))
+	ctxt.GetInner(ctx).SetExecutor("h1", &executor.Local{Config: &executor.SSHConfig{Host: "h1", User: current.Username}})
+
+	deployDir := t.TempDir()
+	write := func(dir, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(deployDir, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(deployDir, dir, "file"), []byte(content), 0644))
+	}
+	read := func(dir string) string {
+		data, err := os.ReadFile(filepath.Join(deployDir, dir, "file"))
+		require.NoError(t, err)
+		return string(data)
+	}
+	backup := func(id string) {
+		b := NewBuilder(logprinter.NewLogger("")).BackupComponent("tikv", "v7.5.0", id, "h1", deployDir)
+		require.NoError(t, b.Build().Execute(ctx))
+	}
+	write("bin", "v7.5.0")
+	write("conf", "conf 1")
+
+	// the backup of an earlier attempt of the same upgrade is kept
+	backup("a")
+	write("bin", "v8.1.0")
+	backup("a")
+	require.Equal(t, "v7.5.0", read("bin.old.v7.5.0.a"))
+	require.Equal(t, "conf 1", read("conf.old.v7.5.0.a"))
+	require.NoDirExists(t, filepath.Join(deployDir, "scripts.old.v7.5.0.a"))
+
+	// another upgrade backs up the current files
+	write("conf", "conf 2")
+	backup("b")
+	require.Equal(t, "v8.1.0", read("bin.old.v7.5.0.b"))
+	require.Equal(t, "conf 2", read("conf.old.v7.5.0.b"))
+
+	// the backups are moved back
+	b := NewBuilder(logprinter.NewLogger("")).RestoreComponent("tikv", "v7.5.0", "a", "h1", deployDir)
+	require.NoError(t, b.Build().Execute(ctx))
+	require.Equal(t, "v7.5.0", read("bin"))
+	require.Equal(t, "conf 1", read("conf"))
+	require.NoDirExists(t, filepath.Join(deployDir, "bin.old.v7.5.0.a"))
+	require.NoDirExists(t, filepath.Join(deployDir, "conf.old.v7.5.0.a"))
+	require.DirExists(t, filepath.Join(deployDir, "bin.old.v7.5.0.b"))
+}
@@ -44,8 +44,8 @@ func NewBuilder(logger *logprinter.Logger) *Builder {
 
 // RootSSH appends a RootSSH task to the current task collection
 func (b *Builder) RootSSH(
-	host string, port int, user, password, keyFile, passphrase string, sshTimeout, exeTimeout uint64,
-	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHTimeout uint64,
+	host string, port int, user, password, keyFile, passphrase string, sshAgent bool, sshTimeout, exeTimeout uint64,
+	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHAgent bool, proxySSHTimeout uint64,
 	sshType, defaultSSHType executor.SSHType, sudo bool,
 ) *Builder {
 	if sshType == "" {
@@ -58,6 +58,7 @@ func (b *Builder) RootSSH(
 		password:        password,
 		keyFile:         keyFile,
 		passphrase:      passphrase,
+		sshAgent:        sshAgent,
 		timeout:         sshTimeout,
 		exeTimeout:      exeTimeout,
 		proxyHost:       proxyHost,
@@ -66,6 +67,7 @@ func (b *Builder) RootSSH(
 		proxyPassword:   proxyPassword,
 		proxyKeyFile:    proxyKeyFile,
 		proxyPassphrase: proxyPassphrase,
+		proxySSHAgent:   proxySSHAgent,
 		proxyTimeout:    proxySSHTimeout,
 		sshType:         sshType,
 		sudo:            sudo,
@@ -88,6 +90,7 @@ func NewSimpleUerSSH(logger *logprinter.Logger, host string, port int, user stri
 			p.Password,
 			p.IdentityFile,
 			p.IdentityFilePassphrase,
+			p.SSHAgent,
 			gOpt.SSHProxyTimeout,
 			gOpt.SSHType,
 			sshType,
@@ -97,7 +100,7 @@ func NewSimpleUerSSH(logger *logprinter.Logger, host string, port int, user stri
 // UserSSH append a UserSSH task to the current task collection
 func (b *Builder) UserSSH(
 	host string, port int, deployUser string, sshTimeout, exeTimeout uint64,
-	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHTimeout uint64,
+	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHAgent bool, proxySSHTimeout uint64,
 	sshType, defaultSSHType executor.SSHType,
 ) *Builder {
 	if sshType == "" {
@@ -115,6 +118,7 @@ func (b *Builder) UserSSH(
 		proxyPassword:   proxyPassword,
 		proxyKeyFile:    proxyKeyFile,
 		proxyPassphrase: proxyPassphrase,
+		proxySSHAgent:   proxySSHAgent,
 		proxyTimeout:    proxySSHTimeout,
 		sshType:         sshType,
 	})
@@ -134,7 +138,7 @@ func (b *Builder) Func(name string, fn func(ctx context.Context) error) *Builder
 func (b *Builder) ClusterSSH(
 	topo spec.Topology,
 	deployUser string, sshTimeout, exeTimeout uint64,
-	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHTimeout uint64,
+	proxyHost string, proxyPort int, proxyUser, proxyPassword, proxyKeyFile, proxyPassphrase string, proxySSHAgent bool, proxySSHTimeout uint64,
 	sshType, defaultSSHType executor.SSHType,
 ) *Builder {
 	if sshType == "" {
@@ -154,6 +158,7 @@ func (b *Builder) ClusterSSH(
 			proxyPassword:   proxyPassword,
 			proxyKeyFile:    proxyKeyFile,
 			proxyPassphrase: proxyPassphrase,
+			proxySSHAgent:   proxySSHAgent,
 			proxyTimeout:    proxySSHTimeout,
 			sshType:         sshType,
 		})
@@ -233,10 +238,23 @@ func (b *Builder) InstallPackage(srcPath, dstHost, dstDir string) *Builder {
 }
 
 // BackupComponent appends a BackupComponent task to the current task collection
-func (b *Builder) BackupComponent(component, fromVer string, host, deployDir string) *Builder {
+func (b *Builder) BackupComponent(component, fromVer, backupID string, host, deployDir string) *Builder {
 	b.tasks = append(b.tasks, &BackupComponent{
 		component: component,
 		fromVer:   fromVer,
+		backupID:  backupID,
+		host:      host,
+		deployDir: deployDir,
+	})
+	return b
+}
+
+// RestoreComponent appends a RestoreComponent task to the current task collection
+func (b *Builder) RestoreComponent(component, toVer, backupID string, host, deployDir string) *Builder {
+	b.tasks = append(b.tasks, &RestoreComponent{
+		component: component,
+		toVer:     toVer,
+		backupID:  backupID,
 		host:      host,
 		deployDir: deployDir,
 	})
@@ -325,6 +343,23 @@ func (b *Builder) SSHKeySet(privKeyPath, pubKeyPath string) *Builder {
 	return b
 }
 
+// SSHKnownHosts appends a SSHKnownHosts task to the current task collection
+func (b *Builder) SSHKnownHosts(path string, trust bool) *Builder {
+	b.tasks = append(b.tasks, &SSHKnownHosts{
+		path:  path,
+		trust: trust,
+	})
+	return b
+}
+
+// SSHProxies appends a SSHProxies task to the current task collection
+func (b *Builder) SSHProxies(proxies map[string]string) *Builder {
+	b.tasks = append(b.tasks, &SSHProxies{
+		proxies: proxies,
+	})
+	return b
+}
+
 // EnvInit appends a EnvInit task to the current task collection
 func (b *Builder) EnvInit(host, deployUser string, userGroup string, skipCreateUser bool, sudo bool) *Builder {
 	b.tasks = append(b.tasks, &EnvInit{
@@ -376,6 +411,17 @@ func (b *Builder) Mkdir(user, host string, sudo bool, dirs ...string) *Builder {
 	return b
 }
 
+// PrepareCheck appends a PrepareCheck task to the current task collection
+func (b *Builder) PrepareCheck(host, user, script string, dirs ...string) *Builder {
+	b.tasks = append(b.tasks, &PrepareCheck{
+		host:   host,
+		user:   user,
+		dirs:   dirs,
+		script: script,
+	})
+	return b
+}
+
 // Rmdir appends a Rmdir task to the current task collection
 func (b *Builder) Rmdir(host string, dirs ...string) *Builder {
 	b.tasks = append(b.tasks, &Rmdir{
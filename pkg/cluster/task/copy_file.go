@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
 )
 
 // CopyFile will copy a local file to the target host
@@ -38,7 +39,12 @@ func (c *CopyFile) Execute(ctx context.Context) error {
 		return ErrNoExecutor
 	}
 
-	err := e.Transfer(ctx, c.src, c.dst, c.download, c.limit, c.compress)
+	var err error
+	if c.download {
+		err = e.Transfer(ctx, c.src, c.dst, c.download, c.limit, c.compress)
+	} else {
+		err = executor.Upload(ctx, e, c.src, c.dst, c.limit, c.compress)
+	}
 	if err != nil {
 		return errors.Annotate(err, "failed to transfer file")
 	}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
 )
 
 // InstallPackage is used to copy all files related the specific version a component
@@ -43,12 +44,24 @@ func (c *InstallPackage) Execute(ctx context.Context) error {
 	dstDir := filepath.Join(c.dstDir, "bin")
 	dstPath := filepath.Join(dstDir, path.Base(c.srcPath))
 
-	err := exec.Transfer(ctx, c.srcPath, dstPath, false, 0, false)
+	// the package is uploaded in chunks, so a large one can be resumed, or
+	// fetched from the peers holding it if it's distributed among the hosts
+	var err error
+	keep := false
+	if d := peerDistributorFrom(ctx); d != nil {
+		keep, err = d.Install(ctx, exec, c.host, c.srcPath, dstPath)
+	} else {
+		err = executor.Upload(ctx, exec, c.srcPath, dstPath, 0, false)
+	}
 	if err != nil {
 		return errors.Annotatef(err, "failed to scp %s to %s:%s", c.srcPath, c.host, dstPath)
 	}
 
 	cmd := fmt.Sprintf(`tar --no-same-owner -zxf %s -C %s && rm %s`, dstPath, dstDir, dstPath)
+	if keep {
+		// the package is removed after the distribution
+		cmd = fmt.Sprintf(`tar --no-same-owner -zxf %s -C %s`, dstPath, dstDir)
+	}
 
 	_, stderr, err := exec.Execute(ctx, cmd, false)
 	if err != nil {
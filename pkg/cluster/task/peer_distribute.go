@@ -0,0 +1,370 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	"github.com/pingcap/tiup/pkg/cluster/spec"
+	"github.com/pingcap/tiup/pkg/utils"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// peerFetchTimeout is the timeout of fetching a package from a peer
+var peerFetchTimeout = 30 * time.Minute
+
+// peerCleanupTimeout is the timeout of cleaning up the distribution
+var peerCleanupTimeout = 5 * time.Minute
+
+type peerDistributorKey struct{}
+
+// PeerDistributor distributes the packages to the hosts in a tree. The control
+// machine uploads a package to at most fanout hosts at a time, and each host
+// holding the package serves it to at most fanout other hosts at a time, so
+// the uplink of the control machine is not the bottleneck of large clusters.
+//
+// The hosts fetch the package from the peers over SSH with an ephemeral key,
+// which is authorized on the peers with a forced command only reading the
+// package, and the fetched package is verified with sha256. The host keys of
+// the peers are verified with the known_hosts file of the cluster, the peers
+// don't serve the package if there is none. The keys and the packages kept for
+// the peers are removed by Cleanup.
+type PeerDistributor struct {
+	fanout int
+	user   string
+	ports  map[string]int // the SSH ports of the hosts
+	tag    string         // the comment of the authorized keys of this distribution
+	dir    string         // the local directory of the ephemeral keys
+
+	mutex          sync.Mutex
+	cond           *sync.Cond
+	packages       map[string]*peerPackage // by the local path
+	authorizedKeys map[string]string       // the authorized_keys file the keys are added to, by the hosts
+}
+
+type peerPackage struct {
+	once     sync.Once
+	err      error
+	checksum string
+	key      string // the local path of the private key
+	pubKey   string // the public key in the authorized_keys format
+
+	holders  map[string]string // the remote path of the package, by the hosts holding it
+	servable map[string]bool   // the holders authorized the key
+	pending  map[string]bool   // the hosts getting the package
+	serving  map[string]int    // the number of transfers served, by the hosts, "" for the control machine
+}
+
+// WithPeerDistributor returns the context in which the packages are distributed
+// to the hosts of the topology by a PeerDistributor, and the function to clean
+// up after the distribution. The context is returned as is if fanout is not
+// positive.
+func WithPeerDistributor(ctx context.Context, fanout int, topo spec.Topology) (context.Context, func()) {
+	if fanout <= 0 {
+		return ctx, func() {}
+	}
+
+	ports := make(map[string]int)
+	topo.IterInstance(func(inst spec.Instance) {
+		ports[inst.GetManageHost()] = inst.GetSSHPort()
+	})
+	d := newPeerDistributor(fanout, topo.BaseTopo().GlobalOptions.User, ports)
+	ctx = context.WithValue(ctx, peerDistributorKey{}, d)
+	return ctx, func() { d.Cleanup(ctx) }
+}
+
+func newPeerDistributor(fanout int, user string, ports map[string]int) *PeerDistributor {
+	d := &PeerDistributor{
+		fanout:         fanout,
+		user:           user,
+		ports:          ports,
+		tag:            "tiup-p2p-" + uuid.New().String()[:8],
+		packages:       make(map[string]*peerPackage),
+		authorizedKeys: make(map[string]string),
+	}
+	d.cond = sync.NewCond(&d.mutex)
+	return d
+}
+
+func peerDistributorFrom(ctx context.Context) *PeerDistributor {
+	d, _ := ctx.Value(peerDistributorKey{}).(*PeerDistributor)
+	return d
+}
+
+// pkg returns the distribution of the package, the checksum and the key of it
+// are prepared on first use
+func (d *PeerDistributor) pkg(src string) (*peerPackage, error) {
+	d.mutex.Lock()
+	p, ok := d.packages[src]
+	if !ok {
+		p = &peerPackage{
+			holders:  make(map[string]string),
+			servable: make(map[string]bool),
+			pending:  make(map[string]bool),
+			serving:  make(map[string]int),
+		}
+		d.packages[src] = p
+	}
+	d.mutex.Unlock()
+
+	p.once.Do(func() {
+		p.err = d.prepare(p, src)
+	})
+	return p, p.err
+}
+
+func (d *PeerDistributor) prepare(p *peerPackage, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer f.Close()
+	if p.checksum, err = utils.SHA256(f); err != nil {
+		return err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	p.pubKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+
+	d.mutex.Lock()
+	if d.dir == "" {
+		d.dir, err = os.MkdirTemp("", "tiup-p2p-")
+	}
+	d.mutex.Unlock()
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	key, err := os.CreateTemp(d.dir, "key-")
+	if err != nil {
+		return perrs.AddStack(err)
+	}
+	defer key.Close()
+	p.key = key.Name()
+	_, err = key.Write(pem.EncodeToMemory(block))
+	return perrs.AddStack(err)
+}
+
+// source returns the holder with the least transfers to serve the package, or
+// "" for the control machine, ok is false if all of them are busy
+func (p *peerPackage) source(fanout int) (source string, ok bool) {
+	least := fanout
+	for host := range p.servable {
+		if n := p.serving[host]; n < least {
+			source, least, ok = host, n, true
+		}
+	}
+	if !ok && p.serving[""] < fanout {
+		return "", true
+	}
+	return source, ok
+}
+
+// Install gets the package src to dst on the host, from another copy on the
+// host, the peers or the control machine. The copy at dst is kept to serve the
+// peers if keep is returned.
+func (d *PeerDistributor) Install(ctx context.Context, e ctxt.Executor, host, src, dst string) (keep bool, err error) {
+	p, err := d.pkg(src)
+	if err != nil {
+		return false, err
+	}
+
+	// wake up the waiting below once the context is canceled
+	stop := context.AfterFunc(ctx, func() {
+		d.mutex.Lock()
+		d.cond.Broadcast()
+		d.mutex.Unlock()
+	})
+	defer stop()
+
+	var source string
+	d.mutex.Lock()
+	for {
+		if err := ctx.Err(); err != nil {
+			d.mutex.Unlock()
+			return false, err
+		}
+		if path, ok := p.holders[host]; ok {
+			d.mutex.Unlock()
+			_, _, err := e.Execute(ctx, fmt.Sprintf("cp -f %s %s", utils.ShellQuote(path), utils.ShellQuote(dst)), false)
+			return false, err
+		}
+		if !p.pending[host] {
+			var ok bool
+			if source, ok = p.source(d.fanout); ok {
+				break
+			}
+		}
+		d.cond.Wait()
+	}
+	p.pending[host] = true
+	p.serving[source]++
+	d.mutex.Unlock()
+
+	if source != "" {
+		if err = d.fetch(ctx, e, p, source, dst); err != nil {
+			zap.L().Warn("Failed to fetch the package from the peer, upload it instead",
+				zap.String("host", host), zap.String("peer", source), zap.String("package", src), zap.Error(err))
+		}
+	}
+	if source == "" || err != nil {
+		err = executor.Upload(ctx, e, src, dst, 0, false)
+	}
+	// the peers can't verify the host key of the host without known_hosts
+	servable := false
+	if err == nil && knownHostsPath(ctx) != "" {
+		servable = d.authorize(ctx, e, p, host, dst)
+	}
+
+	d.mutex.Lock()
+	p.serving[source]--
+	delete(p.pending, host)
+	if err == nil {
+		p.holders[host] = dst
+		if servable {
+			p.servable[host] = true
+		}
+	}
+	d.cond.Broadcast()
+	d.mutex.Unlock()
+	return err == nil, err
+}
+
+// knownHostsPath returns the known_hosts file of the cluster, or empty if
+// there is none
+func knownHostsPath(ctx context.Context) string {
+	path := ctxt.GetInner(ctx).KnownHostsPath
+	if path == "" || !utils.IsExist(path) {
+		return ""
+	}
+	return path
+}
+
+// fetch makes the host fetch the package from the peer with the ephemeral key,
+// the host key of the peer is verified with the known_hosts file of the cluster
+func (d *PeerDistributor) fetch(ctx context.Context, e ctxt.Executor, p *peerPackage, peer, dst string) error {
+	key, knownHosts := dst+".p2p-key", dst+".p2p-known_hosts"
+	if err := e.Transfer(ctx, p.key, key, false, 0, false); err != nil {
+		return err
+	}
+	if err := e.Transfer(ctx, knownHostsPath(ctx), knownHosts, false, 0, false); err != nil {
+		_, _, _ = e.Execute(ctx, fmt.Sprintf("rm -f %s", utils.ShellQuote(key)), false)
+		return err
+	}
+	port := d.ports[peer]
+	if port <= 0 {
+		port = 22
+	}
+
+	cmd := fmt.Sprintf(
+		"chmod 600 %[1]s && ssh -i %[1]s -p %[2]d -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes "+
+			"-o UserKnownHostsFile=%[6]s -o GlobalKnownHostsFile=/dev/null -o ConnectTimeout=10 -o LogLevel=ERROR %[3]s@%[4]s > %[5]s; "+
+			"rc=$?; rm -f %[1]s %[6]s; [ $rc -eq 0 ] && sha256sum %[5]s",
+		utils.ShellQuote(key), port, d.user, peer, utils.ShellQuote(dst), utils.ShellQuote(knownHosts))
+	stdout, _, err := e.Execute(ctx, cmd, false, peerFetchTimeout)
+	if err != nil {
+		return err
+	}
+	if fields := strings.Fields(string(stdout)); len(fields) == 0 || fields[0] != p.checksum {
+		_, _, _ = e.Execute(ctx, fmt.Sprintf("rm -f %s", utils.ShellQuote(dst)), false)
+		return executor.ErrTransferChecksumMismatch.New("Checksum of the package %s fetched from %s is %s, but %s is expected", dst, peer, strings.Join(fields, " "), p.checksum)
+	}
+	return nil
+}
+
+// authorize authorizes the ephemeral key to read the package on the host, the
+// host doesn't serve the peers if it fails
+func (d *PeerDistributor) authorize(ctx context.Context, e ctxt.Executor, p *peerPackage, host, path string) bool {
+	d.mutex.Lock()
+	keysFile, ok := d.authorizedKeys[host]
+	d.mutex.Unlock()
+	if !ok {
+		keysFile = executor.FindSSHAuthorizedKeysFile(ctx, e)
+	}
+
+	entry := fmt.Sprintf(`restrict,command="cat %s" %s %s@%s`, utils.ShellQuote(path), p.pubKey, d.tag, host)
+	cmd := fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && echo %s >> %s", utils.ShellQuote(entry), keysFile)
+	_, _, err := e.Execute(ctx, cmd, false)
+
+	d.mutex.Lock()
+	// the keys may be added even if it fails, they are removed by Cleanup
+	d.authorizedKeys[host] = keysFile
+	d.mutex.Unlock()
+	if err != nil {
+		zap.L().Warn("Failed to authorize the peers to fetch the package", zap.String("host", host), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// Cleanup removes the packages kept for the peers and the authorized keys on
+// the hosts, and the local ephemeral keys. It's done even if the context is
+// canceled, e.g. the operation is interrupted.
+func (d *PeerDistributor) Cleanup(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), peerCleanupTimeout)
+	defer cancel()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	holders := make(map[string][]string)
+	for _, p := range d.packages {
+		for host, path := range p.holders {
+			holders[host] = append(holders[host], path)
+		}
+	}
+	for host, paths := range holders {
+		e, ok := ctxt.GetInner(ctx).GetExecutor(host)
+		if !ok {
+			continue
+		}
+		quoted := make([]string, 0, len(paths))
+		for _, path := range paths {
+			quoted = append(quoted, utils.ShellQuote(path))
+		}
+		cmd := fmt.Sprintf("rm -f %s", strings.Join(quoted, " "))
+		if keysFile, ok := d.authorizedKeys[host]; ok {
+			cmd += fmt.Sprintf("; sed -i '/ %s@/d' %s", d.tag, keysFile)
+		}
+		if _, _, err := e.Execute(ctx, cmd, false); err != nil {
+			zap.L().Warn("Failed to clean up the distributed packages", zap.String("host", host), zap.Error(err))
+		}
+	}
+	if d.dir != "" {
+		_ = os.RemoveAll(d.dir)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePeerSSH is a stand-in for the ssh client on the hosts, all hosts are
+// local and share the same authorized_keys, the forced command authorized for
+// the host connected is run. The host keys must be checked with the known_hosts
+// file pushed to the host.
+const fakePeerSSH = `#!/bin/bash
+strict=; known=
+for arg; do
+	case "$arg" in
+	StrictHostKeyChecking=yes) strict=1 ;;
+	UserKnownHostsFile=*) known="${arg#*=}" ;;
+	esac
+done
+[ -n "$strict" ] && [ -f "$known" ] || exit 255
+host="${arg#*@}"
+command=$(grep "@${host}\$" ~/.ssh/authorized_keys | sed 's/^restrict,command="\([^"]*\)".*/\1/')
+[ -n "$command" ] || exit 255
+eval "$command"
+`
+
+// uploadCounter counts the uploads of the package from the control machine
+type uploadCounter struct {
+	ctxt.Executor
+	src     string
+	uploads *atomic.Int32
+}
+
+func (e *uploadCounter) Transfer(ctx context.Context, src, dst string, download bool, limit int, compress bool) error {
+	if src == e.src {
+		e.uploads.Add(1)
+	}
+	return e.Executor.Transfer(ctx, src, dst, download, limit, compress)
+}
+
+func TestPeerDistributor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "ssh"), []byte(fakePeerSSH), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	src := filepath.Join(t.TempDir(), "tikv.tar.gz")
+	require.NoError(t, os.WriteFile(src, []byte("package"), 0644))
+
+	ctx := ctxt.New(context.Background(), 0, logprinter.NewLogger(""))
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHosts, []byte("h1 ssh-ed25519 AAAA\n"), 0600))
+	ctxt.GetInner(ctx).KnownHostsPath = knownHosts
+	uploads := new(atomic.Int32)
+	hosts := []string{"h1", "h2", "h3", "h4"}
+	for _, host := range hosts {
+		ctxt.GetInner(ctx).SetExecutor(host, &uploadCounter{
+			Executor: &executor.Local{Config: &executor.SSHConfig{Host: host, User: current.Username}},
+			src:      src,
+			uploads:  uploads,
+		})
+	}
+	d := newPeerDistributor(1, current.Username, map[string]int{})
+	dir := t.TempDir()
+	dst := func(host string, i int) string {
+		return filepath.Join(dir, host, string(rune('0'+i))+".tar.gz")
+	}
+	install := func(host string, i int) bool {
+		e, _ := ctxt.GetInner(ctx).GetExecutor(host)
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst(host, i)), 0755))
+		keep, err := d.Install(ctx, e, host, src, dst(host, i))
+		require.NoError(t, err)
+		data, err := os.ReadFile(dst(host, i))
+		require.NoError(t, err)
+		assert.Equal(t, "package", string(data))
+		return keep
+	}
+
+	// the control machine only seeds the first host
+	assert.True(t, install("h1", 0))
+	assert.True(t, install("h2", 0))
+	assert.Equal(t, int32(1), uploads.Load())
+
+	// the corrupted package on the peer is not accepted
+	require.NoError(t, os.WriteFile(dst("h1", 0), []byte("corrupted"), 0644))
+	require.NoError(t, os.WriteFile(dst("h2", 0), []byte("corrupted"), 0644))
+	assert.True(t, install("h3", 0))
+	assert.Equal(t, int32(2), uploads.Load())
+	require.NoError(t, os.WriteFile(dst("h1", 0), []byte("package"), 0644))
+	require.NoError(t, os.WriteFile(dst("h2", 0), []byte("package"), 0644))
+	assert.True(t, install("h4", 0))
+	assert.Equal(t, int32(2), uploads.Load())
+
+	// the other instances on the host copy the package on the host
+	assert.False(t, install("h4", 1))
+	assert.Equal(t, int32(2), uploads.Load())
+
+	keys, err := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	require.NoError(t, err)
+	assert.Contains(t, string(keys), d.tag+"@h4")
+
+	// the cleanup is done even if the operation is canceled
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	d.Cleanup(canceled)
+	keys, err = os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	require.NoError(t, err)
+	assert.Empty(t, string(keys))
+	for _, host := range hosts {
+		assert.NoFileExists(t, dst(host, 0))
+	}
+	assert.FileExists(t, dst("h4", 1))
+	assert.NoDirExists(t, d.dir)
+
+	for _, host := range hosts {
+		assert.NoFileExists(t, dst(host, 0)+".p2p-known_hosts")
+	}
+
+	// the peers don't serve the package without the known_hosts file
+	ctxt.GetInner(ctx).KnownHostsPath = ""
+	d = newPeerDistributor(1, current.Username, map[string]int{})
+	assert.True(t, install("h1", 0))
+	assert.True(t, install("h2", 0))
+	assert.Equal(t, int32(4), uploads.Load())
+	d.Cleanup(ctx)
+}
+
+func TestPeerDistributorCanceled(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+	src := filepath.Join(t.TempDir(), "tikv.tar.gz")
+	require.NoError(t, os.WriteFile(src, []byte("package"), 0644))
+
+	d := newPeerDistributor(1, current.Username, map[string]int{})
+	p, err := d.pkg(src)
+	require.NoError(t, err)
+	defer os.RemoveAll(d.dir)
+	// the control machine is busy serving another host
+	p.serving[""] = 1
+
+	ctx, cancel := context.WithCancel(ctxt.New(context.Background(), 0, logprinter.NewLogger("")))
+	e := &executor.Local{Config: &executor.SSHConfig{Host: "h1", User: current.Username}}
+	errC := make(chan error, 1)
+	go func() {
+		_, err := d.Install(ctx, e, "h1", src, filepath.Join(t.TempDir(), "tikv.tar.gz"))
+		errC <- err
+	}()
+	cancel()
+	select {
+	case err := <-errC:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiting is not stopped by the cancel")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Plan writes the steps of a task tree to w without executing any of them.
+// Display steps are printed with their prefix and the tasks they contain are
+// indented below them, every other task is printed by its String() output,
+// which contains the target host and the command, files or systemctl action.
+func Plan(w io.Writer, t Task) error {
+	return writePlan(w, t, 0)
+}
+
+func writePlan(w io.Writer, t Task, depth int) error {
+	switch tt := t.(type) {
+	case *Serial:
+		for _, inner := range tt.inner {
+			if err := writePlan(w, inner, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Parallel:
+		for _, inner := range tt.inner {
+			if err := writePlan(w, inner, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *StepDisplay:
+		if tt.hidden {
+			return writePlan(w, tt.inner, depth)
+		}
+		if err := writePlanLine(w, depth, strings.TrimSpace(tt.prefix)); err != nil {
+			return err
+		}
+		return writePlan(w, tt.inner, depth+1)
+	case *ParallelStepDisplay:
+		if len(tt.inner.inner) == 0 {
+			return nil
+		}
+		if err := writePlanLine(w, depth, strings.TrimSpace(tt.prefix)); err != nil {
+			return err
+		}
+		return writePlan(w, tt.inner, depth+1)
+	}
+
+	for _, line := range strings.Split(t.String(), "\n") {
+		if err := writePlanLine(w, depth, "* "+line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePlanLine(w io.Writer, depth int, line string) error {
+	_, err := fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), line)
+	return err
+}
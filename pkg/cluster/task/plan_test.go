@@ -0,0 +1,51 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	logger := logprinter.NewLogger("")
+	executed := false
+
+	prepare := NewBuilder(logger).
+		Mkdir("tidb", "172.16.5.1", true, "/data/deploy").
+		BuildAsStep("  - Prepare 172.16.5.1:22")
+	tk := NewBuilder(logger).
+		ParallelStep("+ Initialize target host environments", false, prepare).
+		ParallelStep("+ Nothing to do", false).
+		SystemCtl("172.16.5.1", "tidb-4000.service", "restart", false, false, "system").
+		Func("UpgradeCluster", func(ctx context.Context) error {
+			executed = true
+			return nil
+		}).
+		Build()
+
+	var buf bytes.Buffer
+	require.NoError(t, Plan(&buf, tk))
+	require.False(t, executed)
+	require.Equal(t, `+ Initialize target host environments
+  - Prepare 172.16.5.1:22
+    * Mkdir: host=172.16.5.1, directories='/data/deploy'
+* SystemCtl: host=172.16.5.1 action=restart tidb-4000.service
+* UpgradeCluster
+`, buf.String())
+}
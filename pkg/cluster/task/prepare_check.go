@@ -0,0 +1,89 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/tui"
+)
+
+var (
+	errNSPrepare = errNS.NewSubNamespace("prepare")
+	// ErrHostUnprepared is the error when the host is not prepared by root for
+	// the sudo-less deployment
+	ErrHostUnprepared = errNSPrepare.NewType("unprepared")
+)
+
+// the problem reported if lingering is not enabled for the user
+const lingerDisabled = "linger"
+
+// PrepareCheck checks the host is prepared by root for the sudo-less deployment,
+// i.e. the directories exist and are writable by the deploy user, and lingering
+// is enabled for the systemd user instance of it. Nothing is fixed as it needs
+// root, the problems are reported with the script to run as root instead.
+type PrepareCheck struct {
+	host   string
+	user   string
+	dirs   []string
+	script string // the path of the script preparing the host
+}
+
+// Execute implements the Task interface
+func (c *PrepareCheck) Execute(ctx context.Context) error {
+	exec, found := ctxt.GetInner(ctx).GetExecutor(c.host)
+	if !found {
+		panic(ErrNoExecutor)
+	}
+
+	cmds := make([]string, 0, len(c.dirs)+1)
+	for _, dir := range c.dirs {
+		cmds = append(cmds, fmt.Sprintf(`{ [ -d %[1]s ] && [ -w %[1]s ]; } || echo %[1]s`, dir))
+	}
+	cmds = append(cmds, fmt.Sprintf(`loginctl show-user %s --property=Linger 2>/dev/null | grep -q '=yes' || echo %s`, c.user, lingerDisabled))
+	stdout, _, err := exec.Execute(ctx, strings.Join(cmds, "; "), false)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, line := range strings.Split(strings.TrimSpace(string(stdout)), "\n") {
+		switch line = strings.TrimSpace(line); line {
+		case "":
+		case lingerDisabled:
+			problems = append(problems, fmt.Sprintf("lingering is not enabled for user '%s'", c.user))
+		default:
+			problems = append(problems, fmt.Sprintf("directory '%s' does not exist or is not writable by user '%s'", line, c.user))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return ErrHostUnprepared.
+		New("Host %s is not prepared for the sudo-less deployment:\n  %s", c.host, strings.Join(problems, "\n  ")).
+		WithProperty(tui.SuggestionFromFormat("Please copy the script %s to the host, run it as root, and try again.", c.script))
+}
+
+// Rollback implements the Task interface
+func (c *PrepareCheck) Rollback(ctx context.Context) error {
+	return ErrUnsupportedRollback
+}
+
+// String implements the fmt.Stringer interface
+func (c *PrepareCheck) String() string {
+	return fmt.Sprintf("PrepareCheck: host=%s, user=%s, directories='%s'", c.host, c.user, strings.Join(c.dirs, "','"))
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoginctl reports lingering is enabled for the users listed in $LINGER
+const fakeLoginctl = `#!/bin/bash
+[[ " $LINGER " == *" $2 "* ]] && echo Linger=yes || echo Linger=no
+`
+
+func TestPrepareCheck(t *testing.T) {
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "loginctl"), []byte(fakeLoginctl), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	ctx := ctxt.New(context.Background(), 0, logprinter.NewLogger(""))
+	ctxt.GetInner(ctx).SetExecutor("h1", &executor.Local{Config: &executor.SSHConfig{Host: "h1", User: current.Username}})
+
+	prepared := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "missing")
+	check := &PrepareCheck{host: "h1", user: current.Username, dirs: []string{prepared, missing}, script: "prepare_h1.sh"}
+
+	err = check.Execute(ctx)
+	require.Error(t, err)
+	require.True(t, errorx.IsOfType(err, ErrHostUnprepared))
+	require.Contains(t, err.Error(), missing)
+	require.NotContains(t, err.Error(), "'"+prepared+"'")
+	require.Contains(t, err.Error(), "lingering is not enabled")
+
+	t.Setenv("LINGER", current.Username)
+	require.NoError(t, os.MkdirAll(missing, 0755))
+	require.NoError(t, check.Execute(ctx))
+}
@@ -35,14 +35,16 @@ type RootSSH struct {
 	password        string           // password of the user
 	keyFile         string           // path to the private key file
 	passphrase      string           // passphrase of the private key file
+	sshAgent        bool             // use the keys in the ssh-agent
 	timeout         uint64           // timeout in seconds when connecting via SSH
 	exeTimeout      uint64           // timeout in seconds waiting command to finish
-	proxyHost       string           // hostname of the proxy SSH server
+	proxyHost       string           // jump hosts of the SSH server in the ProxyJump format
 	proxyPort       int              // port of the proxy SSH server
 	proxyUser       string           // username to login to the proxy SSH server
 	proxyPassword   string           // password of the proxy user
 	proxyKeyFile    string           // path to the private key file
 	proxyPassphrase string           // passphrase of the private key file
+	proxySSHAgent   bool             // use the keys in the ssh-agent for the proxy
 	proxyTimeout    uint64           // timeout in seconds when connecting via SSH
 	sshType         executor.SSHType // the type of SSH channel
 	sudo            bool
@@ -57,20 +59,27 @@ func (s *RootSSH) Execute(ctx context.Context) error {
 		Password:   s.password,
 		KeyFile:    s.keyFile,
 		Passphrase: s.passphrase,
+		SSHAgent:   s.sshAgent,
 		Timeout:    time.Second * time.Duration(s.timeout),
 		ExeTimeout: time.Second * time.Duration(s.exeTimeout),
+
+		KnownHosts:      ctxt.GetInner(ctx).KnownHostsPath,
+		TrustOnFirstUse: ctxt.GetInner(ctx).TrustUnknownHosts,
+		Pool:            ctxt.GetInner(ctx).SSHPool,
 	}
-	if len(s.proxyHost) > 0 {
-		sc.Proxy = &executor.SSHConfig{
-			Host:       s.proxyHost,
-			Port:       s.proxyPort,
-			User:       s.proxyUser,
-			Password:   s.proxyPassword,
-			KeyFile:    s.proxyKeyFile,
-			Passphrase: s.proxyPassphrase,
-			Timeout:    time.Second * time.Duration(s.proxyTimeout),
-		}
+	proxy, err := proxyConfig(ctx, s.host, s.proxyHost, executor.SSHConfig{
+		Port:       s.proxyPort,
+		User:       s.proxyUser,
+		Password:   s.proxyPassword,
+		KeyFile:    s.proxyKeyFile,
+		Passphrase: s.proxyPassphrase,
+		SSHAgent:   s.proxySSHAgent,
+		Timeout:    time.Second * time.Duration(s.proxyTimeout),
+	})
+	if err != nil {
+		return err
 	}
+	sc.Proxy = proxy
 	e, err := executor.New(s.sshType, s.sudo, sc)
 	if err != nil {
 		return err
@@ -94,6 +103,20 @@ func (s RootSSH) String() string {
 	return fmt.Sprintf("RootSSH: user=%s, host=%s, port=%d", s.user, s.host, s.port)
 }
 
+// proxyConfig returns the config of the jump hosts to connect to the host
+// through, the ones of the host set in the context are used if jump is empty,
+// and nil is returned if there is none. The other fields of the jump hosts are
+// copied from base.
+func proxyConfig(ctx context.Context, host, jump string, base executor.SSHConfig) (*executor.SSHConfig, error) {
+	if jump == "" {
+		jump = ctxt.GetInner(ctx).SSHProxies[host]
+	}
+	if jump == "" {
+		return nil, nil
+	}
+	return executor.ParseProxyJump(jump, base)
+}
+
 // UserSSH is used to establish an SSH connection to the target host with generated key
 type UserSSH struct {
 	host            string
@@ -101,12 +124,13 @@ type UserSSH struct {
 	deployUser      string
 	timeout         uint64
 	exeTimeout      uint64 // timeout in seconds waiting command to finish
-	proxyHost       string // hostname of the proxy SSH server
+	proxyHost       string // jump hosts of the SSH server in the ProxyJump format
 	proxyPort       int    // port of the proxy SSH server
 	proxyUser       string // username to login to the proxy SSH server
 	proxyPassword   string // password of the proxy user
 	proxyKeyFile    string // path to the private key file
 	proxyPassphrase string // passphrase of the private key file
+	proxySSHAgent   bool   // use the keys in the ssh-agent for the proxy
 	proxyTimeout    uint64 // timeout in seconds when connecting via SSH
 	sshType         executor.SSHType
 }
@@ -120,19 +144,24 @@ func (s *UserSSH) Execute(ctx context.Context) error {
 		User:       s.deployUser,
 		Timeout:    time.Second * time.Duration(s.timeout),
 		ExeTimeout: time.Second * time.Duration(s.exeTimeout),
-	}
 
-	if len(s.proxyHost) > 0 {
-		sc.Proxy = &executor.SSHConfig{
-			Host:       s.proxyHost,
-			Port:       s.proxyPort,
-			User:       s.proxyUser,
-			Password:   s.proxyPassword,
-			KeyFile:    s.proxyKeyFile,
-			Passphrase: s.proxyPassphrase,
-			Timeout:    time.Second * time.Duration(s.proxyTimeout),
-		}
+		KnownHosts:      ctxt.GetInner(ctx).KnownHostsPath,
+		TrustOnFirstUse: ctxt.GetInner(ctx).TrustUnknownHosts,
+		Pool:            ctxt.GetInner(ctx).SSHPool,
+	}
+	proxy, err := proxyConfig(ctx, s.host, s.proxyHost, executor.SSHConfig{
+		Port:       s.proxyPort,
+		User:       s.proxyUser,
+		Password:   s.proxyPassword,
+		KeyFile:    s.proxyKeyFile,
+		Passphrase: s.proxyPassphrase,
+		SSHAgent:   s.proxySSHAgent,
+		Timeout:    time.Second * time.Duration(s.proxyTimeout),
+	})
+	if err != nil {
+		return err
 	}
+	sc.Proxy = proxy
 	e, err := executor.New(s.sshType, false, sc)
 	if err != nil {
 		return err
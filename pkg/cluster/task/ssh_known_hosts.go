@@ -0,0 +1,57 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// SSHKnownHosts is used to set the Context known_hosts file to verify the host
+// keys with. The host keys are trusted on first use if trust is set, or if the
+// file does not exist yet, which is the case of the clusters deployed before the
+// host keys are managed.
+type SSHKnownHosts struct {
+	path  string
+	trust bool
+}
+
+// Execute implements the Task interface
+func (s *SSHKnownHosts) Execute(ctx context.Context) error {
+	trust := s.trust
+	if !trust && !utils.IsExist(s.path) {
+		logger := ctx.Value(logprinter.ContextKeyLogger).(*logprinter.Logger)
+		logger.Warnf("The host keys of the cluster are not recorded yet, they will be trusted and saved to %s", s.path)
+		trust = true
+	}
+	ctxt.GetInner(ctx).KnownHostsPath = s.path
+	ctxt.GetInner(ctx).TrustUnknownHosts = trust
+	return nil
+}
+
+// Rollback implements the Task interface
+func (s *SSHKnownHosts) Rollback(ctx context.Context) error {
+	ctxt.GetInner(ctx).KnownHostsPath = ""
+	ctxt.GetInner(ctx).TrustUnknownHosts = false
+	return nil
+}
+
+// String implements the fmt.Stringer interface
+func (s *SSHKnownHosts) String() string {
+	return fmt.Sprintf("SSHKnownHosts: path=%s, trust=%v", s.path, s.trust)
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/tiup/pkg/cluster/ctxt"
+)
+
+// SSHProxies is used to set the Context jump hosts of the hosts, the hosts are
+// connected through their jump hosts unless the ones of the SSH task are set
+type SSHProxies struct {
+	proxies map[string]string
+}
+
+// Execute implements the Task interface
+func (s *SSHProxies) Execute(ctx context.Context) error {
+	ctxt.GetInner(ctx).SSHProxies = s.proxies
+	return nil
+}
+
+// Rollback implements the Task interface
+func (s *SSHProxies) Rollback(ctx context.Context) error {
+	ctxt.GetInner(ctx).SSHProxies = nil
+	return nil
+}
+
+// String implements the fmt.Stringer interface
+func (s *SSHProxies) String() string {
+	return fmt.Sprintf("SSHProxies: hosts=%d", len(s.proxies))
+}
@@ -15,8 +15,6 @@ package task
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"strings"
 
 	"github.com/pingcap/tiup/pkg/cluster/ctxt"
@@ -97,6 +95,7 @@ func (s *StepDisplay) Execute(ctx context.Context) error {
 
 	switch s.Logger.GetDisplayMode() {
 	case logprinter.DisplayModeJSON,
+		logprinter.DisplayModeYAML,
 		logprinter.DisplayModePlain:
 		// do nothing
 	default:
@@ -126,8 +125,9 @@ func (s *StepDisplay) Execute(ctx context.Context) error {
 	}
 
 	switch s.Logger.GetDisplayMode() {
-	case logprinter.DisplayModeJSON:
-		_ = printDpJSON(dp)
+	case logprinter.DisplayModeJSON,
+		logprinter.DisplayModeYAML:
+		_ = s.Logger.Output(dp)
 	case logprinter.DisplayModePlain:
 		printDpPlain(s.Logger, dp)
 	default:
@@ -155,8 +155,9 @@ func (s *StepDisplay) handleTaskBegin(task Task) {
 		Suffix: strings.Split(task.String(), "\n")[0],
 	}
 	switch s.Logger.GetDisplayMode() {
-	case logprinter.DisplayModeJSON:
-		_ = printDpJSON(dp)
+	case logprinter.DisplayModeJSON,
+		logprinter.DisplayModeYAML:
+		_ = s.Logger.Output(dp)
 	case logprinter.DisplayModePlain:
 		printDpPlain(s.Logger, dp)
 	default:
@@ -173,8 +174,9 @@ func (s *StepDisplay) handleTaskProgress(task Task, p string) {
 		Suffix: strings.Split(p, "\n")[0],
 	}
 	switch s.Logger.GetDisplayMode() {
-	case logprinter.DisplayModeJSON:
-		_ = printDpJSON(dp)
+	case logprinter.DisplayModeJSON,
+		logprinter.DisplayModeYAML:
+		_ = s.Logger.Output(dp)
 	case logprinter.DisplayModePlain:
 		printDpPlain(s.Logger, dp)
 	default:
@@ -217,6 +219,7 @@ func (ps *ParallelStepDisplay) SetLogger(logger *logprinter.Logger) *ParallelSte
 func (ps *ParallelStepDisplay) Execute(ctx context.Context) error {
 	switch ps.Logger.GetDisplayMode() {
 	case logprinter.DisplayModeJSON,
+		logprinter.DisplayModeYAML,
 		logprinter.DisplayModePlain:
 		// do nothing
 	default:
@@ -237,15 +240,6 @@ func (ps *ParallelStepDisplay) String() string {
 	return ps.inner.String()
 }
 
-func printDpJSON(dp *progress.DisplayProps) error {
-	output, err := json.Marshal(dp)
-	if err != nil {
-		return err
-	}
-	fmt.Println(string(output))
-	return nil
-}
-
 func printDpPlain(logger *logprinter.Logger, dp *progress.DisplayProps) {
 	switch dp.Mode {
 	case progress.ModeError:
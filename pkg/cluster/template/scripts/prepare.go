@@ -0,0 +1,62 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripts
+
+import (
+	"bytes"
+	"path"
+	"text/template"
+
+	"github.com/pingcap/tiup/embed"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// PrepareScript represent the data to generate the script preparing a host
+// as root for the sudo-less deployment
+type PrepareScript struct {
+	ClusterName string
+	Host        string
+	User        string
+	Group       string
+	Dirs        []string // the directories owned by the user
+}
+
+// Script generates the content of the script
+func (c *PrepareScript) Script() ([]byte, error) {
+	fp := path.Join("templates", "scripts", "prepare_host.sh.tpl")
+	tpl, err := embed.ReadTemplate(fp)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("Prepare").Parse(string(tpl))
+	if err != nil {
+		return nil, err
+	}
+
+	content := bytes.NewBufferString("")
+	if err := tmpl.Execute(content, c); err != nil {
+		return nil, err
+	}
+	return content.Bytes(), nil
+}
+
+// ConfigToFile write the script to specific path
+func (c *PrepareScript) ConfigToFile(file string) error {
+	content, err := c.Script()
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(file, content, 0755)
+}
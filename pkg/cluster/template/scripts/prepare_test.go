@@ -0,0 +1,52 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripts
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareScript(t *testing.T) {
+	script := &PrepareScript{
+		ClusterName: "test",
+		Host:        "172.16.5.1",
+		User:        "tidb",
+		Group:       "tidb-group",
+		Dirs:        []string{"/tidb-data/tikv-20160", "/tidb-deploy/tikv-20160"},
+	}
+	file := filepath.Join(t.TempDir(), "prepare.sh")
+	require.NoError(t, script.ConfigToFile(file))
+
+	// the script is valid bash
+	out, err := exec.Command("bash", "-n", file).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	content, err := script.Script()
+	require.NoError(t, err)
+	require.Contains(t, string(content), "getent group tidb-group >/dev/null || groupadd tidb-group")
+	require.Contains(t, string(content), "useradd -m -g tidb-group tidb")
+	require.Contains(t, string(content), "prepare_dir /tidb-data/tikv-20160\n")
+	require.Contains(t, string(content), "prepare_dir /tidb-deploy/tikv-20160\n")
+	require.Contains(t, string(content), "loginctl enable-linger tidb")
+
+	script.Group = ""
+	content, err = script.Script()
+	require.NoError(t, err)
+	require.Contains(t, string(content), "useradd -m tidb")
+	require.NotContains(t, string(content), "groupadd")
+}
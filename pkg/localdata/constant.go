@@ -94,6 +94,12 @@ const (
 	// EnvNameSCPPath is the variable name by which user can specific the executable scp binary path
 	EnvNameSCPPath = "TIUP_SCP_PATH"
 
+	// EnvNameExecutorRecord is the variable name by which user can record the commands executed on the hosts and their results into a file
+	EnvNameExecutorRecord = "TIUP_CLUSTER_RECORD"
+
+	// EnvNameExecutorReplay is the variable name by which user can replay the results recorded in a file instead of executing the commands on the hosts
+	EnvNameExecutorReplay = "TIUP_CLUSTER_REPLAY"
+
 	// EnvNameKeepSourceTarget is the variable name by which user can keep the source target or not
 	EnvNameKeepSourceTarget = "TIUP_KEEP_SOURCE_TARGET"
 
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -38,13 +39,67 @@ const (
 	DisplayModeDefault DisplayMode = iota // default is the interactive output
 	DisplayModePlain                      // plain text
 	DisplayModeJSON                       // JSON
+	DisplayModeYAML                       // YAML
 )
 
+// Structured returns if the output is machine-readable in the display mode
+func (m DisplayMode) Structured() bool {
+	return m == DisplayModeJSON || m == DisplayModeYAML
+}
+
+// ParseDisplayMode returns the display mode of the format name
+func ParseDisplayMode(m string) DisplayMode {
+	return fmtDisplayMode(m)
+}
+
+// Marshal encodes the value in the structured display mode, a JSON line for
+// JSON, or a YAML document for YAML, the default and plain modes print the
+// value as is.
+func Marshal(mode DisplayMode, v any) ([]byte, error) {
+	switch mode {
+	case DisplayModeJSON:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case DisplayModeYAML:
+		// encode as JSON first so the YAML output shares the schema of the
+		// JSON one, the order of the keys is kept by decoding into a node
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		resetNodeStyle(&node)
+		data, err = yaml.Marshal(&node)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte("---\n"), data...), nil
+	default:
+		return []byte(fmt.Sprintln(v)), nil
+	}
+}
+
+// resetNodeStyle removes the flow and quoted styles of the JSON document
+func resetNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetNodeStyle(n)
+	}
+}
+
 func fmtDisplayMode(m string) DisplayMode {
 	var dp DisplayMode
 	switch strings.ToLower(m) {
 	case "json":
 		dp = DisplayModeJSON
+	case "yaml", "yml":
+		dp = DisplayModeYAML
 	case "plain", "text":
 		dp = DisplayModePlain
 	default:
@@ -55,7 +110,7 @@ func fmtDisplayMode(m string) DisplayMode {
 
 func printLog(w io.Writer, mode DisplayMode, level, format string, args ...any) {
 	switch mode {
-	case DisplayModeJSON:
+	case DisplayModeJSON, DisplayModeYAML:
 		obj := struct {
 			Level string `json:"level"`
 			Msg   string `json:"message"`
@@ -63,12 +118,12 @@ func printLog(w io.Writer, mode DisplayMode, level, format string, args ...any)
 			Level: level,
 			Msg:   fmt.Sprintf(format, args...),
 		}
-		data, err := json.Marshal(obj)
+		data, err := Marshal(mode, obj)
 		if err != nil {
 			_, _ = fmt.Fprintf(w, "{\"error\":\"%s\"}", err)
 			return
 		}
-		_, _ = fmt.Fprint(w, string(data)+"\n")
+		_, _ = w.Write(data)
 	default:
 		_, _ = fmt.Fprintf(w, format+"\n", args...)
 	}
@@ -115,6 +170,16 @@ func Errorf(format string, args ...any) {
 	printLog(stderr, outputFmt, "error", format, args...)
 }
 
+// Output prints the value to stdout in the global display mode
+func Output(v any) error {
+	data, err := Marshal(outputFmt, v)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
 // SetStdout redirect stdout to a custom writer
 func SetStdout(w io.Writer) {
 	stdout = w
@@ -94,3 +94,14 @@ func (l *Logger) Errorf(format string, args ...any) {
 	zap.L().Error(fmt.Sprintf(format, args...))
 	printLog(l.stderr, l.outputFmt, "error", format, args...)
 }
+
+// Output prints the value to stdout in the display mode of logger, e.g. the
+// result of a command in the structured display modes
+func (l *Logger) Output(v any) error {
+	data, err := Marshal(l.outputFmt, v)
+	if err != nil {
+		return err
+	}
+	_, err = l.stdout.Write(data)
+	return err
+}
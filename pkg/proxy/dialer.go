@@ -0,0 +1,152 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	perrs "github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
+	"golang.org/x/crypto/ssh"
+)
+
+// dialTimeout is the timeout to connect to the hosts not behind jump hosts
+const dialTimeout = 10 * time.Second
+
+type sshConn struct {
+	cli   *ssh.Client
+	close func()
+}
+
+// SSHDialer dials the addresses through the SSH jump hosts of their hosts, the
+// hosts without their own jump hosts are dialed through the default ones, or
+// directly if there is none. The jump hosts are in the ProxyJump format of
+// OpenSSH, and the connections to them are reused.
+type SSHDialer struct {
+	l      sync.Mutex
+	base   executor.SSHConfig  // the user and credentials to login to the jump hosts
+	def    string              // the default jump hosts
+	routes map[string]string   // the jump hosts of the hosts
+	conns  map[string]*sshConn // the connections through the jump hosts, by the jump hosts
+}
+
+// NewSSHDialer creates a dialer with the default jump hosts, the fields other
+// than the host of the jump hosts are copied from base
+func NewSSHDialer(jump string, base executor.SSHConfig) (*SSHDialer, error) {
+	if jump != "" {
+		if _, err := executor.ParseProxyJump(jump, base); err != nil {
+			return nil, err
+		}
+	}
+	return &SSHDialer{
+		base:   base,
+		def:    jump,
+		routes: make(map[string]string),
+		conns:  make(map[string]*sshConn),
+	}, nil
+}
+
+// Route sets the jump hosts of the hosts, the fields other than the host of the
+// jump hosts are copied from base
+func (d *SSHDialer) Route(routes map[string]string, base executor.SSHConfig) error {
+	for _, jump := range routes {
+		if _, err := executor.ParseProxyJump(jump, base); err != nil {
+			return err
+		}
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	d.base = base
+	for host, jump := range routes {
+		d.routes[host] = jump
+	}
+	return nil
+}
+
+// DialContext connects to the address through the jump hosts of its host
+func (d *SSHDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, perrs.AddStack(err)
+	}
+
+	d.l.Lock()
+	jump, ok := d.routes[host]
+	if !ok {
+		jump = d.def
+	}
+	d.l.Unlock()
+	if jump == "" {
+		return (&net.Dialer{Timeout: dialTimeout}).DialContext(ctx, network, addr)
+	}
+
+	conn, err := d.connect(jump)
+	if err != nil {
+		return nil, err
+	}
+	// reuse the old connection if dial success
+	if c, err := conn.cli.Dial(network, addr); err == nil {
+		return c, nil
+	}
+
+	// the connection may be broken, reconnect once
+	d.drop(jump, conn)
+	if conn, err = d.connect(jump); err != nil {
+		return nil, err
+	}
+	return conn.cli.Dial(network, addr)
+}
+
+// Close closes the connections to the jump hosts
+func (d *SSHDialer) Close() {
+	d.l.Lock()
+	defer d.l.Unlock()
+	for jump, conn := range d.conns {
+		conn.close()
+		delete(d.conns, jump)
+	}
+}
+
+func (d *SSHDialer) connect(jump string) (*sshConn, error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	if conn, ok := d.conns[jump]; ok {
+		return conn, nil
+	}
+
+	proxy, err := executor.ParseProxyJump(jump, d.base)
+	if err != nil {
+		return nil, err
+	}
+	cli, closeFn, err := executor.ConnectSSH(proxy)
+	if err != nil {
+		return nil, perrs.Annotatef(err, "connect to ssh proxy %s", jump)
+	}
+	conn := &sshConn{cli: cli, close: closeFn}
+	d.conns[jump] = conn
+	return conn, nil
+}
+
+func (d *SSHDialer) drop(jump string, conn *sshConn) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	if d.conns[jump] == conn {
+		conn.close()
+		delete(d.conns, jump)
+	}
+}
@@ -18,78 +18,24 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"strconv"
 	"sync"
-	"time"
 
-	"github.com/appleboy/easyssh-proxy"
-	perrs "github.com/pingcap/errors"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/ssh"
 )
 
 // HTTPProxy stands for a http proxy based on SSH connection
 type HTTPProxy struct {
-	cli    *ssh.Client
-	config *easyssh.MakeConfig
-	l      sync.RWMutex
 	tr     *http.Transport
 	logger *logprinter.Logger
 }
 
 // NewHTTPProxy creates and initializes a new http proxy
-func NewHTTPProxy(host string,
-	port int,
-	user, password, keyFile, passphrase string,
-	logger *logprinter.Logger,
-) *HTTPProxy {
-	p := &HTTPProxy{
-		config: &easyssh.MakeConfig{
-			Server:  host,
-			Port:    strconv.Itoa(port),
-			User:    user,
-			Timeout: 10 * time.Second,
-		},
+func NewHTTPProxy(dialer *SSHDialer, logger *logprinter.Logger) *HTTPProxy {
+	return &HTTPProxy{
+		tr:     &http.Transport{DialContext: dialer.DialContext},
 		logger: logger,
 	}
-
-	if len(keyFile) > 0 {
-		p.config.KeyPath = keyFile
-		p.config.Passphrase = passphrase
-	} else if len(password) > 0 {
-		p.config.Password = password
-	}
-
-	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
-		p.l.RLock()
-		cli := p.cli
-		p.l.RUnlock()
-
-		// reuse the old client if dial success
-		if cli != nil {
-			c, err := cli.Dial(network, addr)
-			if err == nil {
-				return c, nil
-			}
-		}
-
-		// create a new ssh client
-		// timeout is implemented inside easyssh, don't need to repeat the implementation
-		_, cli, err := p.config.Connect()
-		if err != nil {
-			return nil, perrs.Annotate(err, "connect to ssh proxy")
-		}
-
-		p.l.Lock()
-		p.cli = cli
-		p.l.Unlock()
-
-		return cli.Dial(network, addr)
-	}
-
-	p.tr = &http.Transport{DialContext: dial}
-	return p
 }
 
 // ServeHTTP implements http.Handler
@@ -21,6 +21,7 @@ import (
 	"sync/atomic"
 
 	"github.com/fatih/color"
+	"github.com/pingcap/tiup/pkg/cluster/executor"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/tui"
 	"github.com/pingcap/tiup/pkg/utils"
@@ -29,9 +30,11 @@ import (
 var (
 	httpProxy *http.Server
 	tcpProxy  atomic.Value
+	dialer    *SSHDialer
 )
 
-// MaybeStartProxy maybe starts an inner http/tcp proxies
+// MaybeStartProxy maybe starts an inner http/tcp proxies, the host may be a
+// chain of jump hosts in the ProxyJump format
 func MaybeStartProxy(
 	host string,
 	port int,
@@ -49,20 +52,51 @@ func MaybeStartProxy(
 		return err
 	}
 
+	d, err := NewSSHDialer(host, executor.SSHConfig{
+		Port:       port,
+		User:       user,
+		Password:   sshProps.Password,
+		KeyFile:    sshProps.IdentityFile,
+		Passphrase: sshProps.IdentityFilePassphrase,
+		SSHAgent:   sshProps.SSHAgent,
+		Timeout:    dialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	start(d, logger)
+	return nil
+}
+
+// MaybeRouteProxy routes the requests to the hosts through their jump hosts,
+// the inner http/tcp proxies are started if they are not yet. The fields other
+// than the host of the jump hosts are copied from base, including the default
+// jump hosts if the proxies are started already.
+func MaybeRouteProxy(routes map[string]string, base executor.SSHConfig, logger *logprinter.Logger) error {
+	if dialer == nil {
+		if len(routes) == 0 {
+			return nil
+		}
+		d, err := NewSSHDialer("", base)
+		if err != nil {
+			return err
+		}
+		start(d, logger)
+	}
+	return dialer.Route(routes, base)
+}
+
+func start(d *SSHDialer, logger *logprinter.Logger) {
+	dialer = d
+
 	httpPort := utils.MustGetFreePort("127.0.0.1", 12345, 0)
 	addr := fmt.Sprintf("127.0.0.1:%d", httpPort)
 
 	// TODO: Using environment variables to share data may not be a good idea
 	os.Setenv("TIUP_INNER_HTTP_PROXY", "http://"+addr)
 	httpProxy = &http.Server{
-		Addr: addr,
-		Handler: NewHTTPProxy(
-			host, port, user,
-			sshProps.Password,
-			sshProps.IdentityFile,
-			sshProps.IdentityFilePassphrase,
-			logger,
-		),
+		Addr:    addr,
+		Handler: NewHTTPProxy(d, logger),
 	}
 
 	logger.Infof("%s", color.HiGreenString("Start HTTP inner proxy %s", httpProxy.Addr))
@@ -72,18 +106,10 @@ func MaybeStartProxy(
 		}
 	}()
 
-	p := NewTCPProxy(
-		host, port, user,
-		sshProps.Password,
-		sshProps.IdentityFile,
-		sshProps.IdentityFilePassphrase,
-		logger,
-	)
+	p := NewTCPProxy(d, logger)
 	tcpProxy.Store(p)
 
 	logger.Infof("%s", color.HiGreenString("Start TCP inner proxy %s", p.endpoint))
-
-	return nil
 }
 
 // MaybeStopProxy stops the http/tcp proxies if it has been started before
@@ -95,6 +121,9 @@ func MaybeStopProxy() {
 	if p := tcpProxy.Load(); p != nil {
 		_ = p.(*TCPProxy).Stop()
 	}
+	if dialer != nil {
+		dialer.Close()
+	}
 }
 
 // GetTCPProxy returns the tcp proxy
@@ -14,59 +14,37 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/appleboy/easyssh-proxy"
-	perrs "github.com/pingcap/errors"
 	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
 	"github.com/pingcap/tiup/pkg/utils"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/ssh"
 )
 
 // TCPProxy represents a simple TCP proxy
 // unlike HTTP proxies, TCP proxies are point-to-point
 type TCPProxy struct {
-	l        sync.RWMutex
 	listener net.Listener
-	cli      *ssh.Client
-	config   *easyssh.MakeConfig
+	dialer   *SSHDialer
 	closed   int32
 	endpoint string
 	logger   *logprinter.Logger
 }
 
 // NewTCPProxy starts a 1to1 TCP proxy
-func NewTCPProxy(
-	host string,
-	port int,
-	user, password, keyFile, passphrase string,
-	logger *logprinter.Logger,
-) *TCPProxy {
+func NewTCPProxy(dialer *SSHDialer, logger *logprinter.Logger) *TCPProxy {
 	p := &TCPProxy{
-		config: &easyssh.MakeConfig{
-			Server:  host,
-			Port:    strconv.Itoa(port),
-			User:    user,
-			Timeout: 10 * time.Second,
-		},
+		dialer: dialer,
 		logger: logger,
 	}
 
-	if len(keyFile) > 0 {
-		p.config.KeyPath = keyFile
-		p.config.Passphrase = passphrase
-	} else if len(password) > 0 {
-		p.config.Password = password
-	}
-
-	port = utils.MustGetFreePort("127.0.0.1", 22345, 0)
+	port := utils.MustGetFreePort("127.0.0.1", 22345, 0)
 	p.endpoint = fmt.Sprintf("127.0.0.1:%d", port)
 
 	listener, err := net.Listen("tcp", p.endpoint)
@@ -120,43 +98,14 @@ func (p *TCPProxy) Close(c chan struct{}) {
 	close(c)
 }
 
-func (p *TCPProxy) getConn() (*ssh.Client, error) {
-	p.l.RLock()
-	cli := p.cli
-	p.l.RUnlock()
-
-	// reuse the old client if dial success
-	if cli != nil {
-		return cli, nil
-	}
-
-	// create a new ssh client
-	_, cli, err := p.config.Connect()
-	if err != nil {
-		return nil, perrs.Annotate(err, "connect to ssh proxy")
-	}
-
-	p.l.Lock()
-	p.cli = cli
-	p.l.Unlock()
-
-	return cli, nil
-}
-
 func (p *TCPProxy) forward(localConn io.ReadWriter, endpoints []string) {
-	cli, err := p.getConn()
-	if err != nil {
-		zap.L().Error("Failed to get ssh client", zap.String("error", err.Error()))
-		return
-	}
-
 	var remoteConn net.Conn
 OUTER_LOOP:
 	for _, endpoint := range endpoints {
 		errC := make(chan error, 1)
 		go func() {
 			var err error
-			remoteConn, err = cli.Dial("tcp", endpoint)
+			remoteConn, err = p.dialer.DialContext(context.Background(), "tcp", endpoint)
 			if err != nil {
 				zap.L().Error("Failed to connect endpoint", zap.String("error", err.Error()))
 			}
@@ -174,12 +123,17 @@ OUTER_LOOP:
 		}
 	}
 
+	if remoteConn == nil {
+		zap.L().Error("Failed to connect all endpoints", zap.Strings("endpoints", endpoints))
+		return
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
 	go func() {
 		defer wg.Done()
-		_, err = io.Copy(remoteConn, localConn)
+		_, err := io.Copy(remoteConn, localConn)
 		if err != nil {
 			zap.L().Error("Failed to copy from local to remote", zap.String("error", err.Error()))
 		}
@@ -187,7 +141,7 @@ OUTER_LOOP:
 
 	go func() {
 		defer wg.Done()
-		_, err = io.Copy(localConn, remoteConn)
+		_, err := io.Copy(localConn, remoteConn)
 		if err != nil {
 			zap.L().Error("Failed to copy from remote to local", zap.String("error", err.Error()))
 		}
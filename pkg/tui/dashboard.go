@@ -0,0 +1,364 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/fatih/color"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiup/pkg/utils"
+	"golang.org/x/term"
+)
+
+const (
+	// the help line of the dashboard
+	dashboardHelp = "Keys: </> sort column, r reverse order, f filter, q quit"
+
+	keyCtrlC = 3
+	keyCtrlD = 4
+
+	screenEnter = "\033[?1049h\033[?25l" // switch to the alternate screen and hide the cursor
+	screenLeave = "\033[?25h\033[?1049l" // show the cursor and switch back to the main screen
+)
+
+var durationCell = regexp.MustCompile(`^(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?$`)
+
+// Dashboard shows the rows returned by the refresh function as a table in the
+// full screen of the terminal and refreshes them periodically. The rows can be
+// sorted by any column and filtered by the values of the filter column with the
+// keys, e.g. to show the instances of a role only.
+type Dashboard struct {
+	title    string
+	header   []string
+	interval time.Duration
+	refresh  func() ([][]string, error)
+
+	sortColumn   int
+	reverse      bool
+	filterColumn int // -1 if the rows can not be filtered
+	filter       string
+
+	rows    [][]string
+	err     error
+	updated time.Time
+}
+
+// NewDashboard creates a Dashboard refreshed every 2 seconds
+func NewDashboard(title string, header []string, refresh func() ([][]string, error)) *Dashboard {
+	return &Dashboard{
+		title:        title,
+		header:       header,
+		interval:     2 * time.Second,
+		refresh:      refresh,
+		filterColumn: -1,
+	}
+}
+
+// SetInterval changes the refresh interval of the dashboard
+func (d *Dashboard) SetInterval(interval time.Duration) *Dashboard {
+	if interval > 0 {
+		d.interval = interval
+	}
+	return d
+}
+
+// SetSort sorts the rows by the column, which is the header or its key in the
+// structured display modes, e.g. "Data Dir" or "data_dir"
+func (d *Dashboard) SetSort(column string, reverse bool) error {
+	i, err := d.column(column)
+	if err != nil {
+		return err
+	}
+	d.sortColumn, d.reverse = i, reverse
+	return nil
+}
+
+// SetFilter filters the rows by the value of the column, an empty value shows
+// all the rows, the value can be changed with the f key later
+func (d *Dashboard) SetFilter(column, value string) error {
+	i, err := d.column(column)
+	if err != nil {
+		return err
+	}
+	d.filterColumn, d.filter = i, value
+	return nil
+}
+
+func (d *Dashboard) column(name string) (int, error) {
+	keys := make([]string, 0, len(d.header))
+	for i, h := range d.header {
+		if utils.TableKey(h) == utils.TableKey(name) {
+			return i, nil
+		}
+		keys = append(keys, utils.TableKey(h))
+	}
+	return -1, errors.Errorf("unknown column '%s', available columns are: %s", name, strings.Join(keys, ", "))
+}
+
+// Update refreshes the rows of the dashboard
+func (d *Dashboard) Update() error {
+	d.rows, d.err = d.refresh()
+	d.updated = time.Now()
+	return d.err
+}
+
+// Rows returns the rows filtered and sorted
+func (d *Dashboard) Rows() [][]string {
+	rows := make([][]string, 0, len(d.rows))
+	for _, row := range d.rows {
+		if d.filter != "" && d.filterColumn < len(row) &&
+			utils.StripColors(row[d.filterColumn]) != d.filter {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b []string) int {
+		var c int
+		if d.sortColumn < len(a) && d.sortColumn < len(b) {
+			c = compareCells(a[d.sortColumn], b[d.sortColumn])
+		}
+		if d.reverse {
+			return -c
+		}
+		return c
+	})
+	return rows
+}
+
+// Frame renders the dashboard as lines of text
+func (d *Dashboard) Frame() string {
+	var b strings.Builder
+	updated := "-"
+	if !d.updated.IsZero() {
+		updated = d.updated.Format("15:04:05")
+	}
+	fmt.Fprintf(&b, "%s - updated at %s, refreshed every %s\n", color.HiCyanString(d.title), updated, d.interval)
+
+	order := "ascending"
+	if d.reverse {
+		order = "descending"
+	}
+	filter := "none"
+	if d.filterColumn >= 0 && d.filter != "" {
+		filter = fmt.Sprintf("%s=%s", utils.TableKey(d.header[d.filterColumn]), d.filter)
+	}
+	fmt.Fprintf(&b, "Sort: %s (%s)  Filter: %s  %s\n", utils.TableKey(d.header[d.sortColumn]), order, filter, dashboardHelp)
+	if d.err != nil {
+		fmt.Fprintf(&b, "%s\n", color.RedString("Error: %s", d.err))
+	}
+	b.WriteString("\n")
+
+	if d.updated.IsZero() {
+		b.WriteString("Loading...\n")
+		return b.String()
+	}
+	header := slices.Clone(d.header)
+	header[d.sortColumn] = color.New(color.Underline).Sprint(header[d.sortColumn])
+	b.WriteString(renderTable(append([][]string{header}, d.Rows()...), true))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// handleKey changes the dashboard by the key, it returns false if the key is
+// to quit
+func (d *Dashboard) handleKey(key byte) bool {
+	switch key {
+	case 'q', 'Q', keyCtrlC, keyCtrlD:
+		return false
+	case '>', '.':
+		d.sortColumn = (d.sortColumn + 1) % len(d.header)
+	case '<', ',':
+		d.sortColumn = (d.sortColumn + len(d.header) - 1) % len(d.header)
+	case 'r', 'R':
+		d.reverse = !d.reverse
+	case 'f', 'F':
+		d.nextFilter()
+	}
+	return true
+}
+
+// nextFilter changes the filter to the next value of the filter column, and
+// back to none after the last one
+func (d *Dashboard) nextFilter() {
+	if d.filterColumn < 0 {
+		return
+	}
+	values := make([]string, 0)
+	for _, row := range d.rows {
+		if d.filterColumn < len(row) {
+			values = append(values, utils.StripColors(row[d.filterColumn]))
+		}
+	}
+	slices.Sort(values)
+	values = slices.Compact(values)
+
+	i := slices.Index(values, d.filter)
+	switch {
+	case d.filter == "" && len(values) > 0:
+		d.filter = values[0]
+	case i >= 0 && i+1 < len(values):
+		d.filter = values[i+1]
+	default:
+		d.filter = ""
+	}
+}
+
+// IsTerminal returns if both the standard input and output are terminals, the
+// dashboard can only run in a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Run shows the dashboard until it is quit with the q key or the context is
+// done, the standard input and output must be a terminal
+func (d *Dashboard) Run(ctx context.Context) error {
+	if !IsTerminal() {
+		return errors.New("the dashboard can only be shown in a terminal")
+	}
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+	fmt.Print(screenEnter)
+	defer fmt.Print(screenLeave)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, key := range translateKeys(buf[:n]) {
+				select {
+				case keys <- key:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	type result struct {
+		rows [][]string
+		err  error
+	}
+	results := make(chan result)
+	refresh := func() {
+		rows, err := d.refresh()
+		select {
+		case results <- result{rows, err}:
+		case <-done:
+		}
+	}
+	go refresh()
+
+	// the next refresh starts after the interval since the last one is done
+	var next <-chan time.Time
+	for {
+		d.draw()
+		select {
+		case <-ctx.Done():
+			return nil
+		case r := <-results:
+			d.rows, d.err, d.updated = r.rows, r.err, time.Now()
+			next = time.After(d.interval)
+		case <-next:
+			next = nil
+			go refresh()
+		case key, ok := <-keys:
+			if !ok || !d.handleKey(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// draw redraws the screen without clearing it to avoid flickering, the lines
+// end with "\r\n" as the terminal is in raw mode
+func (d *Dashboard) draw() {
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for _, line := range strings.Split(strings.TrimSuffix(d.Frame(), "\n"), "\n") {
+		b.WriteString(line)
+		b.WriteString("\033[K\r\n")
+	}
+	b.WriteString("\033[J")
+	fmt.Print(b.String())
+}
+
+// translateKeys translates the arrow keys to the keys changing the sort column
+func translateKeys(input []byte) []byte {
+	s := strings.NewReplacer("\033[C", ">", "\033[D", "<", "\033OC", ">", "\033OD", "<").Replace(string(input))
+	return []byte(s)
+}
+
+// compareCells compares the cells as numbers if both of them are numbers,
+// percentages, durations like "1d2h" or sizes like "1.5GiB", and the cells
+// which are not numbers, e.g. "-" are before the numbers
+func compareCells(a, b string) int {
+	a, b = utils.StripColors(a), utils.StripColors(b)
+	va, aok := cellValue(a)
+	vb, bok := cellValue(b)
+	switch {
+	case aok && bok:
+		return cmp.Compare(va, vb)
+	case aok:
+		return 1
+	case bok:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func cellValue(cell string) (float64, bool) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0, false
+	}
+	if v, err := strconv.ParseFloat(strings.TrimSuffix(cell, "%"), 64); err == nil {
+		return v, true
+	}
+	if m := durationCell.FindStringSubmatch(cell); m != nil {
+		var seconds float64
+		for i, unit := range []float64{24 * 3600, 3600, 60, 1} {
+			if n, err := strconv.Atoi(m[i+1]); err == nil {
+				seconds += float64(n) * unit
+			}
+		}
+		return seconds, true
+	}
+	if v, err := units.RAMInBytes(cell); err == nil {
+		return float64(v), true
+	}
+	return 0, false
+}
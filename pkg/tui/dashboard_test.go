@@ -0,0 +1,73 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCells(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		res  int
+	}{
+		{"9.5%", "10%", -1},
+		{"512MiB", "1.5GiB", -1},
+		{"1d2h", "3h4m5s", 1},
+		{"12", "12", 0},
+		{"-", "0%", -1},
+		{"tikv", "pd", 1},
+		{color.GreenString("Up"), "Down", 1},
+	} {
+		require.Equal(t, c.res, compareCells(c.a, c.b), "%s <=> %s", c.a, c.b)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	rows := [][]string{
+		{"172.16.5.1:2379", "pd", "12.5%"},
+		{"172.16.5.1:20160", "tikv", "80%"},
+		{"172.16.5.2:20160", "tikv", "-"},
+	}
+	d := NewDashboard("test", []string{"ID", "Role", "CPU"}, func() ([][]string, error) {
+		return rows, nil
+	})
+	require.Contains(t, d.Frame(), "Loading...")
+	require.NoError(t, d.Update())
+
+	require.Error(t, d.SetSort("memory", false))
+	require.NoError(t, d.SetSort("cpu", true))
+	require.NoError(t, d.SetFilter("Role", ""))
+	require.Equal(t, [][]string{rows[1], rows[0], rows[2]}, d.Rows())
+
+	// the sort column and the order are changed by the keys
+	require.True(t, d.handleKey('<'))
+	require.True(t, d.handleKey('r'))
+	require.Equal(t, [][]string{rows[0], rows[1], rows[2]}, d.Rows())
+
+	// the filter goes through the roles and back to none
+	require.True(t, d.handleKey('f'))
+	require.Equal(t, [][]string{rows[0]}, d.Rows())
+	require.True(t, d.handleKey('f'))
+	require.Equal(t, [][]string{rows[1], rows[2]}, d.Rows())
+	require.Contains(t, d.Frame(), "Filter: role=tikv")
+	require.True(t, d.handleKey('f'))
+	require.Len(t, d.Rows(), 3)
+
+	require.Equal(t, []byte("<>q"), translateKeys([]byte("\033[D\033[Cq")))
+	require.False(t, d.handleKey('q'))
+}
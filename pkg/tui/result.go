@@ -0,0 +1,57 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/utils"
+)
+
+// CommandResult is the result of a command in the structured display modes,
+// the type and the suggestion are included for the errorx errors
+type CommandResult struct {
+	Code       int    `json:"exit_code"`
+	Err        string `json:"error,omitempty"`
+	ErrType    string `json:"error_type,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// NewCommandResult returns the result of the command exited with the code
+func NewCommandResult(code int, err error) *CommandResult {
+	res := &CommandResult{Code: code}
+	if err == nil {
+		return res
+	}
+	res.Err = err.Error()
+	if errx := errorx.Cast(err); errx != nil {
+		res.ErrType = errx.Type().FullName()
+	}
+	res.Suggestion = ErrorSuggestion(err)
+	return res
+}
+
+// ErrorSuggestion returns the suggestion of the error or its causes, empty if
+// there is none
+func ErrorSuggestion(err error) string {
+	cause := errorx.Cast(err)
+	for cause != nil {
+		if v, ok := cause.Property(utils.ErrPropSuggestion); ok {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+		cause = errorx.Cast(cause.Cause())
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"testing"
+
+	"github.com/joomcode/errorx"
+	"github.com/pingcap/tiup/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandResult(t *testing.T) {
+	res := NewCommandResult(0, nil)
+	require.Equal(t, &CommandResult{}, res)
+
+	errNS := errorx.NewNamespace("test")
+	errType := errNS.NewType("failed")
+	err := errorx.Decorate(
+		errType.New("inner").WithProperty(utils.ErrPropSuggestion, "try again"),
+		"outer",
+	)
+	res = NewCommandResult(1, err)
+	require.Equal(t, 1, res.Code)
+	require.Equal(t, "test.failed", res.ErrType)
+	require.Equal(t, "try again", res.Suggestion)
+	require.Contains(t, res.Err, "outer")
+}
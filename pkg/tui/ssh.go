@@ -31,6 +31,7 @@ type SSHConnectionProps struct {
 	Password               string
 	IdentityFile           string
 	IdentityFilePassphrase string
+	SSHAgent               bool // use the keys in the ssh-agent of SSH_AUTH_SOCK
 }
 
 // ReadIdentityFileOrPassword is ReadIdentityFileOrPassword
@@ -89,6 +90,12 @@ Looks like your SSH private key {{ColorKeyword}}{{.File}}{{ColorReset}} is inval
 	}
 
 	// No password, nor identity file were specified, check ssh-agent via the env SSH_AUTH_SOCK
+	return ReadSSHAgent()
+}
+
+// ReadSSHAgent checks the ssh-agent of SSH_AUTH_SOCK, the keys in the agent and
+// their OpenSSH certificates are used instead of the identity file.
+func ReadSSHAgent() (*SSHConnectionProps, error) {
 	sshAuthSock := os.Getenv("SSH_AUTH_SOCK")
 	if len(sshAuthSock) == 0 {
 		return nil, ErrIdentityFileReadFailed.New("none of ssh password, identity file, SSH_AUTH_SOCK specified")
@@ -101,5 +108,5 @@ Looks like your SSH private key {{ColorKeyword}}{{.File}}{{ColorReset}} is inval
 		return nil, ErrIdentityFileReadFailed.New("The SSH_AUTH_SOCK file: '%s' is not a valid unix socket file", sshAuthSock)
 	}
 
-	return &SSHConnectionProps{}, nil
+	return &SSHConnectionProps{SSHAgent: true}, nil
 }
@@ -24,6 +24,8 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 
 	"github.com/fatih/color"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/pingcap/tiup/pkg/utils"
 	"github.com/pingcap/tiup/pkg/utils/mock"
 	"golang.org/x/term"
 )
@@ -35,9 +37,27 @@ func PrintTable(rows [][]string, header bool) {
 		return
 	}
 
-	// Print the table
+	// the rows are output as objects keyed by the header in the structured
+	// display modes
+	if mode := logprinter.GetDisplayMode(); mode.Structured() && header && len(rows) > 0 {
+		t := utils.NewTableDisplayer(os.Stdout, rows[0]).SetDisplayMode(mode)
+		for _, row := range rows[1:] {
+			t.AddRow(row...)
+		}
+		if err := t.Display(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print the table: %s\n", err)
+		}
+		return
+	}
+
+	if out := renderTable(rows, header); out != "" {
+		fmt.Println(out)
+	}
+}
+
+// renderTable renders the rows as ASCII table
+func renderTable(rows [][]string, header bool) string {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
 	t.SuppressTrailingSpaces()
 	if header {
 		addRow(t, rows[0], true)
@@ -79,7 +99,7 @@ func PrintTable(rows [][]string, header bool) {
 			SeparateColumns: true,
 		},
 	})
-	t.Render()
+	return t.Render()
 }
 
 func addRow(t table.Writer, rawLine []string, header bool) {
@@ -44,6 +44,39 @@ func ShowDiff(t1 string, t2 string, w io.Writer) {
 	fmt.Fprint(w, dmp.DiffPrettyText(diffs))
 }
 
+// DiffLine is a line added or removed in the diff result
+type DiffLine struct {
+	Op   string `json:"op"` // "insert" or "delete"
+	Line string `json:"line"`
+}
+
+// DiffLines returns the lines added or removed from t1 to t2 in order, the
+// unchanged lines are omitted.
+func DiffLines(t1 string, t2 string) []DiffLine {
+	dmp := diffmatchpatch.New()
+	c1, c2, lines := dmp.DiffLinesToChars(t1, t2)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(c1, c2, false), lines)
+
+	result := make([]DiffLine, 0)
+	for _, d := range diffs {
+		var op string
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = "insert"
+		case diffmatchpatch.DiffDelete:
+			op = "delete"
+		default:
+			continue
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				result = append(result, DiffLine{Op: op, Line: strings.TrimSuffix(line, "\n")})
+			}
+		}
+	}
+	return result
+}
+
 func validateExpandable(fromField, toField any) bool {
 	fromStr, ok := fromField.(string)
 	if !ok {
@@ -550,3 +550,14 @@ str2: "/ssd0/tiflash"
 	err = ValidateSpecDiff(d1, d2)
 	require.Error(t, err)
 }
+
+func TestDiffLines(t *testing.T) {
+	d1 := "a: 1\nb: 2\nc: 3\n"
+	d2 := "a: 1\nb: 20\nc: 3\nd: 4\n"
+	require.Equal(t, []DiffLine{
+		{Op: "delete", Line: "b: 2"},
+		{Op: "insert", Line: "b: 20"},
+		{Op: "insert", Line: "d: 4"},
+	}, DiffLines(d1, d2))
+	require.Empty(t, DiffLines(d1, d1))
+}
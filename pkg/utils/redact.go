@@ -0,0 +1,46 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// the patterns of the secrets redacted
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// password = "..." in the config files and password: ... in the topology
+	{regexp.MustCompile(`(?i)^(\s*["']?[\w.-]*(?:password|passwd|secret|token)["']?\s*[:=][ \t]*)\S.*$`), `${1}"******"`},
+	// --password=... or --password ... in the command lines
+	{regexp.MustCompile(`(?i)(--?[\w-]*(?:password|passwd|secret|token)[= ])\S+`), `${1}******`},
+	// user:password@tcp(host:port) in the DSNs
+	{regexp.MustCompile(`([\w.-]+):[^@\s/:]+@(tcp|unix)\(`), `${1}:******@${2}(`},
+}
+
+// RedactSecrets replaces the secrets in the text with asterisks line by line,
+// e.g. the passwords in the configs, the command lines and the DSNs
+func RedactSecrets(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		for _, p := range secretPatterns {
+			body = p.re.ReplaceAllString(body, p.repl)
+		}
+		lines[i] = body + line[len(strings.TrimRight(line, "\r\n")):]
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	for _, c := range []struct {
+		text, expected string
+	}{
+		{"password = \"123456\"\n", "password = \"******\"\n"},
+		{"  grafana_password: admin", "  grafana_password: \"******\""},
+		{"\"security.session-token\" = 'abc'\r\n", "\"security.session-token\" = \"******\"\r\n"},
+		{"token-limit = 1000\n", "token-limit = 1000\n"},
+		{"exec bin/tidb-server --password=abc --port 4000", "exec bin/tidb-server --password=****** --port 4000"},
+		{"dsn root:secret@tcp(127.0.0.1:4000)/test", "dsn root:******@tcp(127.0.0.1:4000)/test"},
+		{"[INFO] [server.go:100] [\"connected\"]\n", "[INFO] [server.go:100] [\"connected\"]\n"},
+		{"[security]\nadmin_password = abc\r\nadmin_user = admin\n", "[security]\nadmin_password = \"******\"\r\nadmin_user = admin\n"},
+	} {
+		require.Equal(t, c.expected, RedactSecrets(c.text))
+	}
+}
@@ -3,14 +3,25 @@ package utils
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
+
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+)
+
+var (
+	ansiEscape  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	nonKeyChars = regexp.MustCompile(`[^a-z0-9]+`)
 )
 
-// TableDisplayer is a simple table displayer
+// TableDisplayer is a simple table displayer, the rows are output as a list of
+// objects keyed by the header in the structured display modes, e.g.
+// [{"id": "...", "status": "Up"}] for the header "ID" and "Status" in JSON
 type TableDisplayer struct {
 	Header []string
 	Rows   [][]string
 	Writer io.Writer
+	Mode   logprinter.DisplayMode
 }
 
 // NewTableDisplayer creates a new TableDisplayer
@@ -21,6 +32,12 @@ func NewTableDisplayer(w io.Writer, header []string) *TableDisplayer {
 	}
 }
 
+// SetDisplayMode changes the display mode of the table
+func (t *TableDisplayer) SetDisplayMode(m logprinter.DisplayMode) *TableDisplayer {
+	t.Mode = m
+	return t
+}
+
 // AddRow adds a row to the table
 func (t *TableDisplayer) AddRow(row ...string) {
 	// cut items if row is longer than header
@@ -30,8 +47,49 @@ func (t *TableDisplayer) AddRow(row ...string) {
 	t.Rows = append(t.Rows, row)
 }
 
+// TableKey returns the key of the column in the structured display modes, the
+// header in snake case, e.g. "data_dir" for "Data Dir"
+func TableKey(header string) string {
+	key := nonKeyChars.ReplaceAllString(strings.ToLower(StripColors(header)), "_")
+	return strings.Trim(key, "_")
+}
+
+// StripColors removes the colors of the text
+func StripColors(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// Records returns the rows as objects keyed by the header, the colors of the
+// cells are removed
+func (t *TableDisplayer) Records() []map[string]string {
+	keys := make([]string, len(t.Header))
+	for i, h := range t.Header {
+		keys[i] = TableKey(h)
+	}
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(keys))
+		for i, cell := range row {
+			if i < len(keys) {
+				record[keys[i]] = StripColors(cell)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
 // Display the table
-func (t *TableDisplayer) Display() {
+func (t *TableDisplayer) Display() error {
+	if t.Mode.Structured() {
+		data, err := logprinter.Marshal(t.Mode, t.Records())
+		if err != nil {
+			return err
+		}
+		_, err = t.Writer.Write(data)
+		return err
+	}
+
 	lens := make([]int, len(t.Header))
 	for i, h := range t.Header {
 		lens[i] = len(h)
@@ -56,4 +114,5 @@ func (t *TableDisplayer) Display() {
 		}
 		fmt.Fprintf(t.Writer, "%s\n", row[len(row)-1])
 	}
+	return nil
 }
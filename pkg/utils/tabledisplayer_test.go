@@ -0,0 +1,63 @@
+// Copyright 2025 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	logprinter "github.com/pingcap/tiup/pkg/logger/printer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableKey(t *testing.T) {
+	require.Equal(t, "id", TableKey("ID"))
+	require.Equal(t, "data_dir", TableKey("Data Dir"))
+	require.Equal(t, "host_port", TableKey("Host:Port "))
+	require.Equal(t, "status", TableKey("\x1b[32mStatus\x1b[0m"))
+}
+
+func TestTableDisplayer(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	buf := &bytes.Buffer{}
+	table := NewTableDisplayer(buf, []string{"ID", "Status"})
+	table.AddRow("172.16.5.1:2379", "Up")
+	table.AddRow("172.16.5.2:2379", "Down")
+	require.NoError(t, table.Display())
+	require.Equal(t, `ID               Status
+--               ------
+172.16.5.1:2379  Up
+172.16.5.2:2379  Down
+`, buf.String())
+
+	buf.Reset()
+	table = NewTableDisplayer(buf, []string{"ID", "Status"}).SetDisplayMode(logprinter.DisplayModeJSON)
+	table.AddRow("172.16.5.1:2379", color.GreenString("Up"))
+	table.AddRow("172.16.5.2:2379", color.RedString("Down"))
+	require.NoError(t, table.Display())
+	require.Equal(t, `[{"id":"172.16.5.1:2379","status":"Up"},{"id":"172.16.5.2:2379","status":"Down"}]`+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, table.SetDisplayMode(logprinter.DisplayModeYAML).Display())
+	require.Equal(t, `---
+- id: 172.16.5.1:2379
+  status: Up
+- id: 172.16.5.2:2379
+  status: Down
+`, buf.String())
+}
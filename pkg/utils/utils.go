@@ -85,3 +85,8 @@ func ParseHostPort(hostport string) (host, port string) {
 	port = hostport[colon+1:]
 	return
 }
+
+// ShellQuote quotes the string as a single argument of the shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}